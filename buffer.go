@@ -1,6 +1,7 @@
 package json
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
 	"io"
@@ -15,6 +16,8 @@ type buffer struct {
 	last  States
 	state States
 	class Classes
+
+	comments bool // comments allows skipping comments as whitespace.
 }
 
 // newBuffer creates a new buffer with the given data
@@ -32,14 +35,61 @@ func (b *buffer) first() (byte, error) {
 	for ; b.index < b.length; b.index++ {
 		c := b.data[b.index]
 
-		if !(c == whiteSpace || c == carriageReturn || c == newLine || c == tab) {
-			return c, nil
+		if c == whiteSpace || c == carriageReturn || c == newLine || c == tab {
+			continue
+		}
+
+		if b.comments && c == slash {
+			ok, err := b.comment()
+			if err != nil {
+				return 0, err
+			}
+
+			if ok {
+				continue
+			}
 		}
+
+		return c, nil
 	}
 
 	return 0, io.EOF
 }
 
+// comment skips the comment starting at the current index and moves the index
+// to the last byte of the comment. It returns false if there is no comment.
+//
+// Both `//` line comments (terminated by a newline or the end of data)
+// and `/* */` block comments are supported.
+func (b *buffer) comment() (bool, error) {
+	if b.index+1 >= b.length || b.data[b.index] != slash {
+		return false, nil
+	}
+
+	switch b.data[b.index+1] {
+	case slash:
+		end := bytes.IndexByte(b.data[b.index+2:], newLine)
+		if end == -1 {
+			b.index = b.length - 1
+		} else {
+			b.index += end + 2
+		}
+
+		return true, nil
+
+	case aesterisk:
+		end := bytes.Index(b.data[b.index+2:], []byte("*/"))
+		if end == -1 {
+			return false, newSyntaxError(b.index, "unterminated comment")
+		}
+
+		b.index += end + 3
+		return true, nil
+	}
+
+	return false, nil
+}
+
 // current returns the byte of the current index.
 func (b *buffer) current() (byte, error) {
 	if b.index >= b.length {
@@ -277,6 +327,8 @@ func (b *buffer) pathToken() error {
 			inToken = true
 
 		case c == plus || c == minus:
+			// unlike JSON numbers (see ParseFloatLiteral), a signed number is accepted
+			// in path expressions, e.g. `$[?(@.price > +10)]`.
 			if inNumber || (b.index > 0 && numIndex[b.data[b.index-1]]) {
 				inToken = true
 			} else if !inToken && (b.index+1 < b.length && numIndex[b.data[b.index+1]]) {
@@ -339,6 +391,10 @@ func (b *buffer) numeric(token bool) error {
 	}
 
 	for ; b.index < b.length; b.index++ {
+		if b.comments && b.data[b.index] == slash {
+			break
+		}
+
 		b.class = b.getClasses(doubleQuote)
 		if b.class == __ {
 			return errors.New("invalid token found while parsing path")
@@ -421,6 +477,47 @@ func (b *buffer) string(search byte, token bool) error {
 		b.last = b.state
 	}
 
+	if b.index >= b.length {
+		return errors.New("unterminated string")
+	}
+
+	return nil
+}
+
+// identifier parses an unquoted identifier from the buffer and moves the index
+// to the first byte after the identifier.
+func (b *buffer) identifier() (*string, error) {
+	start := b.index
+	if start >= b.length || !isIdentifierStart(b.data[start]) {
+		return nil, errors.New("invalid identifier")
+	}
+
+	for b.index < b.length && isIdentifierPart(b.data[b.index]) {
+		b.index++
+	}
+
+	ident := string(b.data[start:b.index])
+	return &ident, nil
+}
+
+func isIdentifierStart(c byte) bool {
+	return 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || c == underScore || c == dollarSign
+}
+
+func isIdentifierPart(c byte) bool {
+	return isIdentifierStart(c) || '0' <= c && c <= '9'
+}
+
+// nonFinite returns the NaN or Infinity literal starting at the current index, or nil if there is none.
+func (b *buffer) nonFinite() []byte {
+	rest := b.data[b.index:]
+
+	for _, literal := range [][]byte{nanLiteral, infinityLiteral, negInfinityLiteral} {
+		if bytes.HasPrefix(rest, literal) {
+			return literal
+		}
+	}
+
 	return nil
 }
 
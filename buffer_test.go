@@ -622,3 +622,71 @@ func TestBufferFirst(t *testing.T) {
 		})
 	}
 }
+
+func TestBufferFirst_Comments(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     []byte
+		expected byte
+		index    int
+		isErr    bool
+	}{
+		{
+			name:     "line comment",
+			data:     []byte("// comment\n  x"),
+			expected: 'x',
+			index:    13,
+		},
+		{
+			name:     "block comment",
+			data:     []byte("/* comment */x"),
+			expected: 'x',
+			index:    13,
+		},
+		{
+			name:     "multiple comments",
+			data:     []byte(" /**/ // a\n /* b */ x"),
+			expected: 'x',
+			index:    20,
+		},
+		{
+			name:     "not a comment",
+			data:     []byte("/x"),
+			expected: '/',
+			index:    0,
+		},
+		{
+			name:  "line comment until the end",
+			data:  []byte("// comment"),
+			isErr: true,
+		},
+		{
+			name:  "unterminated block comment",
+			data:  []byte("/* comment */ /* x"),
+			isErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := newBuffer(tt.data)
+			b.comments = true
+
+			got, err := b.first()
+			if tt.isErr {
+				if err == nil {
+					t.Errorf("Expected error, got %q", got)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			if got != tt.expected || b.index != tt.index {
+				t.Errorf("Expected %q at %d, got %q at %d", tt.expected, tt.index, got, b.index)
+			}
+		})
+	}
+}
@@ -0,0 +1,167 @@
+package json
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Builder constructs a JSON document with chained calls, instead of
+// creating each node and appending it to its parent by hand.
+//
+// Containers are opened with Object or Array and closed with End. Inside an object,
+// each value must be preceded by Key. The first misuse is recorded and returned by Build,
+// and the calls after it are ignored.
+//
+// Usage:
+//
+//	root, err := json.NewBuilder().
+//		Object().
+//			Key("name").Str("foo").
+//			Key("tags").Array().Str("a").Str("b").End().
+//		End().
+//		Build()
+//
+//	result: {"name": "foo", "tags": ["a", "b"]}
+type Builder struct {
+	root  *Node
+	stack []*Node // open containers, innermost last
+	key   *string // key of the next object member
+	err   error
+}
+
+// NewBuilder returns an empty Builder.
+func NewBuilder() *Builder {
+	return &Builder{}
+}
+
+// Object opens a new object. It must be closed with End.
+func (b *Builder) Object() *Builder {
+	node := ObjectNode("", nil)
+	if b.add(node) {
+		b.stack = append(b.stack, node)
+	}
+
+	return b
+}
+
+// Array opens a new array. It must be closed with End.
+func (b *Builder) Array() *Builder {
+	node := ArrayNode("", nil)
+	if b.add(node) {
+		b.stack = append(b.stack, node)
+	}
+
+	return b
+}
+
+// Key sets the key of the next value in the current object.
+func (b *Builder) Key(key string) *Builder {
+	if b.err != nil {
+		return b
+	}
+
+	switch {
+	case len(b.stack) == 0 || !b.stack[len(b.stack)-1].IsObject():
+		b.err = fmt.Errorf("key %q outside of an object", key)
+	case b.key != nil:
+		b.err = fmt.Errorf("key %q follows key %q without a value", key, *b.key)
+	default:
+		b.key = &key
+	}
+
+	return b
+}
+
+// Str adds a string value.
+func (b *Builder) Str(value string) *Builder {
+	b.add(StringNode("", value))
+	return b
+}
+
+// Num adds a number value.
+func (b *Builder) Num(value float64) *Builder {
+	b.add(NumberNode("", value))
+	return b
+}
+
+// Bool adds a boolean value.
+func (b *Builder) Bool(value bool) *Builder {
+	b.add(BoolNode("", value))
+	return b
+}
+
+// Null adds a null value.
+func (b *Builder) Null() *Builder {
+	b.add(NullNode(""))
+	return b
+}
+
+// End closes the innermost open object or array.
+func (b *Builder) End() *Builder {
+	if b.err != nil {
+		return b
+	}
+
+	switch {
+	case len(b.stack) == 0:
+		b.err = errors.New("end without an open object or array")
+	case b.key != nil:
+		b.err = fmt.Errorf("key %q has no value", *b.key)
+	default:
+		b.stack = b.stack[:len(b.stack)-1]
+	}
+
+	return b
+}
+
+// Build returns the constructed document. It returns the first misuse of the builder,
+// or an error if the document is empty or has containers that are not closed.
+func (b *Builder) Build() (*Node, error) {
+	if b.err != nil {
+		return nil, b.err
+	}
+
+	if b.root == nil {
+		return nil, ErrEmptyDocument
+	}
+
+	if len(b.stack) > 0 {
+		return nil, fmt.Errorf("%d unclosed object or array", len(b.stack))
+	}
+
+	return b.root, nil
+}
+
+// add attaches the node to the current container, or makes it the root.
+// It reports whether the node was added.
+func (b *Builder) add(node *Node) bool {
+	if b.err != nil {
+		return false
+	}
+
+	if len(b.stack) == 0 {
+		if b.root != nil {
+			b.err = errors.New("multiple top-level values")
+			return false
+		}
+
+		b.root = node
+		return true
+	}
+
+	parent := b.stack[len(b.stack)-1]
+	if parent.IsObject() {
+		if b.key == nil {
+			b.err = errors.New("object value without a key")
+			return false
+		}
+
+		key := *b.key
+		b.key = nil
+		b.err = parent.AppendObject(key, node)
+	} else {
+		b.err = parent.AppendArray(node)
+	}
+
+	return b.err == nil
+}
@@ -0,0 +1,138 @@
+package json
+
+import (
+	"errors"
+	"testing"
+)
+
+// Build the sample document from https://goessner.net/articles/JsonPath/index.html#e3
+func TestBuilder_StoreExample(t *testing.T) {
+	book := func(b *Builder, category, author, title, isbn string, price float64) *Builder {
+		b.Object().
+			Key("category").Str(category).
+			Key("author").Str(author).
+			Key("title").Str(title)
+		if isbn != "" {
+			b.Key("isbn").Str(isbn)
+		}
+
+		return b.Key("price").Num(price).End()
+	}
+
+	b := NewBuilder().Object().Key("store").Object().Key("book").Array()
+	book(b, "reference", "Nigel Rees", "Sayings of the Century", "", 8.95)
+	book(b, "fiction", "Evelyn Waugh", "Sword of Honour", "", 12.99)
+	book(b, "fiction", "Herman Melville", "Moby Dick", "0-553-21311-3", 8.99)
+	book(b, "fiction", "J. R. R. Tolkien", "The Lord of the Rings", "0-395-19395-8", 22.99)
+	b.End().
+		Key("bicycle").Object().
+		Key("color").Str("red").
+		Key("price").Num(19.95).
+		End().
+		Key("tools").Null().
+		End().
+		End()
+
+	root, err := b.Build()
+	if err != nil {
+		t.Fatalf("Build returns error: %v", err)
+	}
+
+	expected := Must(Unmarshal([]byte(`{"store": {
+		"book": [
+			{"category": "reference", "author": "Nigel Rees", "title": "Sayings of the Century", "price": 8.95},
+			{"category": "fiction", "author": "Evelyn Waugh", "title": "Sword of Honour", "price": 12.99},
+			{"category": "fiction", "author": "Herman Melville", "title": "Moby Dick", "isbn": "0-553-21311-3", "price": 8.99},
+			{"category": "fiction", "author": "J. R. R. Tolkien", "title": "The Lord of the Rings", "isbn": "0-395-19395-8", "price": 22.99}
+		],
+		"bicycle": {"color": "red", "price": 19.95},
+		"tools": null
+	}}`)))
+
+	if !equals(root, expected) {
+		t.Errorf("Build() = %s, want %s", root, expected)
+	}
+
+	if got := root.MustKey("store").MustKey("book").MustIndex(2).MustKey("title").MustString(); got != "Moby Dick" {
+		t.Errorf("book[2].title = %q, want Moby Dick", got)
+	}
+}
+
+func TestBuilder(t *testing.T) {
+	tests := []struct {
+		name     string
+		build    func(b *Builder) *Builder
+		expected string
+	}{
+		{
+			name:     "scalar root",
+			build:    func(b *Builder) *Builder { return b.Num(1.5) },
+			expected: `1.5`,
+		},
+		{
+			name:     "empty containers",
+			build:    func(b *Builder) *Builder { return b.Array().Object().End().Array().End().End() },
+			expected: `[{},[]]`,
+		},
+		{
+			name: "mixed values",
+			build: func(b *Builder) *Builder {
+				return b.Array().Str("a").Num(2).Bool(false).Null().End()
+			},
+			expected: `["a",2,false,null]`,
+		},
+		{
+			name: "nested object",
+			build: func(b *Builder) *Builder {
+				return b.Object().Key("a").Object().Key("b").Bool(true).End().End()
+			},
+			expected: `{"a":{"b":true}}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root, err := tt.build(NewBuilder()).Build()
+			if err != nil {
+				t.Fatalf("Build returns error: %v", err)
+			}
+
+			value, err := Marshal(root)
+			if err != nil {
+				t.Fatalf("Marshal returns error: %v", err)
+			}
+
+			if string(value) != tt.expected {
+				t.Errorf("Build() = %s, want %s", value, tt.expected)
+			}
+		})
+	}
+}
+
+func TestBuilder_Invalid(t *testing.T) {
+	tests := []struct {
+		name  string
+		build func(b *Builder) *Builder
+	}{
+		{"empty", func(b *Builder) *Builder { return b }},
+		{"unclosed", func(b *Builder) *Builder { return b.Object().Key("a").Array() }},
+		{"extra end", func(b *Builder) *Builder { return b.Array().End().End() }},
+		{"key outside object", func(b *Builder) *Builder { return b.Array().Key("a").Num(1).End() }},
+		{"missing key", func(b *Builder) *Builder { return b.Object().Num(1).End() }},
+		{"key without value", func(b *Builder) *Builder { return b.Object().Key("a").End() }},
+		{"two keys", func(b *Builder) *Builder { return b.Object().Key("a").Key("b").Num(1).End() }},
+		{"two roots", func(b *Builder) *Builder { return b.Num(1).Num(2) }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if root, err := tt.build(NewBuilder()).Build(); err == nil {
+				t.Errorf("Build() = %s, must fail", root)
+			}
+		})
+	}
+
+	if _, err := NewBuilder().Build(); !errors.Is(err, ErrEmptyDocument) {
+		t.Errorf("Build() of an empty builder = %v, want ErrEmptyDocument", err)
+	}
+}
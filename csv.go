@@ -0,0 +1,150 @@
+package json
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"sort"
+	"strconv"
+)
+
+// CSVOptions holds the options of ToCSVWithOptions.
+type CSVOptions struct {
+	// Columns are the object keys written as the columns, in order.
+	// If empty, the union of the keys of all objects is used, in sorted order.
+	Columns []string
+
+	// NestedJSON writes the object and array values as their JSON encoding.
+	// Otherwise, a nested value is an error.
+	NestedJSON bool
+}
+
+// ToCSV converts an array of flat objects to CSV, with a header row followed by one row
+// per object. It is equivalent to ToCSVWithOptions with the given columns.
+//
+// Usage:
+//
+//	root := Must(Unmarshal([]byte(`[{"id": 1, "name": "foo"}, {"id": 2, "tags": null}]`)))
+//	out, err := json.ToCSV(root, []string{"id", "name"})
+//	if err != nil {
+//		return err
+//	}
+//
+//	result:
+//	id,name
+//	1,foo
+//	2,
+func ToCSV(node *Node, columns []string) ([]byte, error) {
+	return ToCSVWithOptions(node, CSVOptions{Columns: columns})
+}
+
+// ToCSVWithOptions converts an array of objects to CSV, with a header row followed by
+// one row per object.
+//
+// The scalar values are converted from their Value: strings are written as is, numbers in
+// the shortest form and booleans as true or false. The null values and the missing keys
+// are written as empty cells. The values of the nested objects and arrays are encoded as
+// JSON if NestedJSON is set, or an error is returned otherwise.
+//
+// If the node is not an array of objects, it returns an error.
+func ToCSVWithOptions(node *Node, opts CSVOptions) ([]byte, error) {
+	rows, err := node.GetArray()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, row := range rows {
+		if !row.IsObject() {
+			return nil, fmt.Errorf("%s: %w", row.Path(), &TypeError{Expected: Object, Got: row.Type()})
+		}
+	}
+
+	columns := opts.Columns
+	if len(columns) == 0 {
+		columns = csvColumns(rows)
+	}
+
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+
+	if err := w.Write(columns); err != nil {
+		return nil, err
+	}
+
+	record := make([]string, len(columns))
+	for _, row := range rows {
+		for i, column := range columns {
+			cell, err := csvCell(row.next[column], opts.NestedJSON)
+			if err != nil {
+				return nil, err
+			}
+
+			record[i] = cell
+		}
+
+		if err := w.Write(record); err != nil {
+			return nil, err
+		}
+	}
+
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// csvColumns returns the union of the keys of the given objects in sorted order.
+func csvColumns(rows []*Node) []string {
+	seen := make(map[string]bool)
+	columns := make([]string, 0)
+
+	for _, row := range rows {
+		for key := range row.next {
+			if !seen[key] {
+				seen[key] = true
+				columns = append(columns, key)
+			}
+		}
+	}
+
+	sort.Strings(columns)
+	return columns
+}
+
+// csvCell returns the text of the given value in a CSV cell.
+func csvCell(node *Node, nestedJSON bool) (string, error) {
+	if node == nil {
+		return "", nil
+	}
+
+	if node.isContainer() {
+		if !nestedJSON {
+			return "", fmt.Errorf("%s: nested %s value can't be written to a CSV cell", node.Path(), node.Type())
+		}
+
+		value, err := Marshal(node)
+		if err != nil {
+			return "", err
+		}
+
+		return string(value), nil
+	}
+
+	value, err := node.Value()
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", node.Path(), err)
+	}
+
+	switch v := value.(type) {
+	case string:
+		return v, nil
+	case float64:
+		return formatNumber(v, node.nodeType == Float), nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	default:
+		return "", nil
+	}
+}
@@ -0,0 +1,109 @@
+package json
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestToCSV(t *testing.T) {
+	tests := []struct {
+		name     string
+		json     string
+		columns  []string
+		expected string
+	}{
+		{
+			name:     "columns",
+			json:     `[{"id": 1, "name": "foo", "extra": true}, {"id": 2.5, "name": "bar"}]`,
+			columns:  []string{"name", "id"},
+			expected: "name,id\nfoo,1\nbar,2.5\n",
+		},
+		{
+			name:     "union of keys",
+			json:     `[{"b": 1, "a": "x"}, {"c": false}]`,
+			expected: "a,b,c\nx,1,\n,,false\n",
+		},
+		{
+			name:     "null and missing values",
+			json:     `[{"a": null}, {}]`,
+			columns:  []string{"a", "b"},
+			expected: "a,b\n,\n,\n",
+		},
+		{
+			name:     "quoted cells",
+			json:     `[{"a": "x,y", "b": "say \"hi\"", "c": "line\nbreak"}]`,
+			expected: "a,b,c\n\"x,y\",\"say \"\"hi\"\"\",\"line\nbreak\"\n",
+		},
+		{
+			name:     "escaped strings",
+			json:     `[{"a": "caf\u00e9"}]`,
+			expected: "a\ncafé\n",
+		},
+		{
+			name:     "number literals",
+			json:     `[{"a": 1.50, "b": 1e3, "c": -0.001}]`,
+			expected: "a,b,c\n1.5,1000,-0.001\n",
+		},
+		{
+			name:     "empty array",
+			json:     `[]`,
+			columns:  []string{"a"},
+			expected: "a\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, err := ToCSV(Must(Unmarshal([]byte(tt.json))), tt.columns)
+			if err != nil {
+				t.Fatalf("ToCSV returns error: %v", err)
+			}
+
+			if string(out) != tt.expected {
+				t.Errorf("ToCSV() = %q, want %q", out, tt.expected)
+			}
+		})
+	}
+}
+
+func TestToCSVWithOptions_NestedJSON(t *testing.T) {
+	root := Must(Unmarshal([]byte(`[{"id": 1, "tags": ["a", "b"], "meta": {"v": 1}}]`)))
+
+	out, err := ToCSVWithOptions(root, CSVOptions{NestedJSON: true})
+	if err != nil {
+		t.Fatalf("ToCSVWithOptions returns error: %v", err)
+	}
+
+	expected := "id,meta,tags\n1,\"{\"\"v\"\": 1}\",\"[\"\"a\"\", \"\"b\"\"]\"\n"
+	if string(out) != expected {
+		t.Errorf("ToCSVWithOptions() = %q, want %q", out, expected)
+	}
+
+	if _, err := ToCSV(root, nil); err == nil {
+		t.Errorf("ToCSV with a nested value must fail")
+	}
+}
+
+func TestToCSV_Fail(t *testing.T) {
+	tests := []struct {
+		name string
+		node *Node
+	}{
+		{"nil", nil},
+		{"object", Must(Unmarshal([]byte(`{"a": 1}`)))},
+		{"scalar element", Must(Unmarshal([]byte(`[{"a": 1}, 2]`)))},
+		{"nested array", Must(Unmarshal([]byte(`[[1]]`)))},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if out, err := ToCSV(tt.node, nil); err == nil {
+				t.Errorf("ToCSV() = %q, must fail", out)
+			}
+		})
+	}
+
+	if _, err := ToCSV(Must(Unmarshal([]byte(`[1]`))), nil); !errors.Is(err, ErrTypeMismatch) {
+		t.Errorf("ToCSV() of a scalar element = %v, want ErrTypeMismatch", err)
+	}
+}
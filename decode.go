@@ -1,21 +1,87 @@
 package json
 
 import (
+	"bytes"
+	"context"
+	"errors"
 	"fmt"
 	"io"
+	"strconv"
+	"unicode/utf8"
 )
 
 // This limits the max nesting depth to prevent stack overflow.
 // This is permitted by https://tools.ietf.org/html/rfc7159#section-9
 const maxNestingDepth = 10000
 
+// contextCheckInterval is the number of input bytes consumed between
+// two cancellation checks of the context in UnmarshalContext.
+const contextCheckInterval = 64 * 1024
+
 // State machine transition logic and grammar references
 // [1] https://github.com/spyzhov/ajson/blob/master/decode.go
 // [2] https://cs.opensource.google/go/go/+/refs/tags/go1.22.1:src/encoding/json/scanner.go
 
+// Options holds the options to relax the strict JSON grammar while decoding.
+//
+// The zero value is the strict mode which is used by Unmarshal.
+type Options struct {
+	// AllowComments allows `//` line comments and `/* */` block comments
+	// wherever whitespace is allowed. Comments inside string literals are
+	// treated as part of the string.
+	AllowComments bool
+
+	// AllowUnquotedKeys allows object keys to be bare identifiers
+	// (e.g. `{key: 1}`). An identifier consists of ASCII letters, digits,
+	// `_` and `$`, and must not start with a digit. The keys are quoted in
+	// a copy of the data, so the nodes are marshaled as valid JSON, but
+	// SourceRange can't report their offsets in the data.
+	AllowUnquotedKeys bool
+
+	// AllowInfNaN allows the non-standard `NaN`, `Infinity` and `-Infinity`
+	// literals, which are decoded as Number nodes.
+	AllowInfNaN bool
+
+	// ReplaceInvalidSurrogates replaces invalid UTF-16 surrogate escapes in strings
+	// and keys (a lone high or low surrogate, or a reversed pair) with U+FFFD
+	// instead of failing with an "invalid surrogate pair" error.
+	ReplaceInvalidSurrogates bool
+
+	// DuplicateKeyMode controls how a key repeated in the same object is decoded.
+	// The default is DuplicateKeyOverwrite.
+	DuplicateKeyMode DuplicateKeyMode
+
+	// InternKeys makes the object keys with the same content share one string,
+	// instead of allocating a new one for each occurrence. It reduces the memory of
+	// documents with many objects of the same shape, such as large arrays of records.
+	InternKeys bool
+}
+
+// DuplicateKeyMode is the way the decoder handles a key that appears more than once in an object.
+type DuplicateKeyMode int
+
+const (
+	// DuplicateKeyOverwrite keeps the last value of a repeated key.
+	DuplicateKeyOverwrite DuplicateKeyMode = iota
+
+	// DuplicateKeyError fails with a *SyntaxError on a repeated key.
+	DuplicateKeyError
+
+	// DuplicateKeyCombine collapses all the values of a repeated key into an array,
+	// in the order they appear. For example, `{"a": 1, "b": 2, "a": [3]}` is decoded
+	// as `{"a": [1, [3]], "b": 2}`. A key that appears only once is left as is.
+	//
+	// The combined array is a new node, so it and its ancestors are marked as modified,
+	// while the values themselves still refer to the parsed data.
+	DuplicateKeyCombine
+)
+
 // Unmarshal parses the JSON-encoded data and returns a Node.
 // The data must be a valid JSON-encoded value.
 //
+// A leading UTF-8 byte order mark is ignored. If the data holds no value at all,
+// it returns ErrEmptyDocument.
+//
 // Usage:
 // 	node, err := json.Unmarshal([]byte(`{"key": "value"}`))
 // 	if err != nil {
@@ -23,7 +89,129 @@ const maxNestingDepth = 10000
 // 	}
 // 	println(node) // {"key": "value"}
 func Unmarshal(data []byte) (*Node, error) {
+	return UnmarshalWithOptions(data, Options{})
+}
+
+// UnmarshalWithOptions parses the JSON-encoded data with the given options and returns a Node.
+//
+// Usage:
+// 	node, err := json.UnmarshalWithOptions([]byte(`{"key": 1 /* comment */}`), json.Options{AllowComments: true})
+// 	if err != nil {
+// 		fmt.Println(err)
+// 	}
+func UnmarshalWithOptions(data []byte, opts Options) (*Node, error) {
+	return unmarshal(context.Background(), data, opts, nil)
+}
+
+// UnmarshalContext parses the JSON-encoded data like Unmarshal, but aborts
+// with the context error once ctx is canceled or its deadline is exceeded.
+//
+// The context is checked before decoding starts and then roughly every
+// 64 KiB of consumed input, so a single long token (e.g. a huge string)
+// is always read to its end before the next check.
+//
+// Usage:
+// 	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+// 	defer cancel()
+//
+// 	node, err := json.UnmarshalContext(ctx, data)
+// 	if errors.Is(err, context.DeadlineExceeded) {
+// 		fmt.Println("parsing took too long")
+// 	}
+func UnmarshalContext(ctx context.Context, data []byte) (*Node, error) {
+	return unmarshal(ctx, data, Options{}, nil)
+}
+
+// UnmarshalRFC8259 parses the JSON-encoded data like Unmarshal, but also rejects the inputs
+// that Unmarshal accepts while the strict grammar of RFC 8259 does not, or leaves to the implementation:
+//
+//   - invalid UTF-8 sequences, anywhere in the data
+//   - numbers out of the float64 range (e.g. 1e400)
+//   - strings with a lone or reversed UTF-16 surrogate escape (e.g. "\ud800")
+//
+// The checks are done eagerly on the whole tree, so no value can fail to load later.
+// Their errors are *SyntaxError pointing to the invalid value.
+// A leading byte order mark is still ignored, as RFC 8259 allows.
+//
+// Usage:
+// 	node, err := json.UnmarshalRFC8259([]byte(`[1e400]`))
+// 	if err != nil {
+// 		fmt.Println(err) // number out of range at index 1
+// 	}
+func UnmarshalRFC8259(data []byte) (*Node, error) {
+	if offset := invalidUTF8Offset(data); offset >= 0 {
+		return nil, newSyntaxError(offset, "invalid UTF-8 encoding")
+	}
+
+	root, err := Unmarshal(data)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = checkRFC8259(root); err != nil {
+		return nil, err
+	}
+
+	return root, nil
+}
+
+// checkRFC8259 checks the values of the parsed node that the decoder loads lazily.
+func checkRFC8259(node *Node) error {
+	switch node.nodeType {
+	case Number:
+		if _, err := ParseFloatLiteral(node.source()); err != nil {
+			if errors.Is(err, errRangeLimit) {
+				return newSyntaxError(node.borders[0], "number out of range")
+			}
+
+			return newSyntaxError(node.borders[0], "invalid number literal")
+		}
+
+	case String:
+		if _, err := unquoteAt(node.source(), node.borders[0], false); err != nil {
+			return err
+		}
+
+	case Array, Object:
+		for _, child := range node.children() {
+			if err := checkRFC8259(child); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// invalidUTF8Offset returns the offset of the first invalid UTF-8 sequence in data, or -1.
+func invalidUTF8Offset(data []byte) int {
+	for i := 0; i < len(data); {
+		r, size := utf8.DecodeRune(data[i:])
+		if r == utf8.RuneError && size == 1 {
+			return i
+		}
+
+		i += size
+	}
+
+	return -1
+}
+
+// unmarshal decodes the data into a new tree. If pool is not nil, the nodes are taken from it.
+func unmarshal(ctx context.Context, data []byte, opts Options, pool *nodePool) (*Node, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	if opts.AllowComments {
+		data = blankComments(data)
+	}
+
 	buf := newBuffer(data)
+	buf.comments = opts.AllowComments
+	if bytes.HasPrefix(data, byteOrderMark) {
+		buf.index = len(byteOrderMark)
+	}
 
 	var (
 		state   States
@@ -35,14 +223,106 @@ func Unmarshal(data []byte) (*Node, error) {
 			key = nil
 			return &tmp
 		}
-		err error
+		err     error
+		checked int // index of the last context check
+
+		// duplicates holds the overwritten values of the repeated keys of each open object,
+		// used by DuplicateKeyCombine.
+		duplicates map[*Node]map[string][]*Node
+
+		// keys holds the decoded keys when InternKeys is set.
+		keys map[string]string
+
+		// unquoted holds the start and end offsets of the unquoted keys.
+		unquoted [][2]int
 	)
 
+	if opts.InternKeys {
+		keys = make(map[string]string)
+	}
+
+	newNode := func(nodeType ValueType) (*Node, error) {
+		if key != nil && current != nil && current.IsObject() {
+			if prev, ok := current.next[*key]; ok {
+				switch opts.DuplicateKeyMode {
+				case DuplicateKeyError:
+					return nil, newSyntaxError(buf.index, fmt.Sprintf("duplicate key %q", *key))
+				case DuplicateKeyCombine:
+					if duplicates == nil {
+						duplicates = make(map[*Node]map[string][]*Node)
+					}
+
+					if duplicates[current] == nil {
+						duplicates[current] = make(map[string][]*Node)
+					}
+
+					duplicates[current][*key] = append(duplicates[current][*key], prev)
+				}
+			}
+		}
+
+		if pool != nil {
+			return newNodeFrom(pool.get(), current, buf, nodeType, useKey())
+		}
+
+		return createNode(current, buf, nodeType, useKey())
+	}
+
 	if _, err = buf.first(); err != nil {
-		return nil, io.EOF
+		if err != io.EOF {
+			return nil, err
+		}
+
+		return nil, ErrEmptyDocument
 	}
 
 	for {
+		if buf.index-checked >= contextCheckInterval {
+			checked = buf.index
+			if err = ctx.Err(); err != nil {
+				return nil, err
+			}
+		}
+
+		if opts.AllowUnquotedKeys && key == nil && (buf.state == OB || buf.state == KE) && isIdentifierStart(buf.data[buf.index]) {
+			// unquoted key detected
+			start := buf.index
+			if key, err = buf.identifier(); err != nil {
+				return nil, newSyntaxError(buf.index, "invalid identifier")
+			}
+
+			unquoted = append(unquoted, [2]int{start, buf.index})
+
+			buf.state = CO
+			if _, err = buf.first(); err != nil {
+				if err != io.EOF {
+					return nil, err
+				}
+
+				break
+			}
+
+			continue
+		}
+
+		if buf.state == GO || buf.state == VA || buf.state == AR {
+			if literal := buf.nonFinite(); literal != nil {
+				if !opts.AllowInfNaN {
+					return nil, newSyntaxError(buf.index, fmt.Sprintf("invalid number literal %s, NaN and Infinity are not allowed", literal))
+				}
+
+				if current, err = newNode(Number); err != nil {
+					return nil, err
+				}
+
+				buf.index += len(literal) - 1
+				current, nesting = updateNode(current, buf, nesting, false)
+				buf.state = OK
+
+				goto next
+			}
+		}
+
 		state = buf.getState()
 		if state == __ {
 			return nil, unexpectedTokenError(buf.data, buf.index)
@@ -54,7 +334,7 @@ func Unmarshal(data []byte) (*Node, error) {
 			case ST: // string
 				if current != nil && current.IsObject() && key == nil {
 					// key detected
-					if key, err = getString(buf); err != nil {
+					if key, err = getKey(buf, opts.ReplaceInvalidSurrogates, keys); err != nil {
 						return nil, err
 					}
 
@@ -64,13 +344,23 @@ func Unmarshal(data []byte) (*Node, error) {
 						return nil, err
 					}
 
-					current, err = createNode(current, buf, String, useKey())
+					current, err = newNode(String)
 					if err != nil {
 						return nil, err
 					}
 
 					if err = buf.string(doubleQuote, false); err != nil {
-						return nil, err
+						return nil, stringSyntaxError(buf)
+					}
+
+					if opts.ReplaceInvalidSurrogates {
+						// unquote eagerly, since the lazy Value() would reject the invalid surrogates.
+						var value *string
+						if value, err = unquoteAt(buf.data[current.borders[0]:buf.index+1], current.borders[0], true); err != nil {
+							return nil, err
+						}
+
+						current.value = *value
 					}
 
 					current, nesting = updateNode(current, buf, nesting, true)
@@ -78,12 +368,12 @@ func Unmarshal(data []byte) (*Node, error) {
 				}
 
 			case MI, ZE, IN: // number
-				if current, err = createNode(current, buf, Number, useKey()); err != nil {
+				if current, err = newNode(Number); err != nil {
 					return nil, err
 				}
 
 				if err = buf.numeric(false); err != nil {
-					return nil, err
+					return nil, newSyntaxError(buf.index, "invalid number literal")
 				}
 
 				current.borders[1] = buf.index
@@ -95,7 +385,7 @@ func Unmarshal(data []byte) (*Node, error) {
 				buf.state = OK
 
 			case T1, F1: // boolean
-				if current, err = createNode(current, buf, Boolean, useKey()); err != nil {
+				if current, err = newNode(Boolean); err != nil {
 					return nil, err
 				}
 
@@ -107,19 +397,19 @@ func Unmarshal(data []byte) (*Node, error) {
 				}
 
 				if err = buf.word(literal); err != nil {
-					return nil, err
+					return nil, newSyntaxError(buf.index, "invalid literal")
 				}
 
 				current, nesting = updateNode(current, buf, nesting, false)
 				buf.state = OK
 
 			case N1: // null
-				if current, err = createNode(current, buf, Null, useKey()); err != nil {
+				if current, err = newNode(Null); err != nil {
 					return nil, err
 				}
 
 				if err = buf.word(nullLiteral); err != nil {
-					return nil, err
+					return nil, newSyntaxError(buf.index, "invalid literal")
 				}
 
 				current, nesting = updateNode(current, buf, nesting, false)
@@ -137,6 +427,11 @@ func Unmarshal(data []byte) (*Node, error) {
 					return nil, unexpectedTokenError(buf.data, buf.index)
 				}
 
+				if dups, ok := duplicates[current]; ok {
+					combineDuplicates(current, dups)
+					delete(duplicates, current)
+				}
+
 				current, nesting = updateNode(current, buf, nesting, true)
 				buf.state = OK
 
@@ -153,7 +448,7 @@ func Unmarshal(data []byte) (*Node, error) {
 					return nil, err
 				}
 
-				if current, err = createNode(current, buf, Object, useKey()); err != nil {
+				if current, err = newNode(Object); err != nil {
 					return nil, err
 				}
 
@@ -164,14 +459,15 @@ func Unmarshal(data []byte) (*Node, error) {
 					return nil, err
 				}
 
-				if current, err = createNode(current, buf, Array, useKey()); err != nil {
+				if current, err = newNode(Array); err != nil {
 					return nil, err
 				}
 
 				buf.state = AR
 
 			case cm: // ,
-				if current == nil {
+				// a comma is only valid inside an open container, not after the closed root.
+				if current == nil || current.ready() {
 					return nil, unexpectedTokenError(buf.data, buf.index)
 				}
 
@@ -195,11 +491,16 @@ func Unmarshal(data []byte) (*Node, error) {
 			}
 		}
 
+	next:
 		if buf.step() != nil {
 			break
 		}
 
 		if _, err = buf.first(); err != nil {
+			if err != io.EOF {
+				return nil, err
+			}
+
 			err = nil
 			break
 		}
@@ -214,9 +515,206 @@ func Unmarshal(data []byte) (*Node, error) {
 		return nil, io.EOF
 	}
 
+	// the unmodified nodes are marshaled from their source bytes, so the data is decoded
+	// again with the keys quoted. The errors are reported by the first pass, whose offsets
+	// match the input.
+	if len(unquoted) > 0 && err == nil {
+		opts.AllowUnquotedKeys = false
+		opts.AllowComments = false
+		if root, err = unmarshal(ctx, quoteKeys(data, unquoted), opts, pool); err != nil {
+			return nil, err
+		}
+
+		// the offsets of the copy are shifted by the inserted quotes.
+		root.markRewritten()
+	}
+
 	return root, err
 }
 
+// quoteKeys returns a copy of the data with the given unquoted keys enclosed in double quotes.
+func quoteKeys(data []byte, keys [][2]int) []byte {
+	out := make([]byte, 0, len(data)+2*len(keys))
+
+	last := 0
+	for _, key := range keys {
+		out = append(out, data[last:key[0]]...)
+		out = append(out, doubleQuote)
+		out = append(out, data[key[0]:key[1]]...)
+		out = append(out, doubleQuote)
+		last = key[1]
+	}
+
+	return append(out, data[last:]...)
+}
+
+// markRewritten marks the current node and its descendants as decoded from a rewritten copy of the input.
+func (n *Node) markRewritten() {
+	n.rewritten = true
+	for _, child := range n.next {
+		child.markRewritten()
+	}
+}
+
+// UnmarshalInto parses the JSON-encoded data into the current node, reusing the nodes
+// and the child maps of its previous content instead of allocating a new tree like Unmarshal.
+// It suits services decoding many small messages of a similar shape in a loop.
+//
+// The current node must be a root node, which becomes the root of the decoded tree.
+// Its previous descendants are recycled, so any node obtained from it before the call
+// (e.g. by GetKey or Collect) must not be used afterwards. Like Unmarshal, the tree refers
+// to the data, which must not be modified while the node is in use.
+//
+// On error, the current node is reset to a null value.
+//
+// Usage:
+//
+//	node := json.NullNode("")
+//	for msg := range messages {
+//		if err := node.UnmarshalInto(msg); err != nil {
+//			return err
+//		}
+//
+//		handle(node)
+//	}
+func (n *Node) UnmarshalInto(data []byte) error {
+	if n == nil {
+		return ErrNilNode
+	}
+
+	if n.prev != nil {
+		return errors.New("can't unmarshal into a node with a parent")
+	}
+
+	if err := n.mutable(); err != nil {
+		return err
+	}
+
+	root, err := unmarshal(context.Background(), data, Options{}, newNodePool(n))
+	if err == nil && root != n {
+		err = errors.New("unexpected root node")
+	}
+
+	if err != nil {
+		*n = Node{nodeType: Null, modified: true}
+		return err
+	}
+
+	return nil
+}
+
+// nodePool recycles the nodes of a tree, for UnmarshalInto.
+type nodePool struct {
+	root  *Node   // root is returned by the first get, so the tree is decoded in place.
+	nodes []*Node // nodes holds the descendants of the root.
+}
+
+// newNodePool returns a pool of the given node and its descendants.
+func newNodePool(root *Node) *nodePool {
+	pool := &nodePool{root: root}
+
+	stack := []*Node{root}
+	for len(stack) > 0 {
+		node := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+
+		for _, child := range node.next {
+			// a frozen subtree is left intact, since the pool overwrites its nodes.
+			if child.frozen {
+				child.prev = nil
+				continue
+			}
+
+			pool.nodes = append(pool.nodes, child)
+			stack = append(stack, child)
+		}
+	}
+
+	return pool
+}
+
+// get returns a recycled node reset to the zero value, except for its emptied child map,
+// or a new node if the pool is empty.
+func (p *nodePool) get() *Node {
+	var node *Node
+	switch {
+	case p.root != nil:
+		node, p.root = p.root, nil
+	case len(p.nodes) > 0:
+		node = p.nodes[len(p.nodes)-1]
+		p.nodes = p.nodes[:len(p.nodes)-1]
+	default:
+		return &Node{}
+	}
+
+	next := node.next
+	clear(next)
+	*node = Node{next: next}
+
+	return node
+}
+
+// blankComments returns a copy of the data with the comments replaced by spaces,
+// or the data itself if it has no comments.
+//
+// The unmodified nodes are marshaled from their source bytes, so the comments inside
+// an array or object would be written out as well. Blanking them keeps the offsets of
+// the nodes and errors unchanged. A `/` that doesn't start a valid comment is left
+// for the parser to report.
+func blankComments(data []byte) []byte {
+	var out []byte
+
+	for i := 0; i < len(data); i++ {
+		switch data[i] {
+		case doubleQuote:
+			i = stringLiteralEnd(data, i) - 1
+
+		case slash:
+			if i+1 >= len(data) {
+				return data
+			}
+
+			var end int
+			switch data[i+1] {
+			case slash:
+				end = bytes.IndexByte(data[i+2:], newLine)
+				if end == -1 {
+					end = len(data)
+				} else {
+					end += i + 2
+				}
+
+			case aesterisk:
+				end = bytes.Index(data[i+2:], []byte("*/"))
+				if end == -1 {
+					return data
+				}
+
+				end += i + 4
+
+			default:
+				return data
+			}
+
+			if out == nil {
+				out = append([]byte(nil), data...)
+			}
+
+			for j := i; j < end; j++ {
+				out[j] = whiteSpace
+			}
+
+			i = end - 1
+		}
+	}
+
+	if out == nil {
+		return data
+	}
+
+	return out
+}
+
 func isValidContainerType(current *Node, nodeType ValueType) bool {
 	switch nodeType {
 	case Object:
@@ -229,22 +727,83 @@ func isValidContainerType(current *Node, nodeType ValueType) bool {
 }
 
 // getString extracts a string from the buffer and advances the buffer index past the string.
-func getString(b *buffer) (*string, error) {
+//
+// If replace is true, invalid surrogates are replaced with U+FFFD.
+func getString(b *buffer, replace bool) (*string, error) {
 	start := b.index
 	if err := b.string(doubleQuote, false); err != nil {
+		return nil, stringSyntaxError(b)
+	}
+
+	return unquoteAt(b.data[start:b.index+1], start, replace)
+}
+
+// stringSyntaxError returns the error of a string literal which failed to scan at the
+// buffer index. A raw control character, which must be escaped in a string literal
+// as per RFC 8259, is reported as such.
+func stringSyntaxError(b *buffer) *SyntaxError {
+	if b.index < b.length && b.data[b.index] < 0x20 {
+		return newSyntaxError(b.index, fmt.Sprintf("invalid control character %q in string literal", b.data[b.index]))
+	}
+
+	return newSyntaxError(b.index, "invalid string literal")
+}
+
+// getKey is getString for object keys. If keys is not nil, a key already found in it
+// is reused instead of allocating a new string, and a new key is added to it.
+func getKey(b *buffer, replace bool, keys map[string]string) (*string, error) {
+	if keys == nil {
+		return getString(b, replace)
+	}
+
+	start := b.index
+	if err := b.string(doubleQuote, false); err != nil {
+		return nil, stringSyntaxError(b)
+	}
+
+	value, err := unquoteBytesAt(b.data[start:b.index+1], start, replace)
+	if err != nil {
 		return nil, err
 	}
 
-	value, ok := Unquote(b.data[start:b.index+1], doubleQuote)
+	// the lookup with the converted slice does not allocate.
+	str, ok := keys[string(value)]
 	if !ok {
-		return nil, unexpectedTokenError(b.data, start)
+		str = string(value)
+		keys[str] = str
 	}
 
-	return &value, nil
+	return &str, nil
+}
+
+// unquoteAt unquotes the string literal found at the given offset of the data.
+// The offset of a returned *SyntaxError is adjusted to the data.
+func unquoteAt(literal []byte, offset int, replace bool) (*string, error) {
+	value, err := unquoteBytesAt(literal, offset, replace)
+	if err != nil {
+		return nil, err
+	}
+
+	str := string(value)
+	return &str, nil
+}
+
+// unquoteBytesAt is unquoteAt returning the unquoted bytes, which may share the literal.
+func unquoteBytesAt(literal []byte, offset int, replace bool) ([]byte, error) {
+	value, err := unquote(literal, doubleQuote, replace)
+	if err != nil {
+		if serr, ok := err.(*SyntaxError); ok {
+			serr.Offset += offset
+		}
+
+		return nil, err
+	}
+
+	return value, nil
 }
 
 func unexpectedTokenError(data []byte, index int) error {
-	return fmt.Errorf("unexpected token at index %d. data %b", index, data)
+	return newSyntaxError(index, "unexpected token")
 }
 
 func createNode(current *Node, buf *buffer, nodeType ValueType, key **string) (*Node, error) {
@@ -273,9 +832,37 @@ func updateNode(current *Node, buf *buffer, nesting int, decreaseLevel bool) (*N
 	return current, nesting
 }
 
+// combineDuplicates replaces each repeated key of the object with an array
+// of all its values, the overwritten ones followed by the last one.
+func combineDuplicates(object *Node, dups map[string][]*Node) {
+	for key, values := range dups {
+		values = append(values, object.next[key])
+
+		combined := &Node{
+			prev:     object,
+			next:     make(map[string]*Node, len(values)),
+			key:      values[0].key,
+			nodeType: Array,
+			modified: true,
+		}
+
+		for i, value := range values {
+			idx := i
+			value.prev = combined
+			value.key = nil
+			value.index = &idx
+			combined.next[strconv.Itoa(i)] = value
+		}
+
+		object.next[key] = combined
+	}
+
+	object.mark()
+}
+
 func checkNestingDepth(nesting int) (int, error) {
 	if nesting >= maxNestingDepth {
-		return nesting, fmt.Errorf("maximum nesting depth exceeded")
+		return nesting, ErrMaxNesting
 	}
 
 	return nesting + 1, nil
@@ -286,3 +873,21 @@ func UnmarshalSafe(data []byte) (*Node, error) {
 	safe = append(safe, data...)
 	return Unmarshal(safe)
 }
+
+// FromJSON parses the JSON-encoded string and returns the root node. It is the counterpart of ToJSON.
+//
+// Like UnmarshalSafe, the node is parsed from a copy of the input,
+// so it does not keep a reference to the string.
+//
+// Usage:
+//
+//	root, err := json.FromJSON(`{"foo": "bar"}`)
+//	if err != nil {
+//		return err
+//	}
+//
+//	result: root.MustKey("foo").MustString() == "bar"
+func FromJSON(s string) (*Node, error) {
+	// the conversion copies the string.
+	return Unmarshal([]byte(s))
+}
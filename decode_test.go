@@ -2,7 +2,13 @@ package json
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strings"
 	"testing"
 )
 
@@ -602,6 +608,34 @@ func TestUnmarshalSafe(t *testing.T) {
 	}
 }
 
+func TestFromJSON_ToJSON(t *testing.T) {
+	tests := []string{`null`, `"a\nb"`, `[1,2.5,true]`, `{"a":{"b":[]}}`}
+
+	for _, input := range tests {
+		root, err := FromJSON(input)
+		if err != nil {
+			t.Fatalf("FromJSON(%q) returns error: %v", input, err)
+		}
+
+		output, err := ToJSON(root)
+		if err != nil {
+			t.Fatalf("ToJSON returns error: %v", err)
+		}
+
+		if output != input {
+			t.Errorf("ToJSON(FromJSON(%q)) = %q", input, output)
+		}
+	}
+
+	if _, err := FromJSON(`{"a":`); err == nil {
+		t.Errorf("FromJSON of an invalid document must fail")
+	}
+
+	if _, err := ToJSON(nil); err == nil {
+		t.Errorf("ToJSON(nil) must fail")
+	}
+}
+
 // BenchmarkGoStdUnmarshal-8   	   61698	     19350 ns/op	     288 B/op	       6 allocs/op
 // BenchmarkUnmarshal-8        	   45620	     26165 ns/op	   21889 B/op	     367 allocs/op
 type bench struct {
@@ -628,3 +662,1176 @@ func BenchmarkUnmarshal(b *testing.B) {
 		}
 	}
 }
+
+// recordsJSON returns an array of n objects of the same shape.
+func recordsJSON(n int) []byte {
+	var sb strings.Builder
+	sb.WriteByte('[')
+	for i := 0; i < n; i++ {
+		if i > 0 {
+			sb.WriteByte(',')
+		}
+
+		fmt.Fprintf(&sb, `{"identifier":%d,"display_name":"name %d","created_at":"2024-01-01"}`, i, i)
+	}
+	sb.WriteByte(']')
+
+	return []byte(sb.String())
+}
+
+func TestUnmarshalWithOptions_InternKeys(t *testing.T) {
+	data := recordsJSON(100)
+
+	plain := Must(Unmarshal(data))
+	interned := Must(UnmarshalWithOptions(data, Options{InternKeys: true}))
+	if !equals(plain, interned) {
+		t.Fatalf("InternKeys changes the decoded document")
+	}
+
+	escaped := Must(UnmarshalWithOptions([]byte(`[{"a\u0062": 1}, {"ab": 2}, {"a\"b": 3}]`), Options{InternKeys: true}))
+	if got := escaped.MustIndex(1).sortedKeys(); len(got) != 1 || got[0] != "ab" {
+		t.Errorf("keys = %v, want [ab]", got)
+	}
+
+	if !escaped.MustIndex(2).HasKey(`a"b`) {
+		t.Errorf("escaped key is not decoded")
+	}
+
+	allocs := func(opts Options) float64 {
+		return testing.AllocsPerRun(10, func() {
+			if _, err := UnmarshalWithOptions(data, opts); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+
+	// each repeated key occurrence saves one allocation.
+	if without, with := allocs(Options{}), allocs(Options{InternKeys: true}); with >= without-200 {
+		t.Errorf("InternKeys allocates %v times, want fewer than %v", with, without-200)
+	}
+}
+
+func BenchmarkUnmarshal_InternKeys(b *testing.B) {
+	data := recordsJSON(1000)
+
+	for _, intern := range []bool{false, true} {
+		b.Run(fmt.Sprintf("intern=%t", intern), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if _, err := UnmarshalWithOptions(data, Options{InternKeys: intern}); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func TestUnmarshalWithOptions_AllowComments(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+		isErr    bool
+	}{
+		{
+			name:     "line comment before value",
+			input:    "// comment\n{\"a\": 1}",
+			expected: `{"a":1}`,
+		},
+		{
+			name:     "line comment with trailing whitespace",
+			input:    "{\"a\": 1} // comment   \n  \t",
+			expected: `{"a":1}`,
+		},
+		{
+			name:     "line comment at the end of data",
+			input:    "[1, 2] // comment",
+			expected: `[1,2]`,
+		},
+		{
+			name:     "block comments between tokens",
+			input:    `{/* a */"a"/* b */:/* c */1/* d */,"b":[/* e */true/* f */]}`,
+			expected: `{"a":1,"b":[true]}`,
+		},
+		{
+			name:     "comment right after number",
+			input:    "[1// one\n,2.5/* two */]",
+			expected: `[1,2.5]`,
+		},
+		{
+			name: "nested structures",
+			input: `{
+				// servers
+				"servers": [
+					{"host": "a", /* primary */ "port": 80},
+					{"host": "b", "port": 8080} // backup
+				],
+				/* multi
+				   line */
+				"debug": false
+			}`,
+			expected: `{"servers":[{"host":"a","port":80},{"host":"b","port":8080}],"debug":false}`,
+		},
+		{
+			name:     "comment-like content in string",
+			input:    `{"url": "http://example.com/*path*/"} // comment`,
+			expected: `{"url":"http://example.com/*path*/"}`,
+		},
+		{
+			name:  "unterminated block comment",
+			input: `[1] /* comment`,
+			isErr: true,
+		},
+		{
+			name:  "single slash",
+			input: `[1 / 2]`,
+			isErr: true,
+		},
+		{
+			name:  "comment only",
+			input: `// comment`,
+			isErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root, err := UnmarshalWithOptions([]byte(tt.input), Options{AllowComments: true})
+			if tt.isErr {
+				if err == nil {
+					t.Errorf("expected error, got %s", root)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			expected := Must(Unmarshal([]byte(tt.expected)))
+			if !equalValues(root, expected) {
+				t.Errorf("unexpected result: %s, expected %s", root, tt.expected)
+			}
+		})
+	}
+}
+
+func TestUnmarshalWithOptions_AllowComments_Source(t *testing.T) {
+	data := []byte(`// config
+{
+	"url": "http://example.com/*x*/", // line comment
+	"list": [1, /* inline */ 2],
+	"nested": {"a": null /* trailing */}
+}`)
+	original := string(data)
+
+	root, err := UnmarshalWithOptions(data, Options{AllowComments: true})
+	if err != nil {
+		t.Fatalf("UnmarshalWithOptions returns error: %v", err)
+	}
+
+	if string(data) != original {
+		t.Errorf("the input data must not be modified")
+	}
+
+	for _, node := range []*Node{root, root.MustKey("list"), root.MustKey("nested")} {
+		value, err := Marshal(node)
+		if err != nil {
+			t.Fatalf("Marshal returns error: %v", err)
+		}
+
+		if bytes.Contains(value, []byte("comment")) || bytes.Contains(value, []byte("inline")) || bytes.Contains(value, []byte("trailing")) {
+			t.Errorf("Marshal(%s) keeps the comments: %s", node.Path(), value)
+		}
+
+		// the output must be plain JSON.
+		decoded, err := Unmarshal(value)
+		if err != nil {
+			t.Fatalf("Unmarshal(%s) returns error: %v", value, err)
+		}
+
+		if !equals(decoded, node) {
+			t.Errorf("Marshal(%s) = %s, not equal", node.Path(), value)
+		}
+	}
+
+	if got := root.MustKey("url").MustString(); got != "http://example.com/*x*/" {
+		t.Errorf("url = %q, comments inside strings must be kept", got)
+	}
+
+	if got := root.MustKey("list").String(); got != "[1,              2]" {
+		t.Errorf("list = %q", got)
+	}
+}
+
+func TestUnmarshal_CommentsNotAllowedByDefault(t *testing.T) {
+	inputs := []string{
+		"// comment\n1",
+		"[1 /* comment */]",
+		`{"a": 1} // comment`,
+	}
+
+	for _, input := range inputs {
+		if _, err := Unmarshal([]byte(input)); err == nil {
+			t.Errorf("expected error for %q", input)
+		}
+	}
+}
+
+// equalValues compares the decoded values of the given nodes recursively.
+func equalValues(a, b *Node) bool {
+	if a.Type() != b.Type() || a.Size() != b.Size() {
+		return false
+	}
+
+	switch a.Type() {
+	case Array, Object:
+		for key, child := range a.next {
+			other, ok := b.next[key]
+			if !ok || !equalValues(child, other) {
+				return false
+			}
+		}
+
+		return true
+
+	default:
+		av, aerr := a.Value()
+		bv, berr := b.Value()
+		return aerr == nil && berr == nil && av == bv
+	}
+}
+
+func TestUnmarshalWithOptions_AllowUnquotedKeys(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+		isErr    bool
+	}{
+		{
+			name:     "single key",
+			input:    `{key: 1}`,
+			expected: `{"key":1}`,
+		},
+		{
+			name:     "mixed quoted and unquoted keys",
+			input:    `{a: 1, "b": 2, c_1: [3], $d: {e: null}}`,
+			expected: `{"a":1,"b":2,"c_1":[3],"$d":{"e":null}}`,
+		},
+		{
+			name:     "no whitespace",
+			input:    `{a:1,b:"x"}`,
+			expected: `{"a":1,"b":"x"}`,
+		},
+		{
+			name:     "whitespace around key",
+			input:    "{\n  name \t: \"foo\"\n}",
+			expected: `{"name":"foo"}`,
+		},
+		{
+			name:     "keyword-like key",
+			input:    `{true: false, null: 0}`,
+			expected: `{"true":false,"null":0}`,
+		},
+		{
+			name:  "key starts with digit",
+			input: `{1a: 1}`,
+			isErr: true,
+		},
+		{
+			name:  "key with invalid character",
+			input: `{a-b: 1}`,
+			isErr: true,
+		},
+		{
+			name:  "unquoted value",
+			input: `{a: b}`,
+			isErr: true,
+		},
+		{
+			name:  "missing colon",
+			input: `{a 1}`,
+			isErr: true,
+		},
+		{
+			name:  "unterminated object",
+			input: `{a`,
+			isErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root, err := UnmarshalWithOptions([]byte(tt.input), Options{AllowUnquotedKeys: true})
+			if tt.isErr {
+				if err == nil {
+					t.Errorf("expected error, got %s", root)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			expected := Must(Unmarshal([]byte(tt.expected)))
+			if !equalValues(root, expected) {
+				t.Errorf("unexpected result: %s, expected %s", root, tt.expected)
+			}
+		})
+	}
+
+	if _, err := Unmarshal([]byte(`{key: 1}`)); err == nil {
+		t.Errorf("unquoted keys must not be allowed by default")
+	}
+}
+
+func TestUnmarshalWithOptions_UnquotedKeysWithComments(t *testing.T) {
+	input := `{
+		// the host name
+		host: "localhost",
+		port: /* default */ 8080,
+	    tags: ["a", "b"]
+	}`
+
+	root, err := UnmarshalWithOptions([]byte(input), Options{AllowComments: true, AllowUnquotedKeys: true})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if root.MustKey("host").MustString() != "localhost" || root.MustKey("port").MustNumeric() != 8080 {
+		t.Errorf("unexpected result: %s", root)
+	}
+}
+
+func TestUnmarshalWithOptions_AllowUnquotedKeys_Source(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		opts     Options
+		expected string
+	}{
+		{
+			name:     "flat",
+			input:    `{b: 1, a: "x"}`,
+			opts:     Options{AllowUnquotedKeys: true},
+			expected: `{"b": 1, "a": "x"}`,
+		},
+		{
+			name:     "nested",
+			input:    `{"a": [{c_1: 1.50, $d: null}], e: {f: true}}`,
+			opts:     Options{AllowUnquotedKeys: true},
+			expected: `{"a": [{"c_1": 1.50, "$d": null}], "e": {"f": true}}`,
+		},
+		{
+			name:     "with comments",
+			input:    "{/* x */ host: \"localhost\", // y\n port: 80}",
+			opts:     Options{AllowUnquotedKeys: true, AllowComments: true},
+			expected: "{        \"host\": \"localhost\",     \n \"port\": 80}",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root, err := UnmarshalWithOptions([]byte(tt.input), tt.opts)
+			if err != nil {
+				t.Fatalf("UnmarshalWithOptions returns error: %v", err)
+			}
+
+			value, err := Marshal(root)
+			if err != nil {
+				t.Fatalf("Marshal returns error: %v", err)
+			}
+
+			if string(value) != tt.expected {
+				t.Errorf("Marshal() = %s, want %s", value, tt.expected)
+			}
+
+			// the output must be plain JSON.
+			if _, err := Unmarshal(value); err != nil {
+				t.Errorf("Unmarshal(%s) returns error: %v", value, err)
+			}
+
+			if root.Changed() {
+				t.Errorf("the decoded nodes must not be modified")
+			}
+		})
+	}
+
+	_, err := UnmarshalWithOptions([]byte(`{a: 1, bb: }`), Options{AllowUnquotedKeys: true})
+
+	var serr *SyntaxError
+	if !errors.As(err, &serr) || serr.Offset != 11 {
+		t.Errorf("error = %v, want a syntax error at the offset of the input", err)
+	}
+}
+
+func TestUnmarshalWithOptions_AllowUnquotedKeys_SourceRange(t *testing.T) {
+	data := []byte(`{a: [1], bb: "x"}`)
+	root := Must(UnmarshalWithOptions(data, Options{AllowUnquotedKeys: true}))
+
+	// the source is a copy with the keys quoted, so its offsets don't match the data.
+	for _, node := range []*Node{root, root.MustKey("a"), root.MustKey("a").MustIndex(0), root.MustKey("bb"), root.MustKey("bb").Clone()} {
+		if start, end, ok := node.SourceRange(); ok {
+			t.Errorf("SourceRange() of %s = %d, %d, true, want false", node.Path(), start, end)
+		}
+	}
+
+	// the data without unquoted keys is decoded as is.
+	data = []byte(`{"a": [1], "bb": "x"}`)
+	root = Must(UnmarshalWithOptions(data, Options{AllowUnquotedKeys: true}))
+	if start, end, ok := root.MustKey("bb").SourceRange(); !ok || string(data[start:end]) != `"x"` {
+		t.Errorf("SourceRange() = %d, %d, %t, want the range of \"x\"", start, end, ok)
+	}
+}
+
+func TestUnmarshal_InfNaN(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		check func(f float64) bool
+	}{
+		{
+			name:  "NaN",
+			input: `NaN`,
+			check: math.IsNaN,
+		},
+		{
+			name:  "Infinity",
+			input: `[1, Infinity]`,
+			check: func(f float64) bool { return math.IsInf(f, 1) },
+		},
+		{
+			name:  "negative Infinity",
+			input: `{"a": -Infinity}`,
+			check: func(f float64) bool { return math.IsInf(f, -1) },
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Unmarshal([]byte(tt.input))
+			if err == nil {
+				t.Fatalf("expected error for %s", tt.input)
+			}
+
+			if !strings.Contains(err.Error(), "NaN and Infinity are not allowed") {
+				t.Errorf("unexpected error message: %s", err)
+			}
+
+			root, err := UnmarshalWithOptions([]byte(tt.input), Options{AllowInfNaN: true})
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			node := root
+			switch {
+			case root.IsArray():
+				node = root.MustIndex(1)
+			case root.IsObject():
+				node = root.MustKey("a")
+			}
+
+			f, err := node.GetNumeric()
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			if !tt.check(f) {
+				t.Errorf("unexpected value: %v", f)
+			}
+		})
+	}
+}
+
+func TestUnmarshalContext(t *testing.T) {
+	data := []byte(`{"key": [1, 2, 3], "str": "value"}`)
+
+	root, err := UnmarshalContext(context.Background(), data)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if root.MustKey("str").MustString() != "value" {
+		t.Errorf("unexpected result: %s", root)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if _, err = UnmarshalContext(ctx, data); err != context.Canceled {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+}
+
+func TestUnmarshalContext_CanceledWhileDecoding(t *testing.T) {
+	// large enough to pass several cancellation checks
+	data := []byte("[" + strings.Repeat(`"value",`, 4*contextCheckInterval/8) + `"value"]`)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	if _, err := UnmarshalContext(ctx, data); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	ctx = &cancelAfter{Context: ctx, calls: 2}
+	if _, err := UnmarshalContext(ctx, data); err != context.Canceled {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+}
+
+// cancelAfter is a context that reports cancellation
+// after Err has been called the given number of times.
+type cancelAfter struct {
+	context.Context
+	calls int
+}
+
+func (c *cancelAfter) Err() error {
+	if c.calls == 0 {
+		return context.Canceled
+	}
+
+	c.calls--
+	return nil
+}
+
+func TestUnmarshal_EscapedKeys(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		key   string
+	}{
+		{name: "unicode escape", input: `{"caf\u00e9": 1}`, key: "café"},
+		{name: "surrogate pair", input: `{"\ud83d\ude00": 1}`, key: "😀"},
+		{name: "newline", input: `{"a\nb": 1}`, key: "a\nb"},
+		{name: "escaped quote", input: `{"say \"hi\"": 1}`, key: `say "hi"`},
+		{name: "backslash and slash", input: `{"a\\b\/c": 1}`, key: `a\b/c`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root, err := Unmarshal([]byte(tt.input))
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			if !root.HasKey(tt.key) {
+				t.Fatalf("key %q not found in %v", tt.key, root.sortedKeys())
+			}
+
+			node := root.MustKey(tt.key)
+			if node.Key() != tt.key {
+				t.Errorf("Key() = %q, want %q", node.Key(), tt.key)
+			}
+
+			if node.MustNumeric() != 1 {
+				t.Errorf("wrong value: %s", node)
+			}
+		})
+	}
+}
+
+func TestUnmarshalWithOptions_ReplaceInvalidSurrogates(t *testing.T) {
+	input := []byte(`{"k\udc00": "v\ud83d", "ok": "\ud83d\ude00"}`)
+
+	root, err := Unmarshal(input)
+	if err == nil {
+		t.Fatalf("expected error for the invalid surrogate in key, got %s", root)
+	} else if !strings.Contains(err.Error(), "invalid surrogate pair at index 3") {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	root = Must(Unmarshal([]byte(`["v\ud83d"]`)))
+	if _, err := root.MustIndex(0).GetString(); err == nil || !strings.Contains(err.Error(), "invalid surrogate pair") {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	root, err = UnmarshalWithOptions(input, Options{ReplaceInvalidSurrogates: true})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if got := root.MustKey("k\uFFFD").MustString(); got != "v\uFFFD" {
+		t.Errorf("wrong value: %q", got)
+	}
+
+	if got := root.MustKey("ok").MustString(); got != "\U0001F600" {
+		t.Errorf("wrong value: %q", got)
+	}
+}
+
+func TestUnmarshal_EmptyDocument(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+	}{
+		{name: "empty", input: ""},
+		{name: "whitespace only", input: " \t\r\n "},
+		{name: "lone BOM", input: "\xef\xbb\xbf"},
+		{name: "BOM and whitespace", input: "\xef\xbb\xbf \n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Unmarshal([]byte(tt.input))
+			if err != ErrEmptyDocument {
+				t.Fatalf("expected ErrEmptyDocument, got %v", err)
+			}
+
+			if !errors.Is(err, io.EOF) {
+				t.Errorf("error must match io.EOF")
+			}
+
+			if err.Error() != "unexpected end of JSON input: empty document" {
+				t.Errorf("unexpected message: %s", err.Error())
+			}
+		})
+	}
+}
+
+func TestUnmarshal_LeadingBOM(t *testing.T) {
+	root, err := Unmarshal([]byte("\xef\xbb\xbf{\"a\": [1]}"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got := root.String(); got != `{"a": [1]}` {
+		t.Errorf("got %s, want %s", got, `{"a": [1]}`)
+	}
+
+	if _, err := Unmarshal([]byte("{}\xef\xbb\xbf")); err == nil {
+		t.Errorf("BOM is only allowed at the beginning of the input")
+	}
+}
+
+func TestUnmarshal_ControlCharacters(t *testing.T) {
+	tests := []struct {
+		name   string
+		input  string
+		offset int
+		err    string
+	}{
+		{name: "tab in value", input: "{\"a\": \"x\ty\"}", offset: 8, err: `'\t'`},
+		{name: "newline in value", input: "{\"a\": \"x\ny\"}", offset: 8, err: `'\n'`},
+		{name: "NUL in value", input: "{\"a\": \"x\x00y\"}", offset: 8, err: `'\x00'`},
+		{name: "unit separator in value", input: "{\"a\": [\"\x1f\"]}", offset: 8, err: `'\x1f'`},
+		{name: "tab in key", input: "{\"k\ty\": 1}", offset: 3, err: `'\t'`},
+		{name: "newline in root string", input: "\"x\n\"", offset: 2, err: `'\n'`},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := Unmarshal([]byte(tc.input))
+
+			var serr *SyntaxError
+			if !errors.As(err, &serr) {
+				t.Fatalf("expected *SyntaxError, got %v", err)
+			}
+
+			if serr.Offset != tc.offset {
+				t.Errorf("offset = %d, want %d", serr.Offset, tc.offset)
+			}
+
+			if !strings.Contains(err.Error(), "invalid control character "+tc.err) {
+				t.Errorf("error = %q, want it to name the control character %s", err, tc.err)
+			}
+
+			var root *Node
+			if err := NewDecoder(strings.NewReader(tc.input)).Decode(&root); !errors.Is(err, ErrSyntax) {
+				t.Errorf("Decoder.Decode() = %v, want a syntax error", err)
+			}
+		})
+	}
+
+	// escaped control characters and DEL are allowed.
+	for _, input := range []string{`{"a": "x\ty\n\u0000"}`, "{\"a\": \"x\x7fy\"}"} {
+		if _, err := Unmarshal([]byte(input)); err != nil {
+			t.Errorf("Unmarshal(%q) returns error: %v", input, err)
+		}
+	}
+}
+
+func TestUnmarshal_DuplicateKeyMode(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		mode     DuplicateKeyMode
+		expected string
+		err      string
+	}{
+		{name: "overwrite", input: `{"a": 1, "a": 2}`, mode: DuplicateKeyOverwrite, expected: `{"a": 2}`},
+		{name: "error", input: `{"a": 1, "a": 2}`, mode: DuplicateKeyError, err: `duplicate key "a"`},
+		{name: "error nested", input: `[{"b": {"a": {}, "a": null}}]`, mode: DuplicateKeyError, err: `duplicate key "a"`},
+		{name: "error same key in other objects", input: `[{"a": 1}, {"a": 2, "b": {"a": 3}}]`, mode: DuplicateKeyError, expected: `[{"a": 1}, {"a": 2, "b": {"a": 3}}]`},
+		{name: "combine", input: `{"a": 1, "b": true, "a": [3], "a": "x"}`, mode: DuplicateKeyCombine, expected: `{"a": [1, [3], "x"], "b": true}`},
+		{name: "combine nested", input: `{"h": {"v": 1, "v": {"w": 2, "w": 3}}, "v": 0}`, mode: DuplicateKeyCombine, expected: `{"h": {"v": [1, {"w": [2, 3]}]}, "v": 0}`},
+		{name: "combine without duplicates", input: `{"a": [1], "b": 2}`, mode: DuplicateKeyCombine, expected: `{"a": [1], "b": 2}`},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			root, err := UnmarshalWithOptions([]byte(tc.input), Options{DuplicateKeyMode: tc.mode})
+			if tc.err != "" {
+				var serr *SyntaxError
+				if !errors.As(err, &serr) {
+					t.Fatalf("expected *SyntaxError, got %v", err)
+				}
+
+				if !strings.Contains(err.Error(), tc.err) {
+					t.Errorf("error = %q, want it to contain %q", err, tc.err)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			bs, err := Marshal(root)
+			if err != nil {
+				t.Fatalf("Marshal returns error: %v", err)
+			}
+
+			got := Must(Unmarshal(bs))
+			if !equals(got, Must(Unmarshal([]byte(tc.expected)))) {
+				t.Errorf("got %s, want %s", bs, tc.expected)
+			}
+		})
+	}
+}
+
+func TestUnmarshal_DuplicateKeyCombine_Shape(t *testing.T) {
+	root := Must(UnmarshalWithOptions([]byte(`{"a": 1, "b": {"c": null}, "a": "x"}`), Options{DuplicateKeyMode: DuplicateKeyCombine}))
+
+	a := root.MustKey("a")
+	if !a.IsArray() || a.Size() != 2 || a.Key() != "a" || a.prev != root {
+		t.Fatalf("combined node must be an array of 2 elements under key a, got %s", a)
+	}
+
+	for i, expected := range []string{`1`, `"x"`} {
+		elem := a.MustIndex(i)
+		if elem.prev != a || elem.Key() != "" || *elem.index != i {
+			t.Errorf("element %d is not attached to the combined array", i)
+		}
+
+		if elem.String() != expected {
+			t.Errorf("element %d = %s, want %s", i, elem, expected)
+		}
+	}
+
+	if !a.Changed() || !root.Changed() {
+		t.Errorf("the combined array and its ancestors must be marked as modified")
+	}
+
+	if b := root.MustKey("b"); b.Changed() || b.String() != `{"c": null}` {
+		t.Errorf("the other members must be left as is, got %s", b)
+	}
+}
+
+// rfc8259Accepted and rfc8259Rejected are taken from the JSONTestSuite corpus
+// (https://github.com/nst/JSONTestSuite). The y_ cases must be accepted and the n_ cases rejected.
+// The i_ cases are left to the implementation by RFC 8259, and listed by how UnmarshalRFC8259 treats them.
+var rfc8259Accepted = []struct {
+	name  string
+	input string
+}{
+	{"y_array_arraysWithSpaces", `[[]   ]`},
+	{"y_array_empty-string", `[""]`},
+	{"y_array_empty", `[]`},
+	{"y_array_false", `[false]`},
+	{"y_array_heterogeneous", `[null, 1, "1", {}]`},
+	{"y_array_null", `[null]`},
+	{"y_array_with_1_and_newline", "[1\n]"},
+	{"y_array_with_leading_space", ` [1]`},
+	{"y_array_with_several_null", `[1,null,null,null,2]`},
+	{"y_array_with_trailing_space", `[2] `},
+	{"y_number", `[123e65]`},
+	{"y_number_0e+1", `[0e+1]`},
+	{"y_number_0e1", `[0e1]`},
+	{"y_number_after_space", `[ 4]`},
+	{"y_number_double_close_to_zero", `[-0.000000000000000000000000000000000000000000000000000000000000000000000000000001]`},
+	{"y_number_int_with_exp", `[20e1]`},
+	{"y_number_minus_zero", `[-0]`},
+	{"y_number_negative_int", `[-123]`},
+	{"y_number_negative_one", `[-1]`},
+	{"y_number_real_capital_e", `[1E22]`},
+	{"y_number_real_capital_e_neg_exp", `[1E-2]`},
+	{"y_number_real_capital_e_pos_exp", `[1E+2]`},
+	{"y_number_real_exponent", `[123e45]`},
+	{"y_number_real_fraction_exponent", `[123.456e78]`},
+	{"y_number_real_neg_exp", `[1e-2]`},
+	{"y_number_real_pos_exponent", `[1e+2]`},
+	{"y_number_simple_int", `[123]`},
+	{"y_number_simple_real", `[123.456789]`},
+	{"y_object_basic", `{"asd":"sdf"}`},
+	{"y_object_duplicated_key", `{"a":"b","a":"c"}`},
+	{"y_object_empty", `{}`},
+	{"y_object_empty_key", `{"":0}`},
+	{"y_object_escaped_null_in_key", `{"foo\u0000bar": 42}`},
+	{"y_object_extreme_numbers", `{ "min": -1.0e+28, "max": 1.0e+28 }`},
+	{"y_object_simple", `{"a":[]}`},
+	{"y_object_string_unicode", `{"title":"Полтора" }`},
+	{"y_object_with_newlines", "{\n\"a\": \"b\"\n}"},
+	{"y_string_1_2_3_bytes_UTF-8_sequences", `["\u0060\u012a\u12AB"]`},
+	{"y_string_accepted_surrogate_pair", `["𐐷"]`},
+	{"y_string_allowed_escapes", `["\"\\\/\b\f\n\r\t"]`},
+	{"y_string_backslash_and_u_escaped_zero", `["\\u0000"]`},
+	{"y_string_comments", `["a/*b*/c/*d//e"]`},
+	{"y_string_escaped_noncharacter", `["￿"]`},
+	{"y_string_nonCharacterInUTF-8_U+FFFF", "[\"\xef\xbf\xbf\"]"},
+	{"y_string_unicode_U+10FFFE_nonchar", `["􏿾"]`},
+	{"y_string_utf8", `["€𝄞"]`},
+	{"y_string_with_del_character", "[\"a\x7fa\"]"},
+	{"y_structure_lonely_false", `false`},
+	{"y_structure_lonely_int", `42`},
+	{"y_structure_lonely_negative_real", `-0.1`},
+	{"y_structure_lonely_null", `null`},
+	{"y_structure_lonely_string", `"asd"`},
+	{"y_structure_lonely_true", `true`},
+	{"y_structure_string_empty", `""`},
+	{"y_structure_trailing_newline", "[\"a\"]\n"},
+	{"y_structure_whitespace_array", " [] "},
+	{"i_number_real_underflow", `[123e-10000000]`},
+	{"i_number_too_big_neg_int", `[-123123123123123123123123123123]`},
+	{"i_number_very_big_negative_int", `[-237462374673276894279832749832423479823246327846]`},
+	{"i_structure_UTF-8_BOM_empty_object", "\xef\xbb\xbf{}"},
+}
+
+var rfc8259Rejected = []struct {
+	name  string
+	input string
+}{
+	{"n_array_1_true_without_comma", `[1 true]`},
+	{"n_array_colon_instead_of_comma", `["": 1]`},
+	{"n_array_comma_after_close", `[""],`},
+	{"n_array_comma_and_number", `[,1]`},
+	{"n_array_double_comma", `[1,,2]`},
+	{"n_array_extra_close", `["x"]]`},
+	{"n_array_extra_comma", `["",]`},
+	{"n_array_incomplete", `["x"`},
+	{"n_array_incomplete_invalid_value", `[x`},
+	{"n_array_inner_array_no_comma", `[3[4]]`},
+	{"n_array_items_separated_by_semicolon", `[1:2]`},
+	{"n_array_just_comma", `[,]`},
+	{"n_array_just_minus", `[-]`},
+	{"n_array_missing_value", `[   , ""]`},
+	{"n_array_newlines_unclosed", "[\"a\",\n4\n,1,"},
+	{"n_array_number_and_comma", `[1,]`},
+	{"n_array_star_inside", `[*]`},
+	{"n_array_unclosed", `[""`},
+	{"n_array_unclosed_with_object_inside", `[{}`},
+	{"n_incomplete_false", `[fals]`},
+	{"n_incomplete_null", `[nul]`},
+	{"n_incomplete_true", `[tru]`},
+	{"n_number_++", `[++1234]`},
+	{"n_number_+1", `[+1]`},
+	{"n_number_+Inf", `[+Inf]`},
+	{"n_number_-01", `[-01]`},
+	{"n_number_-1.0.", `[-1.0.]`},
+	{"n_number_-2.", `[-2.]`},
+	{"n_number_-NaN", `[-NaN]`},
+	{"n_number_.-1", `[.-1]`},
+	{"n_number_.2e-3", `[.2e-3]`},
+	{"n_number_0.1.2", `[0.1.2]`},
+	{"n_number_0.3e+", `[0.3e+]`},
+	{"n_number_0.e1", `[0.e1]`},
+	{"n_number_0_capital_E", `[0E]`},
+	{"n_number_1.0e", `[1.0e]`},
+	{"n_number_1_000", `[1 000.0]`},
+	{"n_number_1eE2", `[1eE2]`},
+	{"n_number_2.e3", `[2.e3]`},
+	{"n_number_9.e+", `[9.e+]`},
+	{"n_number_Inf", `[Inf]`},
+	{"n_number_NaN", `[NaN]`},
+	{"n_number_hex_1_digit", `[0x1]`},
+	{"n_number_infinity", `[Infinity]`},
+	{"n_number_minus_infinity", `[-Infinity]`},
+	{"n_number_minus_space_1", `[- 1]`},
+	{"n_number_neg_int_starting_with_zero", `[-012]`},
+	{"n_number_neg_real_without_int_part", `[-.123]`},
+	{"n_number_real_without_fractional_part", `[1.]`},
+	{"n_number_starting_with_dot", `[.123]`},
+	{"n_number_with_leading_zero", `[012]`},
+	{"n_object_bad_value", `["x", truth]`},
+	{"n_object_comma_instead_of_colon", `{"x", null}`},
+	{"n_object_double_colon", `{"x"::"b"}`},
+	{"n_object_garbage_at_end", `{"a":"a" 123}`},
+	{"n_object_key_with_single_quotes", `{key: 'value'}`},
+	{"n_object_missing_colon", `{"a" b}`},
+	{"n_object_missing_key", `{:"b"}`},
+	{"n_object_missing_semicolon", `{"a" "b"}`},
+	{"n_object_missing_value", `{"a":`},
+	{"n_object_no-colon", `{"a"`},
+	{"n_object_non_string_key", `{1:1}`},
+	{"n_object_single_quote", `{'a':0}`},
+	{"n_object_trailing_comma", `{"id":0,}`},
+	{"n_object_trailing_comment", `{"a":"b"}/**/`},
+	{"n_object_unquoted_key", `{a: "b"}`},
+	{"n_object_with_trailing_garbage", `{"a": true} "x"`},
+	{"n_single_space", ` `},
+	{"n_string_1_surrogate_then_escape", `["\uD800\"]`},
+	{"n_string_escape_x", `["\x00"]`},
+	{"n_string_escaped_backslash_bad", `["\\\"]`},
+	{"n_string_escaped_ctrl_char_tab", "[\"\\\t\"]"},
+	{"n_string_incomplete_escape", `["\"]`},
+	{"n_string_incomplete_escaped_character", `["\u00A"]`},
+	{"n_string_invalid_unicode_escape", `["\uqqqq"]`},
+	{"n_string_invalid_utf8_after_escape", "[\"\\\xe5\"]"},
+	{"n_string_no_quotes_with_bad_escape", `[\n]`},
+	{"n_string_single_quote", `['single quote']`},
+	{"n_string_unescaped_ctrl_char", "[\"a\x00a\"]"},
+	{"n_string_unescaped_newline", "[\"new\nline\"]"},
+	{"n_string_unescaped_tab", "[\"\t\"]"},
+	{"n_structure_UTF8_BOM_no_data", "\xef\xbb\xbf"},
+	{"n_structure_array_with_extra_array_close", `[1]]`},
+	{"n_structure_close_unopened_array", `1]`},
+	{"n_structure_double_array", `[][]`},
+	{"n_structure_end_array", `]`},
+	{"n_structure_lone-open-bracket", `[`},
+	{"n_structure_no_data", ``},
+	{"n_structure_null-byte-outside-string", "[\x00]"},
+	{"n_structure_object_followed_by_closing_object", `{}}`},
+	{"n_structure_open_object", `{`},
+	{"n_structure_single_star", `*`},
+	{"n_structure_trailing_#", `{"a":"b"}#{}`},
+	{"n_structure_unclosed_array", `[1`},
+	{"n_structure_whitespace_formfeed", "[\f]"},
+	{"i_number_neg_int_huge_exp", `[-1e+9999]`},
+	{"i_number_pos_double_huge_exp", `[1.5e+9999]`},
+	{"i_number_real_neg_overflow", `[-123123e100000]`},
+	{"i_number_real_pos_overflow", `[123123e100000]`},
+	{"i_string_1st_surrogate_but_2nd_missing", `["\uDADA"]`},
+	{"i_string_1st_valid_surrogate_2nd_invalid", `["\uD888ሴ"]`},
+	{"i_string_incomplete_surrogate_and_escape_valid", `["\uD800\n"]`},
+	{"i_string_invalid_lonely_surrogate", `["\ud800"]`},
+	{"i_string_inverted_surrogates_U+1D11E", `["\uDd1e\uD834"]`},
+	{"i_string_invalid_utf-8", "[\"\xff\"]"},
+	{"i_string_lone_utf8_continuation_byte", "[\"\x81\"]"},
+	{"i_string_overlong_sequence_2_bytes", "[\"\xc0\xaf\"]"},
+	{"i_string_not_in_unicode_range", "[\"\xf4\xbf\xbf\xbf\"]"},
+	{"i_string_UTF-16LE_with_BOM", "\xff\xfe[\x00\"\x00"},
+}
+
+func TestUnmarshalRFC8259(t *testing.T) {
+	for _, tc := range rfc8259Accepted {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := UnmarshalRFC8259([]byte(tc.input)); err != nil {
+				t.Errorf("UnmarshalRFC8259(%q) returns error: %v", tc.input, err)
+			}
+		})
+	}
+
+	for _, tc := range rfc8259Rejected {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := UnmarshalRFC8259([]byte(tc.input)); err == nil {
+				t.Errorf("UnmarshalRFC8259(%q) must fail", tc.input)
+			}
+		})
+	}
+}
+
+func TestUnmarshalRFC8259_Error(t *testing.T) {
+	tests := []struct {
+		input  string
+		offset int
+		msg    string
+	}{
+		{`[1, 1e400]`, 4, "number out of range"},
+		{`{"a": [-1e400]}`, 7, "number out of range"},
+		{`["a", "\ud800"]`, 7, "invalid surrogate pair"},
+		{"[\"\xff\"]", 2, "invalid UTF-8 encoding"},
+		{"{\"\xc0\xaf\": 1}", 2, "invalid UTF-8 encoding"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.input, func(t *testing.T) {
+			_, err := UnmarshalRFC8259([]byte(tc.input))
+
+			var serr *SyntaxError
+			if !errors.As(err, &serr) {
+				t.Fatalf("expected *SyntaxError, got %v", err)
+			}
+
+			if serr.Offset != tc.offset || !strings.Contains(err.Error(), tc.msg) {
+				t.Errorf("error = %v (offset %d), want %q at %d", err, serr.Offset, tc.msg, tc.offset)
+			}
+		})
+	}
+
+	// the checked values are the ones of the returned tree.
+	root, err := UnmarshalRFC8259([]byte(`{"a": ["😀", 1e308]}`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got := root.MustKey("a").MustIndex(0).MustString(); got != "😀" {
+		t.Errorf("string = %q, want %q", got, "😀")
+	}
+}
+
+func TestUnmarshal_CommaAfterRoot(t *testing.T) {
+	for _, input := range []string{`[],null`, `[1],2`, `{"a":1},"b"`, `{},{}`, `[[]],[]`} {
+		if node, err := Unmarshal([]byte(input)); err == nil {
+			t.Errorf("Unmarshal(%q) = %s, must fail", input, node)
+		}
+	}
+}
+
+func TestNode_UnmarshalInto(t *testing.T) {
+	node := NullNode("")
+	inputs := []string{
+		`{"id": 1, "tags": ["a", "b"], "meta": {"ok": true}}`,
+		`{"id": 2, "tags": [], "meta": {"ok": false, "extra": null}}`,
+		`[1, {"a": [2, 3]}, "x"]`,
+		`"scalar"`,
+		`{"id": 3, "tags": ["c"]}`,
+		string(recordsJSON(3)),
+	}
+
+	for _, input := range inputs {
+		if err := node.UnmarshalInto([]byte(input)); err != nil {
+			t.Fatalf("UnmarshalInto(%s) returns error: %v", input, err)
+		}
+
+		expected := Must(Unmarshal([]byte(input)))
+		if !equals(node, expected) {
+			t.Errorf("UnmarshalInto(%s) = %s", input, node)
+		}
+
+		value, err := Marshal(node)
+		if err != nil {
+			t.Fatalf("Marshal returns error: %v", err)
+		}
+
+		if string(value) != input {
+			t.Errorf("Marshal() = %s, want %s", value, input)
+		}
+
+		if node.Changed() || node.prev != nil {
+			t.Errorf("UnmarshalInto(%s) returns a modified or attached root", input)
+		}
+
+		node.WalkPath(func(path string, child *Node) bool {
+			for _, grandchild := range child.next {
+				if grandchild.prev != child {
+					t.Errorf("node at %s has a wrong parent", grandchild.Path())
+				}
+			}
+
+			return true
+		})
+	}
+
+	if err := node.MustIndex(0).UnmarshalInto([]byte(`1`)); err == nil {
+		t.Errorf("UnmarshalInto a node with a parent must fail")
+	}
+
+	if err := node.UnmarshalInto([]byte(`{"a": [1,]}`)); err == nil {
+		t.Errorf("UnmarshalInto of an invalid document must fail")
+	}
+
+	if !node.IsNull() || node.String() != "null" {
+		t.Errorf("node = %s after a failed UnmarshalInto, want null", node)
+	}
+
+	if err := node.UnmarshalInto([]byte(`{"a": 1}`)); err != nil || node.MustKey("a").MustNumeric() != 1 {
+		t.Errorf("UnmarshalInto after a failure = %s, %v", node, err)
+	}
+
+	if err := (*Node)(nil).UnmarshalInto([]byte(`1`)); err != ErrNilNode {
+		t.Errorf("expected ErrNilNode for nil receiver, got %v", err)
+	}
+}
+
+func BenchmarkNode_UnmarshalInto(b *testing.B) {
+	data := recordsJSON(100)
+
+	b.Run("Unmarshal", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if _, err := Unmarshal(data); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	b.Run("UnmarshalInto", func(b *testing.B) {
+		b.ReportAllocs()
+		node := NullNode("")
+		for i := 0; i < b.N; i++ {
+			if err := node.UnmarshalInto(data); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+var fuzzSeeds = []string{
+	`null`, `true`, `false`, `0`, `-0`, `1.5e-10`, `123456789012345678901234567890`, `-1E+2`,
+	`""`, `"\u0000\"\\\/\b\f\n\r\t"`, `"😀 안녕"`, `"aéb"`,
+	`[]`, `{}`, `[1, [2, [3]], {"a": null}]`, `{"a": {"b": [true, false]}, "c": "d"}`,
+	`{"a":1,"a":2}`, `[1,]`, `{"a" 1}`, `"unterminated`, `01`, `1.`, `.5`, `[`, `}`,
+}
+
+func FuzzUnmarshal(f *testing.F) {
+	for _, seed := range fuzzSeeds {
+		f.Add([]byte(seed))
+	}
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		node, err := Unmarshal(data)
+		if err != nil {
+			return
+		}
+
+		if _, err := Marshal(node); err != nil {
+			t.Fatalf("Marshal(Unmarshal(%q)) returns error: %v", data, err)
+		}
+
+		if _, err := Indent(data, "  "); err != nil {
+			t.Fatalf("Indent(%q) returns error for a valid document: %v", data, err)
+		}
+	})
+}
+
+func FuzzMarshalRoundTrip(f *testing.F) {
+	for _, seed := range fuzzSeeds {
+		f.Add([]byte(seed))
+	}
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		node, err := Unmarshal(data)
+		if err != nil {
+			return
+		}
+
+		// the first pass reuses the source bytes, the second one encodes
+		// every value from scratch.
+		normalized := node.Clone()
+		if err := normalized.Normalize(); err != nil {
+			// values are loaded lazily, so numbers out of the float64 range
+			// are only rejected here.
+			return
+		}
+
+		for _, n := range []*Node{node, normalized} {
+			value, err := Marshal(n)
+			if err != nil {
+				t.Fatalf("Marshal(%q) returns error: %v", data, err)
+			}
+
+			decoded, err := Unmarshal(value)
+			if err != nil {
+				t.Fatalf("Unmarshal(Marshal(%q)) = %q returns error: %v", data, value, err)
+			}
+
+			if !equals(node, decoded) {
+				t.Errorf("round-trip of %q = %q, not equal", data, value)
+			}
+		}
+	})
+}
@@ -3,55 +3,304 @@ package json
 import (
 	"bytes"
 	"fmt"
+	"io"
+	"math"
 	"strconv"
+	"strings"
 )
 
+// encodeOptions holds the options that control how a node is serialized.
+type encodeOptions struct {
+	omitNull    bool // omitNull skips object members whose value is null.
+	omitEmpty   bool // omitEmpty skips object members whose value is empty. see isEmptyValue.
+	allowInfNaN bool // allowInfNaN writes NaN and Infinity numbers as non-standard literals.
+	fixedPrec   bool // fixedPrec writes the non-integral numbers with floatPrec decimal places.
+	floatPrec   int
+}
+
+// filtering reports whether any of the object member filters is enabled.
+func (o encodeOptions) filtering() bool {
+	return o.omitNull || o.omitEmpty
+}
+
+// reencode reports whether the unmodified node must be encoded from its value
+// instead of copying its source.
+func (o encodeOptions) reencode(node *Node) bool {
+	if !node.ready() {
+		return false
+	}
+
+	if o.filtering() && node.isContainer() {
+		return true
+	}
+
+	if o.fixedPrec && (node.isContainer() || isFractional(node)) {
+		return true
+	}
+
+	// the source may hold NaN or Infinity literals accepted by Options.AllowInfNaN,
+	// which must be rejected while encoding.
+	return !o.allowInfNaN && (node.isContainer() || node.IsNumber()) && containsNonFinite(node.source())
+}
+
+// isFractional reports whether the node is a number with a fractional part.
+func isFractional(node *Node) bool {
+	f, err := node.GetNumeric()
+	return err == nil && f != math.Trunc(f)
+}
+
+// omit reports whether the given object member should be skipped.
+func (o encodeOptions) omit(node *Node) bool {
+	if o.omitNull && node.IsNull() {
+		return true
+	}
+
+	return o.omitEmpty && isEmptyValue(node)
+}
+
+// isEmptyValue reports whether the given node is considered "empty"
+// in the same sense as the `omitempty` struct tag of the standard library.
+//
+// The empty values for each type are:
+//
+//   - Null: always empty
+//   - String: ""
+//   - Number: 0
+//   - Boolean: false
+//   - Array, Object: no elements
+func isEmptyValue(node *Node) bool {
+	switch node.Type() {
+	case Null:
+		return true
+	case String:
+		s, err := node.GetString()
+		return err == nil && s == ""
+	case Number, Float:
+		f, err := node.GetNumeric()
+		return err == nil && f == 0
+	case Boolean:
+		b, err := node.GetBool()
+		return err == nil && !b
+	case Array, Object:
+		return node.Size() == 0
+	default:
+		return false
+	}
+}
+
+// Encoder writes JSON encoded nodes to an output stream.
+type Encoder struct {
+	w       io.Writer
+	opts    encodeOptions
+	newline bool
+}
+
+// NewEncoder returns a new encoder that writes to w.
+func NewEncoder(w io.Writer) *Encoder {
+	return &Encoder{w: w}
+}
+
+// SetOmitNull makes the encoder skip object members whose value is null.
+//
+// This only affects the serialized output, the node itself is not modified.
+func (e *Encoder) SetOmitNull(omit bool) {
+	e.opts.omitNull = omit
+}
+
+// SetOmitEmpty makes the encoder skip object members whose value is empty.
+// Empty values are null, "", 0, false and containers without any elements.
+//
+// Emptiness is checked on the member itself, so an object whose members are all
+// omitted is still written as {}. This only affects the serialized output,
+// the node itself is not modified.
+func (e *Encoder) SetOmitEmpty(omit bool) {
+	e.opts.omitEmpty = omit
+}
+
+// SetAllowInfNaN makes the encoder write NaN and infinite numbers as
+// the non-standard `NaN`, `Infinity` and `-Infinity` literals,
+// matching Options.AllowInfNaN of the decoder.
+//
+// Otherwise, encoding such numbers returns an error.
+func (e *Encoder) SetAllowInfNaN(allow bool) {
+	e.opts.allowInfNaN = allow
+}
+
+// SetFloatPrecision makes the encoder write the numbers with a fractional part rounded
+// to prec decimal places, e.g. 3.14159 is written as 3.14 with a precision of 2.
+// Integral numbers such as 42 are still written without a decimal point.
+//
+// A negative precision restores the default, the shortest representation
+// that parses back to the same value. This only affects the serialized output,
+// the node itself is not modified.
+func (e *Encoder) SetFloatPrecision(prec int) {
+	e.opts.fixedPrec = prec >= 0
+	e.opts.floatPrec = prec
+}
+
+// SetTrailingNewline makes the encoder write a newline character after each encoded value,
+// like the Encoder of the standard library. It is disabled by default.
+func (e *Encoder) SetTrailingNewline(newline bool) {
+	e.newline = newline
+}
+
+// Encode writes the JSON encoding of the node to the stream.
+func (e *Encoder) Encode(node *Node) error {
+	bs, err := marshal(node, e.opts)
+	if err != nil {
+		return err
+	}
+
+	if e.newline {
+		bs = append(bs, newLine)
+	}
+
+	_, err = e.w.Write(bs)
+	return err
+}
+
 // Marshal returns the JSON encoding of a Node.
 func Marshal(node *Node) ([]byte, error) {
+	return marshal(node, encodeOptions{})
+}
+
+// ToJSON returns the JSON encoding of a Node as a string. It is the counterpart of FromJSON.
+//
+// Usage:
+//
+//	s, err := json.ToJSON(json.StringNode("", "foo"))
+//	if err != nil {
+//		return err
+//	}
+//
+//	result: s == `"foo"`
+func ToJSON(node *Node) (string, error) {
+	value, err := Marshal(node)
+	if err != nil {
+		return "", err
+	}
+
+	return string(value), nil
+}
+
+// MarshalTo appends the JSON encoding of the current node to the given buffer,
+// instead of allocating a new slice like Marshal. On error, the buffer is left unchanged.
+//
+// Usage:
+//
+//	var buf bytes.Buffer
+//	buf.WriteByte('[')
+//	for i, node := range nodes {
+//		if i > 0 {
+//			buf.WriteByte(',')
+//		}
+//
+//		if err := node.MarshalTo(&buf); err != nil {
+//			return err
+//		}
+//	}
+//	buf.WriteByte(']')
+func (n *Node) MarshalTo(buf *bytes.Buffer) error {
+	return marshalTo(buf, n, encodeOptions{})
+}
+
+func marshal(node *Node, opts encodeOptions) ([]byte, error) {
 	var buf bytes.Buffer
+	if err := marshalTo(&buf, node, opts); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// marshalTo appends the encoding of the node to buf, and truncates buf back on error.
+func marshalTo(buf *bytes.Buffer, node *Node, opts encodeOptions) error {
+	size := buf.Len()
+	if err := encodeNode(buf, node, opts, make(map[*Node]bool)); err != nil {
+		buf.Truncate(size)
+		return err
+	}
+
+	return nil
+}
+
+// cycleError reports a container that is reached again while it is being encoded,
+// which would otherwise recurse forever. The path is built while unwinding, in the
+// notation of Node.Path without the leading `$`.
+type cycleError struct {
+	path string
+}
+
+func (e *cycleError) Error() string {
+	return "cycle detected at $" + e.path + ": the node contains itself"
+}
+
+// encodeNode writes the encoding of the node to buf. It may write a partial output on error.
+//
+// visiting holds the containers being encoded, from the root down to the node.
+// A node shared by several parents is not a cycle, only a node that contains itself is.
+func encodeNode(buf *bytes.Buffer, node *Node, opts encodeOptions, visiting map[*Node]bool) error {
 	var (
 		sVal string
 		bVal bool
 		nVal float64
-		oVal []byte
 		err  error
 	)
 
 	if node == nil {
-		return nil, fmt.Errorf("node is nil")
-	} else if node.modified {
+		return ErrNilNode
+	} else if node.modified || opts.reencode(node) {
 		switch node.nodeType {
 		case Null:
 			buf.Write(nullLiteral)
 
-		case Number:
+		case Number, Float:
 			nVal, err = node.GetNumeric()
 			if err != nil {
-				return nil, err
+				return err
+			}
+
+			if math.IsNaN(nVal) || math.IsInf(nVal, 0) {
+				if !opts.allowInfNaN {
+					return fmt.Errorf("unsupported number value: %v", nVal)
+				}
+
+				buf.Write(nonFiniteLiteral(nVal))
+				break
 			}
 
-			num := fmt.Sprintf("%g", nVal)
-			buf.WriteString(num)
+			if opts.fixedPrec && (node.nodeType == Float || isFractional(node)) {
+				buf.WriteString(strconv.FormatFloat(nVal, 'f', opts.floatPrec, 64))
+				break
+			}
+
+			buf.WriteString(formatNumber(nVal, node.nodeType == Float))
 
 		case String:
 			sVal, err = node.GetString()
 			if err != nil {
-				return nil, err
+				return err
 			}
 
-			quoted := fmt.Sprint(strconv.Quote(sVal))
-			buf.WriteString(quoted)
+			buf.Write(Quote(sVal, doubleQuote))
 
 		case Boolean:
 			bVal, err = node.GetBool()
 			if err != nil {
-				return nil, err
+				return err
 			}
 
 			bStr := fmt.Sprintf("%t", bVal)
 			buf.WriteString(bStr)
 
 		case Array:
+			if visiting[node] {
+				return &cycleError{}
+			}
+
+			visiting[node] = true
+			defer delete(visiting, node)
+
 			buf.WriteByte(bracketOpen)
 
 			for i := 0; i < len(node.next); i++ {
@@ -61,40 +310,52 @@ func Marshal(node *Node) ([]byte, error) {
 
 				elem, ok := node.next[strconv.Itoa(i)]
 				if !ok {
-					return nil, fmt.Errorf("array element %d is not found", i)
+					return fmt.Errorf("array element %d is not found", i)
 				}
 
-				oVal, err = Marshal(elem)
-				if err != nil {
-					return nil, err
-				}
+				if err = encodeNode(buf, elem, opts, visiting); err != nil {
+					if cerr, ok := err.(*cycleError); ok {
+						cerr.path = "[" + strconv.Itoa(i) + "]" + cerr.path
+					}
 
-				buf.Write(oVal)
+					return err
+				}
 			}
 
 			buf.WriteByte(bracketClose)
 
 		case Object:
+			if visiting[node] {
+				return &cycleError{}
+			}
+
+			visiting[node] = true
+			defer delete(visiting, node)
+
 			buf.WriteByte(curlyOpen)
 
 			bVal = false
 			for k, v := range node.next {
+				if v != nil && opts.omit(v) {
+					continue
+				}
+
 				if bVal {
 					buf.WriteByte(comma)
 				} else {
 					bVal = true
 				}
 
-				key := fmt.Sprint(strconv.Quote(k))
-				buf.WriteString(key)
+				buf.Write(Quote(k, doubleQuote))
 				buf.WriteByte(colon)
 
-				oVal, err = Marshal(v)
-				if err != nil {
-					return nil, err
-				}
+				if err = encodeNode(buf, v, opts, visiting); err != nil {
+					if cerr, ok := err.(*cycleError); ok {
+						cerr.path = pathKey(k) + cerr.path
+					}
 
-				buf.Write(oVal)
+					return err
+				}
 			}
 
 			buf.WriteByte(curlyClose)
@@ -102,8 +363,32 @@ func Marshal(node *Node) ([]byte, error) {
 	} else if node.ready() {
 		buf.Write(node.source())
 	} else {
-		return nil, fmt.Errorf("node is not modified")
+		return fmt.Errorf("node is not modified")
 	}
 
-	return buf.Bytes(), nil
+	return nil
+}
+
+// formatNumber formats the finite number in the shortest form that parses back to the same value.
+// A float-classified number keeps a decimal point even if it is integral (e.g. 1.0, -0.0),
+// while an integer-classified one is written without it.
+func formatNumber(f float64, float bool) string {
+	num := strconv.FormatFloat(f, 'g', -1, 64)
+	if float && !strings.ContainsAny(num, ".e") {
+		num += ".0"
+	}
+
+	return num
+}
+
+// nonFiniteLiteral returns the literal of the given NaN or infinite number.
+func nonFiniteLiteral(f float64) []byte {
+	switch {
+	case math.IsNaN(f):
+		return nanLiteral
+	case f > 0:
+		return infinityLiteral
+	default:
+		return negInfinityLiteral
+	}
 }
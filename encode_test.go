@@ -1,8 +1,12 @@
 package json
 
 import (
+	"bytes"
 	"encoding/json"
+	"math"
+	"strings"
 	"testing"
+	"unicode/utf8"
 )
 
 func TestMarshal_Primitive(t *testing.T) {
@@ -188,6 +192,60 @@ func TestMarshal_Errors(t *testing.T) {
 	}
 }
 
+func TestNode_MarshalTo(t *testing.T) {
+	nodes := []*Node{
+		Must(Unmarshal([]byte(`{"a": [1, 2]}`))),
+		StringNode("", "x\ny"),
+		ArrayNode("", []*Node{NumberNode("", 1.5), NullNode("")}),
+	}
+
+	var buf bytes.Buffer
+	buf.WriteByte(bracketOpen)
+	for i, node := range nodes {
+		if i > 0 {
+			buf.WriteByte(comma)
+		}
+
+		if err := node.MarshalTo(&buf); err != nil {
+			t.Fatalf("MarshalTo returns error: %v", err)
+		}
+	}
+	buf.WriteByte(bracketClose)
+
+	if expected := `[{"a": [1, 2]},"x\ny",[1.5,null]]`; buf.String() != expected {
+		t.Errorf("got %s, want %s", buf.String(), expected)
+	}
+
+	for _, node := range nodes {
+		var single bytes.Buffer
+		if err := node.MarshalTo(&single); err != nil {
+			t.Fatalf("MarshalTo returns error: %v", err)
+		}
+
+		if value, err := Marshal(node); err != nil || !bytes.Equal(value, single.Bytes()) {
+			t.Errorf("MarshalTo = %s, Marshal = %s, %v", single.Bytes(), value, err)
+		}
+	}
+}
+
+func TestNode_MarshalTo_Error(t *testing.T) {
+	buf := bytes.NewBufferString("prefix")
+
+	// the error is found after the first element is written.
+	node := ArrayNode("", []*Node{NumberNode("", 1), valueNode(nil, "", Boolean, 1)})
+	if err := node.MarshalTo(buf); err == nil {
+		t.Fatalf("expected error")
+	}
+
+	if buf.String() != "prefix" {
+		t.Errorf("buffer must be left unchanged on error, got %s", buf.String())
+	}
+
+	if err := (*Node)(nil).MarshalTo(buf); err != ErrNilNode {
+		t.Errorf("expected ErrNilNode, got %v", err)
+	}
+}
+
 func TestMarshal_Nil(t *testing.T) {
 	_, err := Marshal(nil)
 	if err == nil {
@@ -285,3 +343,453 @@ func BenchmarkMarshal(b *testing.B) {
 		}
 	}
 }
+
+func TestEncoder_OmitOptions(t *testing.T) {
+	data := `{"null":null,"str":"","num":0,"bool":false,"arr":[],"obj":{},"val":"foo","nested":{"a":null,"b":[null,""]}}`
+
+	tests := []struct {
+		name      string
+		omitNull  bool
+		omitEmpty bool
+		expected  string
+	}{
+		{
+			name:     "no options",
+			expected: data,
+		},
+		{
+			name:     "omit null",
+			omitNull: true,
+			expected: `{"str":"","num":0,"bool":false,"arr":[],"obj":{},"val":"foo","nested":{"b":[null,""]}}`,
+		},
+		{
+			name:      "omit empty",
+			omitEmpty: true,
+			expected:  `{"val":"foo","nested":{"b":[null,""]}}`,
+		},
+		{
+			name:      "omit null and empty",
+			omitNull:  true,
+			omitEmpty: true,
+			expected:  `{"val":"foo","nested":{"b":[null,""]}}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := Must(Unmarshal([]byte(data)))
+
+			var buf bytes.Buffer
+			enc := NewEncoder(&buf)
+			enc.SetOmitNull(tt.omitNull)
+			enc.SetOmitEmpty(tt.omitEmpty)
+
+			if err := enc.Encode(root); err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			got := Must(Unmarshal(buf.Bytes()))
+			expected := Must(Unmarshal([]byte(tt.expected)))
+			if got.Size() != expected.Size() {
+				t.Fatalf("wrong result: '%s', expected '%s'", buf.String(), tt.expected)
+			}
+
+			for key, value := range expected.MustObject() {
+				member, err := got.GetKey(key)
+				if err != nil {
+					t.Errorf("missing key %s in '%s'", key, buf.String())
+					continue
+				}
+
+				if value.IsObject() {
+					if member.Size() != value.Size() {
+						t.Errorf("wrong member %s: '%s', expected '%s'", key, member, value)
+					}
+				} else if member.String() != value.String() {
+					t.Errorf("wrong member %s: '%s', expected '%s'", key, member, value)
+				}
+			}
+
+			if root.Changed() {
+				t.Errorf("encoding must not modify the node")
+			}
+		})
+	}
+}
+
+func TestEncoder_OmitEmpty_Modified(t *testing.T) {
+	root := ObjectNode("", map[string]*Node{
+		"zero":  NumberNode("zero", 0),
+		"one":   NumberNode("one", 1),
+		"empty": StringNode("empty", ""),
+		"list":  ArrayNode("list", nil),
+	})
+
+	var buf bytes.Buffer
+	enc := NewEncoder(&buf)
+	enc.SetOmitEmpty(true)
+
+	if err := enc.Encode(root); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if buf.String() != `{"one":1}` {
+		t.Errorf("wrong result: '%s', expected '%s'", buf.String(), `{"one":1}`)
+	}
+}
+
+func TestEncoder_AllowInfNaN(t *testing.T) {
+	root := Must(UnmarshalWithOptions([]byte(`[NaN,Infinity,-Infinity,1]`), Options{AllowInfNaN: true}))
+
+	if _, err := Marshal(root); err == nil {
+		t.Errorf("expected error for NaN and Infinity values")
+	}
+
+	if _, err := Marshal(NumberNode("", math.Inf(1))); err == nil {
+		t.Errorf("expected error for Infinity value")
+	}
+
+	var buf bytes.Buffer
+	enc := NewEncoder(&buf)
+	enc.SetAllowInfNaN(true)
+
+	if err := enc.Encode(root); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if buf.String() != `[NaN,Infinity,-Infinity,1]` {
+		t.Errorf("wrong result: '%s'", buf.String())
+	}
+}
+
+func TestMarshal_EscapedString(t *testing.T) {
+	node := ObjectNode("", map[string]*Node{
+		"ctrl\x01": StringNode("ctrl\x01", "tab\t\x02 <é>"),
+	})
+
+	value, err := Marshal(node)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	expected := `{"ctrl\u0001":"tab\t\u0002 <é>"}`
+	if string(value) != expected {
+		t.Errorf("wrong result: '%s', expected '%s'", value, expected)
+	}
+
+	if _, err := Unmarshal(value); err != nil {
+		t.Errorf("marshaled string must be valid JSON: %s", err)
+	}
+}
+
+func TestMarshal_IntNode(t *testing.T) {
+	tests := []struct {
+		value    int64
+		expected string
+	}{
+		{0, `0`},
+		{100, `100`},
+		{-42, `-42`},
+		{1000000000000000000, `1000000000000000000`},
+		{9007199254740993, `9007199254740993`},
+		{math.MaxInt64, `9223372036854775807`},
+		{math.MinInt64, `-9223372036854775808`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expected, func(t *testing.T) {
+			node := IntNode("", tt.value)
+
+			value, err := Marshal(node)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			if string(value) != tt.expected {
+				t.Errorf("wrong result: '%s', expected '%s'", value, tt.expected)
+			}
+
+			if f := node.MustNumeric(); f != float64(tt.value) {
+				t.Errorf("wrong numeric value: %v", f)
+			}
+
+			root := ArrayNode("", []*Node{IntNode("", tt.value)})
+			if value, err = Marshal(root); err != nil || string(value) != "["+tt.expected+"]" {
+				t.Errorf("wrong result in array: '%s', %v", value, err)
+			}
+		})
+	}
+
+	if value, _ := Marshal(NumberNode("", 1e18)); string(value) != `1e+18` {
+		t.Errorf("NumberNode must keep the float formatting: %s", value)
+	}
+}
+
+func TestMarshal_NumberFormatting(t *testing.T) {
+	// the parsed numbers are written as they appear in the source.
+	for _, literal := range []string{"1.0", "1", "1e3", "-0.0", "1.50", "-0"} {
+		root := Must(Unmarshal([]byte("[" + literal + ", true]")))
+		if err := root.MustIndex(1).SetBool(false); err != nil {
+			t.Fatalf("SetBool returns error: %v", err)
+		}
+
+		value, err := Marshal(root)
+		if err != nil {
+			t.Fatalf("Marshal returns error: %v", err)
+		}
+
+		if expected := "[" + literal + ",false]"; string(value) != expected {
+			t.Errorf("Marshal(%s) = %s, want %s", literal, value, expected)
+		}
+	}
+
+	tests := []struct {
+		value    float64
+		integer  string
+		floating string
+	}{
+		{1, "1", "1.0"},
+		{1e3, "1000", "1000.0"},
+		{math.Copysign(0, -1), "-0", "-0.0"},
+		{1.5, "1.5", "1.5"},
+		{-2.25, "-2.25", "-2.25"},
+		{1e21, "1e+21", "1e+21"},
+		{1e-7, "1e-07", "1e-07"},
+	}
+
+	for _, tt := range tests {
+		if value, err := Marshal(NumberNode("", tt.value)); err != nil || string(value) != tt.integer {
+			t.Errorf("Marshal(%v) = %s, %v, want %s", tt.value, value, err, tt.integer)
+		}
+
+		value, err := Marshal(FloatNode("", tt.value))
+		if err != nil || string(value) != tt.floating {
+			t.Errorf("Marshal(%v) as float = %s, %v, want %s", tt.value, value, err, tt.floating)
+			continue
+		}
+
+		parsed := Must(Unmarshal(value)).MustNumeric()
+		if parsed != tt.value || math.Signbit(parsed) != math.Signbit(tt.value) {
+			t.Errorf("round-trip of %v = %v", tt.value, parsed)
+		}
+	}
+}
+
+func TestEncoder_TrailingNewline(t *testing.T) {
+	var buf bytes.Buffer
+	enc := NewEncoder(&buf)
+
+	if err := enc.Encode(NumberNode("", 1)); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	enc.SetTrailingNewline(true)
+	for _, data := range []string{`{"a": 1}`, `[true]`} {
+		if err := enc.Encode(Must(Unmarshal([]byte(data)))); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+	}
+
+	expected := "1{\"a\": 1}\n[true]\n"
+	if buf.String() != expected {
+		t.Errorf("wrong result: %q, expected %q", buf.String(), expected)
+	}
+
+	if value, _ := Marshal(NumberNode("", 1)); string(value) != `1` {
+		t.Errorf("Marshal must not append a newline: %q", value)
+	}
+}
+
+func TestEncoder_FloatPrecision(t *testing.T) {
+	tests := []struct {
+		name     string
+		node     *Node
+		prec     int
+		expected string
+	}{
+		{name: "parsed", node: Must(Unmarshal([]byte(`3.14159`))), prec: 2, expected: `3.14`},
+		{name: "rounded up", node: NumberNode("", 2.999), prec: 2, expected: `3.00`},
+		{name: "zero places", node: NumberNode("", 2.5), prec: 0, expected: `2`},
+		{name: "integer", node: Must(Unmarshal([]byte(`42`))), prec: 2, expected: `42`},
+		{name: "integer literal", node: IntNode("", 9007199254740993), prec: 2, expected: `9007199254740993`},
+		{name: "nested", node: Must(Unmarshal([]byte(`[1.23456, {"a": -0.005}, "1.23456", 7]`))), prec: 3, expected: `[1.235,{"a":-0.005},"1.23456",7]`},
+		{name: "shortest", node: Must(Unmarshal([]byte(`[3.14159, 1.0]`))), prec: -1, expected: `[3.14159, 1.0]`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			enc := NewEncoder(&buf)
+			enc.SetFloatPrecision(tt.prec)
+
+			if err := enc.Encode(tt.node); err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			if buf.String() != tt.expected {
+				t.Errorf("wrong result: %s, expected %s", buf.String(), tt.expected)
+			}
+		})
+	}
+
+	float := FloatNode("", 1)
+
+	var buf bytes.Buffer
+	enc := NewEncoder(&buf)
+	enc.SetFloatPrecision(2)
+	if err := enc.Encode(float); err != nil || buf.String() != `1.00` {
+		t.Errorf("a float-classified number must be rounded: %s, %v", buf.String(), err)
+	}
+
+	if value, _ := Marshal(Must(Unmarshal([]byte(`3.14159`)))); string(value) != `3.14159` {
+		t.Errorf("Marshal must not be affected: %s", value)
+	}
+}
+
+func TestMarshal_StringRoundTrip(t *testing.T) {
+	var ascii []byte
+	for c := 0; c < utf8.RuneSelf; c++ {
+		ascii = append(ascii, byte(c))
+	}
+
+	for _, s := range []string{string(ascii), "  ", "\U0001F600 ￿", `A "quoted" \\`} {
+		value, err := Marshal(StringNode("", s))
+		if err != nil {
+			t.Fatalf("Marshal returns error: %v", err)
+		}
+
+		if got := Must(Unmarshal(value)).MustString(); got != s {
+			t.Errorf("round-trip of %q = %q", s, got)
+		}
+
+		// the output must be valid for the standard library too.
+		var std string
+		if err := json.Unmarshal(value, &std); err != nil || std != s {
+			t.Errorf("encoding/json decodes %s as %q, %v", value, std, err)
+		}
+	}
+}
+
+func FuzzMarshalString(f *testing.F) {
+	for _, s := range []string{"", "hello", "\x00\x1f\"\\/", "\b\f\n\r\t", "안녕 \U0001F600", " "} {
+		f.Add(s)
+	}
+
+	f.Fuzz(func(t *testing.T, s string) {
+		value, err := Marshal(StringNode("", s))
+		if err != nil {
+			t.Fatalf("Marshal(%q) returns error: %v", s, err)
+		}
+
+		node, err := Unmarshal(value)
+		if err != nil {
+			t.Fatalf("Unmarshal(%s) returns error: %v", value, err)
+		}
+
+		got, err := node.GetString()
+		if err != nil {
+			t.Fatalf("GetString returns error: %v", err)
+		}
+
+		// invalid UTF-8 bytes are written as U+FFFD, one for each byte.
+		expected := s
+		if !utf8.ValidString(s) {
+			expected = replaceInvalidBytes(s)
+		}
+
+		if got != expected {
+			t.Errorf("round-trip of %q = %q, want %q", s, got, expected)
+		}
+	})
+}
+
+// replaceInvalidBytes replaces each byte of the invalid UTF-8 sequences of s with U+FFFD.
+func replaceInvalidBytes(s string) string {
+	var sb strings.Builder
+	for i := 0; i < len(s); {
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if r == utf8.RuneError && size == 1 {
+			sb.WriteRune(utf8.RuneError)
+		} else {
+			sb.WriteString(s[i : i+size])
+		}
+
+		i += size
+	}
+
+	return sb.String()
+}
+
+func TestMarshal_CycleDetection(t *testing.T) {
+	root := ObjectNode("", nil)
+	list := ArrayNode("", []*Node{NumberNode("", 1), NullNode("")})
+	if err := root.AppendObject("list", list); err != nil {
+		t.Fatalf("AppendObject returns error: %v", err)
+	}
+
+	// wire the root into its own descendant, bypassing the checks of the append methods.
+	list.next["1"] = root
+
+	_, err := Marshal(root)
+	if err == nil {
+		t.Fatal("Marshal must fail on a cycle")
+	}
+
+	if expected := "cycle detected at $['list'][1]: the node contains itself"; err.Error() != expected {
+		t.Errorf("Marshal() error = %q, want %q", err, expected)
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("prefix")
+	if err := root.MarshalTo(&buf); err == nil || buf.String() != "prefix" {
+		t.Errorf("MarshalTo() = %q, %v, want the buffer unchanged and an error", buf.String(), err)
+	}
+}
+
+func TestMarshal_SharedNodeIsNotCycle(t *testing.T) {
+	shared := ArrayNode("", []*Node{StringNode("", "x")})
+	root := ArrayNode("", []*Node{shared})
+	root.next["1"] = shared
+
+	value, err := Marshal(root)
+	if err != nil {
+		t.Fatalf("Marshal returns error: %v", err)
+	}
+
+	if expected := `[["x"],["x"]]`; string(value) != expected {
+		t.Errorf("Marshal() = %s, want %s", value, expected)
+	}
+}
+
+func TestMarshal_PreservesNumberLiterals(t *testing.T) {
+	root := Must(Unmarshal([]byte(`{"a":1.000,"b":2,"c":[1.50,{"d":1e2}]}`)))
+
+	steps := []struct {
+		edit     func() error
+		expected string
+	}{
+		{func() error { return root.MustKey("b").SetNumber(3) }, `{"a":1.000,"b":3,"c":[1.50,{"d":1e2}]}`},
+		{func() error { return root.MustKey("b").SetNumberString("3.50") }, `{"a":1.000,"b":3.50,"c":[1.50,{"d":1e2}]}`},
+		{func() error { return root.MustKey("c").MustIndex(1).MustKey("d").SetNumber(7) }, `{"a":1.000,"b":3.50,"c":[1.50,{"d":7}]}`},
+	}
+
+	for i, step := range steps {
+		if err := step.edit(); err != nil {
+			t.Fatalf("step %d returns error: %v", i, err)
+		}
+
+		value, err := Marshal(root)
+		if err != nil {
+			t.Fatalf("Marshal returns error: %v", err)
+		}
+
+		// the members are written in map order, so compare them one by one.
+		for _, key := range []string{"a", "b", "c"} {
+			got := Must(Unmarshal(value)).MustKey(key).String()
+			want := Must(Unmarshal([]byte(step.expected))).MustKey(key).String()
+			if got != want {
+				t.Errorf("step %d: %s = %s, want %s in %s", i, key, got, want, value)
+			}
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package json
+
+import (
+	"errors"
+	"fmt"
+	"io"
+)
+
+// Sentinel errors that can be matched with errors.Is.
+//
+// The typed errors below also match their category, e.g.
+// errors.Is(err, ErrSyntax) reports true for any *SyntaxError.
+var (
+	ErrNilNode         = errors.New("node is nil")
+	ErrIndexOutOfRange = errors.New("index out of range")
+	ErrMaxNesting      = errors.New("maximum nesting depth exceeded")
+	ErrSyntax          = errors.New("syntax error")
+	ErrTypeMismatch    = errors.New("type mismatch")
+	ErrKeyNotFound     = errors.New("key not found")
+	ErrInvalidUTF8     = errors.New("invalid UTF-8 encoding")
+	ErrImmutableNode   = errors.New("immutable node")
+
+	// ErrEmptyDocument is returned by Unmarshal when the input holds no value,
+	// only whitespace or a byte order mark. It wraps io.EOF.
+	ErrEmptyDocument error = emptyDocumentError{}
+)
+
+type emptyDocumentError struct{}
+
+func (emptyDocumentError) Error() string { return "unexpected end of JSON input: empty document" }
+func (emptyDocumentError) Unwrap() error { return io.EOF }
+
+// SyntaxError describes malformed JSON input found while decoding.
+//
+// Usage:
+//
+//	_, err := json.Unmarshal([]byte(`{"key": }`))
+//	if serr, ok := err.(*json.SyntaxError); ok {
+//		println(serr.Offset) // 8
+//	}
+type SyntaxError struct {
+	Offset int // Offset is the index of the byte where the error occurred.
+	msg    string
+}
+
+func newSyntaxError(offset int, msg string) *SyntaxError {
+	return &SyntaxError{Offset: offset, msg: msg}
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("%s at index %d", e.msg, e.Offset)
+}
+
+// Is reports whether the target is ErrSyntax.
+func (e *SyntaxError) Is(target error) bool {
+	return target == ErrSyntax
+}
+
+// TypeError describes a node whose type does not match the requested one,
+// e.g. calling GetString on a number node.
+//
+// Usage:
+//
+//	_, err := root.GetString()
+//	if terr, ok := err.(*json.TypeError); ok {
+//		println(terr.Expected, terr.Got) // string number
+//	}
+type TypeError struct {
+	Expected ValueType // Expected is the type required by the operation.
+	Got      ValueType // Got is the actual type of the node.
+}
+
+func (e *TypeError) Error() string {
+	return fmt.Sprintf("node is not %s. got: %s", e.Expected.String(), e.Got.String())
+}
+
+// Is reports whether the target is ErrTypeMismatch.
+func (e *TypeError) Is(target error) bool {
+	return target == ErrTypeMismatch
+}
+
+// KeyError describes a key that does not exist in an object node.
+//
+// Usage:
+//
+//	_, err := root.GetKey("missing")
+//	if kerr, ok := err.(*json.KeyError); ok {
+//		println(kerr.Key) // missing
+//	}
+type KeyError struct {
+	Key string // Key is the key that was not found.
+}
+
+func (e *KeyError) Error() string {
+	return fmt.Sprintf("key not found: %s", e.Key)
+}
+
+// Is reports whether the target is ErrKeyNotFound.
+func (e *KeyError) Is(target error) bool {
+	return target == ErrKeyNotFound
+}
@@ -0,0 +1,144 @@
+package json
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestSyntaxError(t *testing.T) {
+	tests := []struct {
+		name   string
+		input  string
+		opts   Options
+		offset int
+	}{
+		{"missing value", `{"key": }`, Options{}, 8},
+		{"unexpected comma", `[1,,2]`, Options{}, 3},
+		{"invalid literal", `[tru]`, Options{}, 4},
+		{"invalid string", "[\"a\x01\"]", Options{}, 3},
+		{"NaN not allowed", `[NaN]`, Options{}, 1},
+		{"unterminated comment", `[1 /* comment`, Options{AllowComments: true}, 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := UnmarshalWithOptions([]byte(tt.input), tt.opts)
+			if err == nil {
+				t.Fatalf("expected error for %s", tt.input)
+			}
+
+			serr, ok := err.(*SyntaxError)
+			if !ok {
+				t.Fatalf("expected *SyntaxError, got %T: %s", err, err)
+			}
+
+			if serr.Offset != tt.offset {
+				t.Errorf("wrong offset: %d, expected %d (%s)", serr.Offset, tt.offset, err)
+			}
+		})
+	}
+}
+
+func TestTypeError(t *testing.T) {
+	root := Must(Unmarshal([]byte(`{"num": 1, "str": "foo"}`)))
+	num := root.MustKey("num")
+	str := root.MustKey("str")
+
+	tests := []struct {
+		name     string
+		get      func() error
+		expected ValueType
+		got      ValueType
+	}{
+		{"GetString", func() error { _, err := num.GetString(); return err }, String, Number},
+		{"GetNumeric", func() error { _, err := str.GetNumeric(); return err }, Number, String},
+		{"GetBool", func() error { _, err := num.GetBool(); return err }, Boolean, Number},
+		{"GetNull", func() error { _, err := num.GetNull(); return err }, Null, Number},
+		{"GetArray", func() error { _, err := root.GetArray(); return err }, Array, Object},
+		{"GetObject", func() error { _, err := str.GetObject(); return err }, Object, String},
+		{"GetKey", func() error { _, err := str.GetKey("key"); return err }, Object, String},
+		{"SwapIndex", func() error { return root.SwapIndex(0, 1) }, Array, Object},
+		{"SetIndex", func() error { return num.SetIndex(0, NullNode("")) }, Array, Number},
+		{"MoveTo", func() error { return num.MoveTo(str, "key") }, Object, String},
+		{"MoveToIndex", func() error { return num.MoveToIndex(root, 0) }, Array, Object},
+		{"AppendArray", func() error { return root.AppendArray(NullNode("")) }, Array, Object},
+		{"AppendUnique", func() error { _, err := str.AppendUnique(NullNode("")); return err }, Array, String},
+		{"Prepend", func() error { return num.Prepend(NullNode("")) }, Array, Number},
+		{"Concat", func() error { return root.Concat(ArrayNode("", nil)) }, Array, Object},
+		{"Resize", func() error { return str.Resize(1, nil) }, Array, String},
+		{"AppendObject", func() error { return num.AppendObject("key", NullNode("")) }, Object, Number},
+		{"AppendObjectIfAbsent", func() error { _, err := str.AppendObjectIfAbsent("key", NullNode("")); return err }, Object, String},
+		{"FillDefaults", func() error { return num.FillDefaults(root) }, Object, Number},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.get()
+
+			terr, ok := err.(*TypeError)
+			if !ok {
+				t.Fatalf("expected *TypeError, got %T: %v", err, err)
+			}
+
+			if terr.Expected != tt.expected || terr.Got != tt.got {
+				t.Errorf("wrong types: expected=%s, got=%s", terr.Expected, terr.Got)
+			}
+		})
+	}
+}
+
+func TestKeyError(t *testing.T) {
+	root := Must(Unmarshal([]byte(`{"key": 1}`)))
+
+	_, err := root.GetKey("missing")
+
+	kerr, ok := err.(*KeyError)
+	if !ok {
+		t.Fatalf("expected *KeyError, got %T: %v", err, err)
+	}
+
+	if kerr.Key != "missing" {
+		t.Errorf("wrong key: %s", kerr.Key)
+	}
+
+	if err.Error() != "key not found: missing" {
+		t.Errorf("wrong message: %s", err)
+	}
+}
+
+func TestSentinelErrors(t *testing.T) {
+	var nilNode *Node
+	root := Must(Unmarshal([]byte(`{"arr": [1, 2]}`)))
+
+	_, syntaxErr := Unmarshal([]byte(`[1,]`))
+	_, typeErr := root.GetString()
+	_, keyErr := root.GetKey("missing")
+	_, nilErr := nilNode.GetNumeric()
+	_, indexErr := root.MustKey("arr").GetIndex(5)
+
+	tests := []struct {
+		name   string
+		err    error
+		target error
+	}{
+		{"syntax", syntaxErr, ErrSyntax},
+		{"type", typeErr, ErrTypeMismatch},
+		{"key", keyErr, ErrKeyNotFound},
+		{"nil node", nilErr, ErrNilNode},
+		{"index", indexErr, ErrIndexOutOfRange},
+		{"wrapped", fmt.Errorf("decode config: %w", syntaxErr), ErrSyntax},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if !errors.Is(tt.err, tt.target) {
+				t.Errorf("expected %v to match %v", tt.err, tt.target)
+			}
+		})
+	}
+
+	if errors.Is(typeErr, ErrSyntax) {
+		t.Errorf("type error must not match ErrSyntax")
+	}
+}
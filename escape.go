@@ -3,6 +3,7 @@ package json
 import (
 	"bytes"
 	"errors"
+	"fmt"
 	"unicode/utf8"
 )
 
@@ -15,6 +16,11 @@ const (
 	badHex                       = -1
 )
 
+var (
+	errInvalidEscape    = errors.New("invalid escape sequence")
+	errInvalidSurrogate = errors.New("invalid surrogate pair")
+)
+
 var hexLookupTable = [256]int{
 	'0': 0x0, '1': 0x1, '2': 0x2, '3': 0x3, '4': 0x4,
 	'5': 0x5, '6': 0x6, '7': 0x7, '8': 0x8, '9': 0x9,
@@ -63,7 +69,7 @@ func Unescape(input, output []byte) ([]byte, error) {
 	for len(input) > 0 {
 		inLen, bufLen, err := processEscapedUTF8(input, buf)
 		if err != nil {
-			return nil, err
+			return nil, fmt.Errorf("%w at offset %d", err, inputLen-len(input))
 		}
 
 		input = input[inLen:] // the number of bytes consumed in the input
@@ -133,13 +139,18 @@ func decodeUnicodeEscape(b []byte) (rune, int) {
 		return r, 6
 	}
 
+	// a low surrogate can't start a pair.
+	if r >= lowSurrogateOffset {
+		return utf8.RuneError, -1
+	}
+
 	// Decode the following escape sequence to verify a UTF-16 susergate pair.
 	r2, ok := decodeSingleUnicodeEscape(b[6:])
 	if !ok {
 		return utf8.RuneError, -1
 	}
 
-	if r2 < lowSurrogateOffset {
+	if r2 < lowSurrogateOffset || r2 > surrogateEnd {
 		return utf8.RuneError, -1
 	}
 
@@ -165,8 +176,19 @@ func Unquote(s []byte, border byte) (t string, ok bool) {
 }
 
 func unquoteBytes(s []byte, border byte) ([]byte, bool) {
+	b, err := unquote(s, border, false)
+	return b, err == nil
+}
+
+// unquote removes the surrounding quotes of s and unescapes the contents.
+//
+// If replace is true, invalid UTF-16 surrogates (a lone high or low surrogate, or a reversed pair)
+// are replaced with U+FFFD. Otherwise they are reported as an error.
+// Invalid UTF-8 bytes are always replaced with U+FFFD, matching what Quote writes for them.
+// The returned error is a *SyntaxError whose offset is relative to s.
+func unquote(s []byte, border byte, replace bool) ([]byte, error) {
 	if len(s) < 2 || s[0] != border || s[len(s)-1] != border {
-		return nil, false
+		return nil, newSyntaxError(0, "invalid quoted string")
 	}
 
 	s = s[1 : len(s)-1]
@@ -193,7 +215,7 @@ func unquoteBytes(s []byte, border byte) ([]byte, bool) {
 	}
 
 	if r == len(s) {
-		return s, true
+		return s, nil
 	}
 
 	utfDoubleMax := utf8.UTFMax * 2
@@ -211,21 +233,30 @@ func unquoteBytes(s []byte, border byte) ([]byte, bool) {
 		if c == backSlash {
 			r++
 			if r >= len(s) {
-				return nil, false
+				return nil, newSyntaxError(r, "invalid escape sequence")
 			}
 
 			if s[r] == 'u' {
 				rr, res := decodeUnicodeEscape(s[r-1:])
 				if res < 0 {
-					return nil, false
+					// the offset is relative to s including the opening quote.
+					if _, ok := decodeSingleUnicodeEscape(s[r-1:]); !ok {
+						return nil, newSyntaxError(r, "invalid unicode escape")
+					}
+
+					if !replace {
+						return nil, newSyntaxError(r, "invalid surrogate pair")
+					}
+
+					rr, res = utf8.RuneError, 6
 				}
 
 				w += utf8.EncodeRune(b[w:], rr)
-				r += 5
+				r += res - 1
 			} else {
 				decode := escapeByteSet[s[r]]
 				if decode == 0 {
-					return nil, false
+					return nil, newSyntaxError(r, "invalid escape sequence")
 				}
 
 				if decode == doubleQuote || decode == backSlash || decode == slash {
@@ -237,24 +268,81 @@ func unquoteBytes(s []byte, border byte) ([]byte, bool) {
 				w++
 			}
 		} else if c == border || c < 0x20 {
-			return nil, false
+			return nil, newSyntaxError(r+1, "invalid character in string")
 		} else if c < utf8.RuneSelf {
 			b[w] = c
 			r++
 			w++
 		} else {
 			rr, size := utf8.DecodeRune(s[r:])
+			r += size
+			w += utf8.EncodeRune(b[w:], rr)
+		}
+	}
+
+	return b[:w], nil
+}
 
-			if rr == utf8.RuneError && size == 1 {
-				return nil, false
+// Quote returns the JSON string literal of s surrounded by the given border character.
+// It is the inverse of Unquote.
+//
+// The border character, backslash and control characters are escaped. Invalid UTF-8
+// sequences are replaced with the Unicode replacement character (U+FFFD).
+//
+// Usage:
+//
+//	quoted := Quote("say \"hi\"\n", '"')
+//	println(string(quoted)) // "say \"hi\"\n"
+func Quote(s string, border byte) []byte {
+	b := make([]byte, 0, len(s)+2)
+	b = append(b, border)
+
+	for i := 0; i < len(s); {
+		c := s[i]
+		if c >= utf8.RuneSelf {
+			r, size := utf8.DecodeRuneInString(s[i:])
+			if r == utf8.RuneError && size == 1 {
+				b = append(b, `\ufffd`...)
+			} else {
+				b = append(b, s[i:i+size]...)
 			}
 
-			r += size
-			w += utf8.EncodeRune(b[w:], rr)
+			i += size
+			continue
 		}
+
+		switch {
+		case c == doubleQuote || c == backSlash:
+			b = append(b, backSlash, c)
+		case c == border:
+			// only the double quote has a short escape sequence in JSON
+			b = appendUnicodeEscape(b, c)
+		case c == newLine:
+			b = append(b, backSlash, 'n')
+		case c == carriageReturn:
+			b = append(b, backSlash, 'r')
+		case c == tab:
+			b = append(b, backSlash, 't')
+		case c == backSpace:
+			b = append(b, backSlash, 'b')
+		case c == formFeed:
+			b = append(b, backSlash, 'f')
+		case c < 0x20:
+			b = appendUnicodeEscape(b, c)
+		default:
+			b = append(b, c)
+		}
+
+		i++
 	}
 
-	return b[:w], true
+	return append(b, border)
+}
+
+// appendUnicodeEscape appends the \u00XX escape sequence of the given byte.
+func appendUnicodeEscape(b []byte, c byte) []byte {
+	const hex = "0123456789abcdef"
+	return append(b, backSlash, 'u', '0', '0', hex[c>>4], hex[c&0xF])
 }
 
 // processEscapedUTF8 processes the escape sequence in the given byte slice and
@@ -271,7 +359,7 @@ func unquoteBytes(s []byte, border byte) ([]byte, bool) {
 // function returns (-1, -1) to indicate an error.
 func processEscapedUTF8(in, out []byte) (inLen int, outLen int, err error) {
 	if len(in) < 2 || in[0] != backSlash {
-		return -1, -1, errors.New("invalid escape sequence")
+		return -1, -1, errInvalidEscape
 	}
 
 	escapeSeqLen := 2
@@ -281,6 +369,10 @@ func processEscapedUTF8(in, out []byte) (inLen int, outLen int, err error) {
 			outLen = utf8.EncodeRune(out, r)
 			return size, outLen, nil
 		}
+
+		if _, ok := decodeSingleUnicodeEscape(in); ok {
+			return -1, -1, errInvalidSurrogate
+		}
 	} else {
 		val := escapeByteSet[escapeChar]
 		if val != 0 {
@@ -289,5 +381,5 @@ func processEscapedUTF8(in, out []byte) (inLen int, outLen int, err error) {
 		}
 	}
 
-	return -1, -1, errors.New("invalid escape sequence")
+	return -1, -1, errInvalidEscape
 }
@@ -2,6 +2,7 @@ package json
 
 import (
 	"bytes"
+	"fmt"
 	"testing"
 	"unicode/utf8"
 )
@@ -206,6 +207,8 @@ func TestUnquoteBytes(t *testing.T) {
 		{[]byte("\"\\u0041\""), '"', []byte("A"), true},
 		{[]byte(`"Hello, 世界"`), '"', []byte("Hello, 世界"), true},
 		{[]byte(`"Hello, \x80"`), '"', nil, false},
+		{[]byte("\"Hello, \x80\xff\""), '"', []byte("Hello, \uFFFD\uFFFD"), true},
+		{[]byte(`"\ud83d\ude00!"`), '"', []byte("\U0001F600!"), true},
 	}
 
 	for _, tc := range tests {
@@ -220,3 +223,91 @@ func TestUnquoteBytes(t *testing.T) {
 		}
 	}
 }
+
+func TestQuote(t *testing.T) {
+	tests := []struct {
+		input    string
+		border   byte
+		expected string
+	}{
+		{"", '"', `""`},
+		{"hello", '"', `"hello"`},
+		{`say "hi"`, '"', `"say \"hi\""`},
+		{`back\slash`, '"', `"back\\slash"`},
+		{"line\nbreak\ttab\r\b\f", '"', `"line\nbreak\ttab\r\b\f"`},
+		{"\x01\x1f", '"', `"\u0001\u001f"`},
+		{"안녕, 世界!", '"', `"안녕, 世界!"`},
+		{"invalid \xff byte", '"', `"invalid \ufffd byte"`},
+		{"it's", '\'', `'it\u0027s'`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got := Quote(tt.input, tt.border)
+			if string(got) != tt.expected {
+				t.Errorf("Quote(%q) = %s, want %s", tt.input, got, tt.expected)
+			}
+
+			if !utf8.ValidString(tt.input) {
+				return
+			}
+
+			unquoted, ok := Unquote(got, tt.border)
+			if !ok || unquoted != tt.input {
+				t.Errorf("Unquote(Quote(%q)) = %q, %t", tt.input, unquoted, ok)
+			}
+		})
+	}
+}
+
+func TestUnquote_InvalidSurrogates(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		offset   int
+		replaced string
+	}{
+		{name: "lone high", input: `"a\ud83d"`, offset: 2, replaced: "a\uFFFD"},
+		{name: "high followed by non-surrogate", input: `"\ud83dA"`, offset: 1, replaced: "\uFFFDA"},
+		{name: "lone low", input: `"\ude00b"`, offset: 1, replaced: "\uFFFDb"},
+		{name: "reversed pair", input: `"\ude00\ud83d"`, offset: 1, replaced: "\uFFFD\uFFFD"},
+		{name: "two highs", input: `"\ud83d\ud83d\ude00"`, offset: 1, replaced: "\uFFFD\U0001F600"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := unquote([]byte(tt.input), doubleQuote, false)
+
+			serr, ok := err.(*SyntaxError)
+			if !ok {
+				t.Fatalf("expected *SyntaxError, got %v", err)
+			}
+
+			expected := fmt.Sprintf("invalid surrogate pair at index %d", tt.offset)
+			if serr.Error() != expected {
+				t.Errorf("wrong error: %s, expected %s", serr, expected)
+			}
+
+			got, err := unquote([]byte(tt.input), doubleQuote, true)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if string(got) != tt.replaced {
+				t.Errorf("unquote() = %q, want %q", got, tt.replaced)
+			}
+		})
+	}
+}
+
+func TestUnescape_InvalidSurrogate(t *testing.T) {
+	_, err := Unescape([]byte(`abc\ud800`), nil)
+	if err == nil || err.Error() != "invalid surrogate pair at offset 3" {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	_, err = Unescape([]byte(`\uZZZZ`), nil)
+	if err == nil || err.Error() != "invalid escape sequence at offset 0" {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
@@ -0,0 +1,105 @@
+package json
+
+import "io"
+
+// EventHandler receives the events emitted by Parse.
+//
+// Parse stops at the first error returned by a handler method and returns it.
+type EventHandler interface {
+	OnObjectStart() error
+	OnObjectEnd() error
+	OnArrayStart() error
+	OnArrayEnd() error
+
+	// OnKey is called with the unquoted key of an object member,
+	// before the events of the member value.
+	OnKey(key string) error
+
+	// OnString is called with the unquoted string value.
+	OnString(value string) error
+
+	// OnNumber is called with the raw number literal. The slice refers to
+	// the parsed data, so it must be copied if it's retained after the call.
+	OnNumber(literal []byte) error
+
+	OnBool(value bool) error
+	OnNull() error
+}
+
+// BaseEventHandler implements EventHandler with no-op methods.
+// It can be embedded to implement only the events of interest.
+type BaseEventHandler struct{}
+
+func (BaseEventHandler) OnObjectStart() error          { return nil }
+func (BaseEventHandler) OnObjectEnd() error            { return nil }
+func (BaseEventHandler) OnArrayStart() error           { return nil }
+func (BaseEventHandler) OnArrayEnd() error             { return nil }
+func (BaseEventHandler) OnKey(key string) error        { return nil }
+func (BaseEventHandler) OnString(value string) error   { return nil }
+func (BaseEventHandler) OnNumber(literal []byte) error { return nil }
+func (BaseEventHandler) OnBool(value bool) error       { return nil }
+func (BaseEventHandler) OnNull() error                 { return nil }
+
+// Parse parses the JSON-encoded data and reports its structure to the handler
+// as a sequence of events, without building a Node tree.
+//
+// It accepts the same grammar as Unmarshal: a leading UTF-8 byte order mark is ignored,
+// and ErrEmptyDocument is returned if the data holds no value. See Scanner for the errors.
+// For a malformed input, the events up to the error are reported before the error is returned.
+//
+// Unlike Unmarshal, which unquotes the string values lazily, Parse unquotes every string,
+// so an invalid surrogate escape in a string value fails Parse with a *SyntaxError.
+//
+// Usage:
+//
+//	type sum struct {
+//		json.BaseEventHandler
+//		total float64
+//	}
+//
+//	func (s *sum) OnNumber(literal []byte) error {
+//		f, err := json.ParseFloatLiteral(literal)
+//		s.total += f
+//		return err
+//	}
+//
+//	err := json.Parse(data, &sum{})
+func Parse(data []byte, handler EventHandler) error {
+	s := NewScanner(data)
+
+	for {
+		tok, err := s.Next()
+		if err == io.EOF {
+			return nil
+		}
+
+		if err != nil {
+			return err
+		}
+
+		switch tok.Type {
+		case TokenObjectStart:
+			err = handler.OnObjectStart()
+		case TokenObjectEnd:
+			err = handler.OnObjectEnd()
+		case TokenArrayStart:
+			err = handler.OnArrayStart()
+		case TokenArrayEnd:
+			err = handler.OnArrayEnd()
+		case TokenKey:
+			err = handler.OnKey(string(tok.Value))
+		case TokenString:
+			err = handler.OnString(string(tok.Value))
+		case TokenNumber:
+			err = handler.OnNumber(tok.Value)
+		case TokenBool:
+			err = handler.OnBool(tok.Value[0] == 't')
+		case TokenNull:
+			err = handler.OnNull()
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+}
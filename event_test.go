@@ -0,0 +1,182 @@
+package json
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// eventRecorder records the events as a space separated string.
+type eventRecorder struct {
+	events []string
+}
+
+func (r *eventRecorder) add(event string) error {
+	r.events = append(r.events, event)
+	return nil
+}
+
+func (r *eventRecorder) OnObjectStart() error          { return r.add("{") }
+func (r *eventRecorder) OnObjectEnd() error            { return r.add("}") }
+func (r *eventRecorder) OnArrayStart() error           { return r.add("[") }
+func (r *eventRecorder) OnArrayEnd() error             { return r.add("]") }
+func (r *eventRecorder) OnKey(key string) error        { return r.add("key:" + key) }
+func (r *eventRecorder) OnString(value string) error   { return r.add("str:" + value) }
+func (r *eventRecorder) OnNumber(literal []byte) error { return r.add("num:" + string(literal)) }
+func (r *eventRecorder) OnNull() error                 { return r.add("null") }
+
+func (r *eventRecorder) OnBool(value bool) error {
+	if value {
+		return r.add("true")
+	}
+
+	return r.add("false")
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{name: "string", input: `"foo"`, expected: `str:foo`},
+		{name: "number", input: ` -1.5e3 `, expected: `num:-1.5e3`},
+		{name: "literals", input: `[true, false, null]`, expected: `[ true false null ]`},
+		{name: "empty containers", input: `[{}, []]`, expected: `[ { } [ ] ]`},
+		{name: "object", input: `{"a": 1, "b": "x"}`, expected: `{ key:a num:1 key:b str:x }`},
+		{name: "escaped", input: `{"é": "a\"b"}`, expected: `{ key:é str:a"b }`},
+		{name: "nested", input: `{"a": [1, {"b": null}], "c": {"d": [true]}}`, expected: `{ key:a [ num:1 { key:b null } ] key:c { key:d [ true ] } }`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &eventRecorder{}
+			if err := Parse([]byte(tt.input), r); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if got := strings.Join(r.events, " "); got != tt.expected {
+				t.Errorf("events = %s, want %s", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestParse_Invalid(t *testing.T) {
+	tests := []string{
+		``,
+		`{`,
+		`[1, 2`,
+		`{"a"}`,
+		`{"a": 1,}`,
+		`[1,]`,
+		`{"a" 1}`,
+		`[1}`,
+		`{"a": 1]`,
+		`1 2`,
+		`tru`,
+		`nul`,
+		`"abc`,
+		`{1: 2}`,
+	}
+
+	for _, input := range tests {
+		t.Run(input, func(t *testing.T) {
+			if err := Parse([]byte(input), &eventRecorder{}); err == nil {
+				t.Errorf("expected error for %s", input)
+			}
+
+			if _, err := Unmarshal([]byte(input)); err == nil {
+				t.Errorf("Unmarshal must reject %s as well", input)
+			}
+		})
+	}
+}
+
+// numberSum sums all numbers of the document without building nodes.
+type numberSum struct {
+	BaseEventHandler
+	total float64
+	count int
+}
+
+func (s *numberSum) OnNumber(literal []byte) error {
+	f, err := ParseFloatLiteral(literal)
+	if err != nil {
+		return err
+	}
+
+	s.total += f
+	s.count++
+	return nil
+}
+
+func TestParse_Aggregate(t *testing.T) {
+	data := []byte(`{"orders": [{"id": "a", "price": 10.5}, {"id": "b", "price": 2}, {"id": "c", "price": 7.5, "tags": [1]}]}`)
+
+	s := &numberSum{}
+	if err := Parse(data, s); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if s.count != 4 || s.total != 21 {
+		t.Errorf("wrong aggregate: count=%d, total=%v", s.count, s.total)
+	}
+}
+
+// stopAtKey stops parsing when the given key is found.
+type stopAtKey struct {
+	BaseEventHandler
+	key string
+}
+
+var errStop = errors.New("stop")
+
+func (s *stopAtKey) OnKey(key string) error {
+	if key == s.key {
+		return errStop
+	}
+
+	return nil
+}
+
+func TestParse_LikeUnmarshal(t *testing.T) {
+	r := &eventRecorder{}
+	if err := Parse([]byte("\xef\xbb\xbf{\"a\": [1]}"), r); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got, expected := strings.Join(r.events, " "), `{ key:a [ num:1 ] }`; got != expected {
+		t.Errorf("events = %s, want %s", got, expected)
+	}
+
+	for _, input := range []string{``, " \n\t", "\xef\xbb\xbf", "\xef\xbb\xbf  "} {
+		if err := Parse([]byte(input), &eventRecorder{}); err != ErrEmptyDocument {
+			t.Errorf("Parse(%q) = %v, want ErrEmptyDocument", input, err)
+		}
+
+		if _, err := Unmarshal([]byte(input)); err != ErrEmptyDocument {
+			t.Errorf("Unmarshal(%q) = %v, want ErrEmptyDocument", input, err)
+		}
+	}
+
+	// the errors of the invalid keys are the ones of Unmarshal.
+	for _, input := range []string{`{"\ud800": 1}`, `[{"a": 1, "\udc00\ud800": 2}]`, "{\"a\x01\": 1}"} {
+		_, expected := Unmarshal([]byte(input))
+		if err := Parse([]byte(input), &eventRecorder{}); err == nil || expected == nil || err.Error() != expected.Error() {
+			t.Errorf("Parse(%q) = %v, want %v", input, err, expected)
+		}
+	}
+
+	err := Parse([]byte(`["a", "\ud800"]`), &eventRecorder{})
+	if serr, ok := err.(*SyntaxError); !ok || serr.Offset != 7 || !strings.Contains(err.Error(), "invalid surrogate pair") {
+		t.Errorf("expected invalid surrogate pair error at 7, got %v", err)
+	}
+}
+
+func TestParse_HandlerError(t *testing.T) {
+	err := Parse([]byte(`{"a": 1, "b": [invalid`), &stopAtKey{key: "b"})
+	if err != errStop {
+		t.Errorf("expected handler error, got %v", err)
+	}
+}
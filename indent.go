@@ -1,7 +1,9 @@
 package json
 
 import (
+	"bufio"
 	"bytes"
+	"io"
 	"strings"
 )
 
@@ -120,6 +122,15 @@ func Indent(data []byte, indent string) ([]byte, error) {
 				}
 			}
 
+		case doubleQuote:
+			// copy the string literal as is, brackets and commas inside it are not structural.
+			end := stringLiteralEnd(data, i)
+			if _, err := out.Write(data[i:end]); err != nil {
+				return nil, err
+			}
+
+			i = end - 1
+
 		default:
 			if err := out.WriteByte(c); err != nil {
 				return nil, err
@@ -130,6 +141,356 @@ func Indent(data []byte, indent string) ([]byte, error) {
 	return out.Bytes(), nil
 }
 
+// IndentWith returns the JSON data formatted with one indent string per nesting level,
+// like MarshalIndent of the standard library with an empty prefix. For example, pass "\t"
+// for tab-indented output.
+//
+// Unlike Indent, it validates the data first, and brackets, commas and colons inside
+// string literals are left as is. Empty objects and arrays are written as {} and [].
+// A leading byte order mark is dropped.
+//
+// Usage:
+//
+//	out, err := json.IndentWith([]byte(`{"a":[1,2],"b":"x,y"}`), "\t")
+//	if err != nil {
+//		return err
+//	}
+//
+//	result:
+//	{
+//		"a": [
+//			1,
+//			2
+//		],
+//		"b": "x,y"
+//	}
+func IndentWith(data []byte, indent string) ([]byte, error) {
+	return IndentWithOptions(data, IndentOptions{Indent: indent})
+}
+
+// IndentOptions holds the options of IndentWithOptions.
+type IndentOptions struct {
+	// Indent is the string written once per nesting level, e.g. "\t" or "  ".
+	Indent string
+
+	// InlineArrayElements keeps the arrays of at most this many scalar elements
+	// on a single line, e.g. [1, 2, 3]. Zero puts every element on its own line.
+	InlineArrayElements int
+
+	// InlineArrayWidth limits the single line arrays to this many bytes, brackets
+	// included. Zero means no limit.
+	InlineArrayWidth int
+}
+
+// IndentWithOptions is like IndentWith, but the short arrays of scalars can be kept
+// on a single line, as many pretty-printers do.
+//
+// Usage:
+//
+//	out, err := json.IndentWithOptions([]byte(`{"a":[1,2],"b":[{"c":3}]}`), json.IndentOptions{
+//		Indent:              "\t",
+//		InlineArrayElements: 8,
+//	})
+//	if err != nil {
+//		return err
+//	}
+//
+//	result:
+//	{
+//		"a": [1, 2],
+//		"b": [
+//			{
+//				"c": 3
+//			}
+//		]
+//	}
+func IndentWithOptions(data []byte, opts IndentOptions) ([]byte, error) {
+	if err := Parse(data, BaseEventHandler{}); err != nil {
+		return nil, err
+	}
+
+	// Parse accepts a leading byte order mark, which is not part of the value.
+	data = compact(bytes.TrimPrefix(data, byteOrderMark))
+
+	var (
+		out   bytes.Buffer
+		level int
+	)
+
+	out.Grow(len(data) * indentGrowthFactor)
+
+	newline := func() {
+		out.WriteByte(newLine)
+		for i := 0; i < level; i++ {
+			out.WriteString(opts.Indent)
+		}
+	}
+
+	for i := 0; i < len(data); i++ {
+		c := data[i]
+
+		switch c {
+		case doubleQuote:
+			end := stringLiteralEnd(data, i)
+			out.Write(data[i:end])
+			i = end - 1
+
+		case curlyOpen, bracketOpen:
+			if c == bracketOpen && opts.InlineArrayElements > 0 {
+				if end, ok := inlineArrayEnd(data, i, opts); ok {
+					writeInlineArray(&out, data[i:end])
+					i = end - 1
+					continue
+				}
+			}
+
+			out.WriteByte(c)
+
+			// keep the empty containers on a single line.
+			if i+1 < len(data) && (data[i+1] == curlyClose || data[i+1] == bracketClose) {
+				i++
+				out.WriteByte(data[i])
+				continue
+			}
+
+			level++
+			newline()
+
+		case curlyClose, bracketClose:
+			level--
+			newline()
+			out.WriteByte(c)
+
+		case comma:
+			out.WriteByte(c)
+			newline()
+
+		case colon:
+			out.WriteByte(c)
+			out.WriteByte(whiteSpace)
+
+		default:
+			out.WriteByte(c)
+		}
+	}
+
+	return out.Bytes(), nil
+}
+
+// IndentStream reads a JSON value from r and writes it to w indented like IndentWith,
+// with each new line starting with prefix. Unlike IndentWith, the input is processed
+// as it is read instead of being held in memory, which suits large files and HTTP bodies.
+//
+// Brackets, commas and colons inside string literals are left as is. The brackets
+// are checked to be balanced, but the input is not fully validated, so the output
+// of an invalid JSON is undefined. Whitespace separated top-level values, as in
+// newline delimited JSON, are written on separate lines. On error, part of the output
+// may already have been written.
+//
+// Usage:
+//
+//	resp, err := http.Get(url)
+//	if err != nil {
+//		return err
+//	}
+//	defer resp.Body.Close()
+//
+//	if err := json.IndentStream(os.Stdout, resp.Body, "", "  "); err != nil {
+//		return err
+//	}
+func IndentStream(w io.Writer, r io.Reader, prefix, indent string) error {
+	in := bufio.NewReader(r)
+	out := bufio.NewWriter(w)
+
+	var (
+		stack    []byte // stack holds the open brackets.
+		offset   = -1   // offset is the offset of the current byte in the input.
+		inString bool
+		escaped  bool
+		opened   bool // opened is true until the first element of an opened container.
+		space    bool // space is true if whitespace separates two top-level values.
+		written  bool
+	)
+
+	newline := func(level int) {
+		out.WriteByte(newLine)
+		out.WriteString(prefix)
+		for i := 0; i < level; i++ {
+			out.WriteString(indent)
+		}
+	}
+
+	for {
+		c, err := in.ReadByte()
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			return err
+		}
+
+		offset++
+
+		if inString {
+			out.WriteByte(c)
+
+			switch {
+			case escaped:
+				escaped = false
+			case c == backSlash:
+				escaped = true
+			case c == doubleQuote:
+				inString = false
+			}
+
+			continue
+		}
+
+		switch c {
+		case whiteSpace, tab, newLine, carriageReturn:
+			space = space || (written && len(stack) == 0)
+			continue
+		}
+
+		empty := false
+		if opened {
+			// keep the empty containers on a single line.
+			opened = false
+			empty = c == curlyClose || c == bracketClose
+
+			if !empty {
+				newline(len(stack))
+			}
+		} else if space && len(stack) == 0 {
+			newline(0)
+		}
+
+		space = false
+		written = true
+
+		switch c {
+		case doubleQuote:
+			inString = true
+			out.WriteByte(c)
+
+		case curlyOpen, bracketOpen:
+			stack = append(stack, c)
+			opened = true
+			out.WriteByte(c)
+
+		case curlyClose, bracketClose:
+			if len(stack) == 0 || stack[len(stack)-1] != matchingOpen(c) {
+				return newSyntaxError(offset, "mismatched bracket")
+			}
+
+			stack = stack[:len(stack)-1]
+			if !empty {
+				newline(len(stack))
+			}
+
+			out.WriteByte(c)
+
+		case comma:
+			out.WriteByte(c)
+			newline(len(stack))
+
+		case colon:
+			out.WriteByte(c)
+			out.WriteByte(whiteSpace)
+
+		default:
+			out.WriteByte(c)
+		}
+	}
+
+	if inString {
+		return newSyntaxError(offset+1, "unterminated string literal")
+	}
+
+	if len(stack) != 0 {
+		return newSyntaxError(offset+1, "unexpected end of input")
+	}
+
+	return out.Flush()
+}
+
+// inlineArrayEnd returns the index following the array starting at data[start] and
+// reports whether the array is short enough to be written on a single line.
+// The data must be compact and valid.
+func inlineArrayEnd(data []byte, start int, opts IndentOptions) (int, bool) {
+	elements := 0
+	for i := start + 1; i < len(data); i++ {
+		switch data[i] {
+		case doubleQuote:
+			i = stringLiteralEnd(data, i) - 1
+			elements = max(elements, 1)
+		case curlyOpen, bracketOpen:
+			return 0, false
+		case comma:
+			elements++
+		case bracketClose:
+			end := i + 1
+			if elements > opts.InlineArrayElements {
+				return 0, false
+			}
+
+			// each comma is followed by a space on the single line.
+			width := end - start + max(elements-1, 0)
+			if opts.InlineArrayWidth > 0 && width > opts.InlineArrayWidth {
+				return 0, false
+			}
+
+			return end, true
+		default:
+			elements = max(elements, 1)
+		}
+	}
+
+	return 0, false
+}
+
+// writeInlineArray writes the compact array of scalars with a space after each comma.
+func writeInlineArray(out *bytes.Buffer, array []byte) {
+	for i := 0; i < len(array); i++ {
+		switch array[i] {
+		case doubleQuote:
+			end := stringLiteralEnd(array, i)
+			out.Write(array[i:end])
+			i = end - 1
+		case comma:
+			out.WriteByte(comma)
+			out.WriteByte(whiteSpace)
+		default:
+			out.WriteByte(array[i])
+		}
+	}
+}
+
+// matchingOpen returns the opening bracket of the given closing bracket.
+func matchingOpen(c byte) byte {
+	if c == curlyClose {
+		return curlyOpen
+	}
+
+	return bracketOpen
+}
+
+// stringLiteralEnd returns the index following the closing quote of the string literal
+// starting at data[start], or len(data) if the literal is not terminated.
+func stringLiteralEnd(data []byte, start int) int {
+	for i := start + 1; i < len(data); i++ {
+		switch data[i] {
+		case backSlash:
+			i++
+		case doubleQuote:
+			return i + 1
+		}
+	}
+
+	return len(data)
+}
+
 func writeNewlineAndIndent(out *bytes.Buffer, level int, indent string) error {
 	if err := out.WriteByte('\n'); err != nil {
 		return err
@@ -142,3 +503,37 @@ func writeNewlineAndIndent(out *bytes.Buffer, level int, indent string) error {
 
 	return nil
 }
+
+// compact returns the JSON data without the insignificant whitespace
+// outside of string literals.
+func compact(data []byte) []byte {
+	out := make([]byte, 0, len(data))
+	inString := false
+
+	for i := 0; i < len(data); i++ {
+		c := data[i]
+
+		if inString {
+			out = append(out, c)
+			if c == backSlash && i+1 < len(data) {
+				i++
+				out = append(out, data[i])
+			} else if c == doubleQuote {
+				inString = false
+			}
+
+			continue
+		}
+
+		switch c {
+		case whiteSpace, tab, newLine, carriageReturn:
+			continue
+		case doubleQuote:
+			inString = true
+		}
+
+		out = append(out, c)
+	}
+
+	return out
+}
@@ -2,7 +2,11 @@ package json
 
 import (
 	"bytes"
+	"errors"
+	"io"
+	"strings"
 	"testing"
+	"testing/iotest"
 )
 
 func TestIndentJSON(t *testing.T) {
@@ -67,6 +71,18 @@ func TestIndentJSON(t *testing.T) {
 			indent:   "*",
 			expected: []byte("{\n**\"fruits\": [\"apple\",[\n****\"banana\",\n****\"cherry\"\n**],\"date\"]\n}"),
 		},
+		{
+			name:     "brackets inside strings",
+			input:    []byte(`{"a":"}{,:","b":"[\"]"}`),
+			indent:   "  ",
+			expected: []byte("{\n    \"a\": \"}{,:\",\"b\": \"[\\\"]\"\n}"),
+		},
+		{
+			name:     "top-level string",
+			input:    []byte(`"}"`),
+			indent:   "  ",
+			expected: []byte(`"}"`),
+		},
 	}
 
 	for _, tt := range tests {
@@ -82,3 +98,197 @@ func TestIndentJSON(t *testing.T) {
 		})
 	}
 }
+
+func TestIndentWith(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		indent   string
+		expected string
+	}{
+		{name: "scalar", input: ` "a" `, indent: "\t", expected: `"a"`},
+		{name: "empty containers", input: `{"a": {}, "b": [ ]}`, indent: "\t", expected: "{\n\t\"a\": {},\n\t\"b\": []\n}"},
+		{name: "tab", input: `{"a":[1,2],"b":"x"}`, indent: "\t", expected: "{\n\t\"a\": [\n\t\t1,\n\t\t2\n\t],\n\t\"b\": \"x\"\n}"},
+		{name: "spaces", input: `[[1],{"k":null}]`, indent: "  ", expected: "[\n  [\n    1\n  ],\n  {\n    \"k\": null\n  }\n]"},
+		{
+			name:     "special characters in strings",
+			input:    `{"a,b": "[x]: {y}", "c\"d": "\\"}`,
+			indent:   "\t",
+			expected: "{\n\t\"a,b\": \"[x]: {y}\",\n\t\"c\\\"d\": \"\\\\\"\n}",
+		},
+		{name: "whitespace in strings", input: `["a b",  "c\td"]`, indent: "\t", expected: "[\n\t\"a b\",\n\t\"c\\td\"\n]"},
+		{name: "byte order mark", input: "\xef\xbb\xbf {\"a\": [1]}", indent: "\t", expected: "{\n\t\"a\": [\n\t\t1\n\t]\n}"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			actual, err := IndentWith([]byte(tt.input), tt.indent)
+			if err != nil {
+				t.Fatalf("IndentWith() error = %v", err)
+			}
+
+			if string(actual) != tt.expected {
+				t.Errorf("IndentWith() = %q, want %q", actual, tt.expected)
+			}
+
+			if _, err := Unmarshal(actual); err != nil {
+				t.Errorf("output must be valid JSON: %v", err)
+			}
+		})
+	}
+}
+
+func TestIndentWith_Invalid(t *testing.T) {
+	for _, input := range []string{``, `{`, `["a]`, `{"a": 1,}`, `[1] [2]`} {
+		if _, err := IndentWith([]byte(input), "\t"); err == nil {
+			t.Errorf("expected error for %s", input)
+		}
+	}
+}
+
+func TestIndentWithOptions(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		opts     IndentOptions
+		expected string
+	}{
+		{
+			name:     "disabled",
+			input:    `[1,2]`,
+			opts:     IndentOptions{Indent: "\t"},
+			expected: "[\n\t1,\n\t2\n]",
+		},
+		{
+			name:     "scalar arrays",
+			input:    `{"a":[1,2,3],"b":["x, y",true,null],"c":[]}`,
+			opts:     IndentOptions{Indent: "\t", InlineArrayElements: 3},
+			expected: "{\n\t\"a\": [1, 2, 3],\n\t\"b\": [\"x, y\", true, null],\n\t\"c\": []\n}",
+		},
+		{
+			name:     "too many elements",
+			input:    `{"a":[1,2,3],"b":[1,2]}`,
+			opts:     IndentOptions{Indent: "  ", InlineArrayElements: 2},
+			expected: "{\n  \"a\": [\n    1,\n    2,\n    3\n  ],\n  \"b\": [1, 2]\n}",
+		},
+		{
+			name:     "too wide",
+			input:    `[["abc","def"],["a"]]`,
+			opts:     IndentOptions{Indent: "  ", InlineArrayElements: 8, InlineArrayWidth: 10},
+			expected: "[\n  [\n    \"abc\",\n    \"def\"\n  ],\n  [\"a\"]\n]",
+		},
+		{
+			name:     "exact width",
+			input:    `[1,2]`,
+			opts:     IndentOptions{Indent: "  ", InlineArrayElements: 8, InlineArrayWidth: 6},
+			expected: `[1, 2]`,
+		},
+		{
+			name:     "nested containers",
+			input:    `[[1],{"a":[2]},[[3]]]`,
+			opts:     IndentOptions{Indent: "  ", InlineArrayElements: 8},
+			expected: "[\n  [1],\n  {\n    \"a\": [2]\n  },\n  [\n    [3]\n  ]\n]",
+		},
+		{
+			name:     "brackets in strings",
+			input:    `["[1]","{"]`,
+			opts:     IndentOptions{Indent: "  ", InlineArrayElements: 8},
+			expected: `["[1]", "{"]`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			actual, err := IndentWithOptions([]byte(tt.input), tt.opts)
+			if err != nil {
+				t.Fatalf("IndentWithOptions() error = %v", err)
+			}
+
+			if string(actual) != tt.expected {
+				t.Errorf("IndentWithOptions() = %q, want %q", actual, tt.expected)
+			}
+
+			if _, err := Unmarshal(actual); err != nil {
+				t.Errorf("output must be valid JSON: %v", err)
+			}
+		})
+	}
+}
+
+func TestIndentStream(t *testing.T) {
+	tests := []string{
+		`{"a":[1,2],"b":"x,y"}`,
+		` { "a" : { } , "b" : [ ] , "c" : [ { } , [ ] ] } `,
+		`{"s": "[{\"q\": 1}, :]", "t": "\\", "u": "]"}`,
+		`[[[1]], {"a": {"b": null}}, true, false, -1.5e3]`,
+		`"top-level string"`,
+		`42`,
+		`[]`,
+	}
+
+	for _, input := range tests {
+		t.Run(input, func(t *testing.T) {
+			expected, err := IndentWith([]byte(input), "\t")
+			if err != nil {
+				t.Fatalf("IndentWith returns error: %v", err)
+			}
+
+			// read one byte at a time to cross the reads at every position.
+			var out bytes.Buffer
+			if err := IndentStream(&out, iotest.OneByteReader(strings.NewReader(input)), "", "\t"); err != nil {
+				t.Fatalf("IndentStream returns error: %v", err)
+			}
+
+			if out.String() != string(expected) {
+				t.Errorf("IndentStream = %q, want %q", out.String(), expected)
+			}
+		})
+	}
+}
+
+func TestIndentStream_Prefix(t *testing.T) {
+	var out bytes.Buffer
+	if err := IndentStream(&out, strings.NewReader(`{"a":[1]}`), "> ", "  "); err != nil {
+		t.Fatalf("IndentStream returns error: %v", err)
+	}
+
+	expected := "{\n>   \"a\": [\n>     1\n>   ]\n> }"
+	if out.String() != expected {
+		t.Errorf("IndentStream = %q, want %q", out.String(), expected)
+	}
+
+	out.Reset()
+	if err := IndentStream(&out, strings.NewReader("{\"a\":1}\n[2]\n\"x\" 3\n"), "", " "); err != nil {
+		t.Fatalf("IndentStream returns error: %v", err)
+	}
+
+	expected = "{\n \"a\": 1\n}\n[\n 2\n]\n\"x\"\n3"
+	if out.String() != expected {
+		t.Errorf("IndentStream = %q, want %q", out.String(), expected)
+	}
+}
+
+func TestIndentStream_Invalid(t *testing.T) {
+	tests := []string{
+		`{"a": [1}`,
+		`]`,
+		`{"a": "unterminated}`,
+		`[1, [2]`,
+	}
+
+	for _, input := range tests {
+		t.Run(input, func(t *testing.T) {
+			err := IndentStream(io.Discard, strings.NewReader(input), "", "\t")
+
+			var serr *SyntaxError
+			if !errors.As(err, &serr) {
+				t.Errorf("expected *SyntaxError, got %v", err)
+			}
+		})
+	}
+
+	readErr := errors.New("read error")
+	if err := IndentStream(io.Discard, iotest.ErrReader(readErr), "", "\t"); !errors.Is(err, readErr) {
+		t.Errorf("IndentStream = %v, want %v", err, readErr)
+	}
+}
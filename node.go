@@ -1,11 +1,16 @@
 package json
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
+	"io"
 	"math"
+	"math/big"
+	"sort"
 	"strconv"
 	"strings"
+	"unicode/utf8"
 )
 
 // Node represents a JSON node.
@@ -19,11 +24,24 @@ type Node struct {
 	index    *int             // index holds the index of the current node in the parent array node.
 	borders  [2]int           // borders stores the start and end index of the current node in the data.
 	modified bool             // modified indicates the current node is changed or not.
+	hash     uint64           // hash caches the fingerprint of the current node. see Hash.
+	hashed   bool             // hashed indicates the hash is computed and still valid.
+	frozen   bool             // frozen rejects the mutations of the current node. see Freeze.
+
+	// rewritten indicates the data is a rewritten copy of the decoded input. see SourceRange.
+	rewritten bool
 }
 
 // NewNode creates a new node instance with the given parent node, buffer, type, and key.
 func NewNode(prev *Node, b *buffer, typ ValueType, key **string) (*Node, error) {
-	curr := &Node{
+	return newNodeFrom(&Node{}, prev, b, typ, key)
+}
+
+// newNodeFrom initializes the given zero node like NewNode. An empty child map
+// already held by the node is reused for a container.
+func newNodeFrom(curr *Node, prev *Node, b *buffer, typ ValueType, key **string) (*Node, error) {
+	next := curr.next
+	*curr = Node{
 		prev:     prev,
 		data:     b.data,
 		borders:  [2]int{b.index, 0},
@@ -33,7 +51,11 @@ func NewNode(prev *Node, b *buffer, typ ValueType, key **string) (*Node, error)
 	}
 
 	if typ == Object || typ == Array {
-		curr.next = make(map[string]*Node)
+		if next == nil {
+			next = make(map[string]*Node)
+		}
+
+		curr.next = next
 	}
 
 	if prev != nil {
@@ -88,16 +110,16 @@ func (n *Node) HasKey(key string) bool {
 // GetKey returns the value of the given key from the current object node.
 func (n *Node) GetKey(key string) (*Node, error) {
 	if n == nil {
-		return nil, fmt.Errorf("node is nil")
+		return nil, ErrNilNode
 	}
 
 	if n.Type() != Object {
-		return nil, fmt.Errorf("target node is not object type. got: %s", n.Type().String())
+		return nil, &TypeError{Expected: Object, Got: n.Type()}
 	}
 
 	value, ok := n.next[key]
 	if !ok {
-		return nil, fmt.Errorf("key not found: %s", key)
+		return nil, &KeyError{Key: key}
 	}
 
 	return value, nil
@@ -113,6 +135,66 @@ func (n *Node) MustKey(key string) *Node {
 	return val
 }
 
+// Coalesce returns the value of the first given key that is present and not null
+// in the current object node. It returns nil if there's no such key or the node is not an object.
+//
+// Usage:
+//
+//	root := Must(Unmarshal([]byte(`{"nickname": null, "name": "foo"}`)))
+//	value := root.Coalesce("nickname", "name", "id")
+//
+//	result: "foo"
+func (n *Node) Coalesce(keys ...string) *Node {
+	if n == nil || !n.IsObject() {
+		return nil
+	}
+
+	for _, key := range keys {
+		if value, ok := n.next[key]; ok && !value.IsNull() {
+			return value
+		}
+	}
+
+	return nil
+}
+
+// Dig walks down from the current node following the given segments and returns the node found.
+// Each segment is either a string, an object key, or an int, an array index (negative counts from the end).
+//
+// The returned error tells which segment failed, and wraps the error of GetKey or GetIndex.
+//
+// Usage:
+//
+//	root := Must(Unmarshal([]byte(`{"store": {"book": [{"title": "foo"}]}}`)))
+//	title, err := root.Dig("store", "book", 0, "title")
+//
+//	result: "foo"
+func (n *Node) Dig(segments ...interface{}) (*Node, error) {
+	if n == nil {
+		return nil, ErrNilNode
+	}
+
+	node := n
+	for i, segment := range segments {
+		var err error
+
+		switch seg := segment.(type) {
+		case string:
+			node, err = node.GetKey(seg)
+		case int:
+			node, err = node.GetIndex(seg)
+		default:
+			return nil, fmt.Errorf("segment %d: unsupported segment type %T, must be string or int", i, segment)
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("segment %d (%v): %w", i, segment, err)
+		}
+	}
+
+	return node, nil
+}
+
 // UniqueKeys traverses the current JSON nodes and collects all the unique keys.
 func (n *Node) UniqueKeys() []string {
 	var collectKeys func(*Node) []string
@@ -143,7 +225,10 @@ func (n *Node) UniqueKeys() []string {
 // TODO: implement the EachKey method. this takes a callback function and executes it for each key in the object node.
 // func (n *Node) EachKey(callback func(key string, value *Node)) { ... }
 
-// Empty returns true if the current node is empty.
+// Empty returns true if the current node has no children.
+//
+// Note that this is also true for all scalar nodes, since they never have children,
+// and false for a nil node. Use IsEmptyContainer to check for an empty object or array.
 func (n *Node) Empty() bool {
 	if n == nil {
 		return false
@@ -152,6 +237,17 @@ func (n *Node) Empty() bool {
 	return len(n.next) == 0
 }
 
+// IsEmptyContainer returns true only if the current node is an object or array with no children.
+//
+// Usage:
+//
+//	Must(Unmarshal([]byte(`[]`))).IsEmptyContainer()  // true
+//	Must(Unmarshal([]byte(`[1]`))).IsEmptyContainer() // false
+//	Must(Unmarshal([]byte(`""`))).IsEmptyContainer()  // false
+func (n *Node) IsEmptyContainer() bool {
+	return n != nil && n.isContainer() && len(n.next) == 0
+}
+
 // Type returns the type (ValueType) of the current node.
 func (n *Node) Type() ValueType {
 	return n.nodeType
@@ -176,7 +272,12 @@ func (n *Node) Value() (value interface{}, err error) {
 		case Null:
 			return nil, nil
 
-		case Number:
+		case Number, Float:
+			if f, ok := parseNonFiniteLiteral(n.source()); ok {
+				n.value = f
+				return f, nil
+			}
+
 			value, err = ParseFloatLiteral(n.source())
 			if err != nil {
 				return nil, err
@@ -185,12 +286,12 @@ func (n *Node) Value() (value interface{}, err error) {
 			n.value = value
 
 		case String:
-			var ok bool
-			value, ok = Unquote(n.source(), doubleQuote)
-			if !ok {
-				return "", errors.New("invalid string value")
+			var str []byte
+			if str, err = unquote(n.source(), doubleQuote, false); err != nil {
+				return "", fmt.Errorf("invalid string value: %w", err)
 			}
 
+			value = string(str)
 			n.value = value
 
 		case Boolean:
@@ -198,8 +299,11 @@ func (n *Node) Value() (value interface{}, err error) {
 				return nil, errors.New("empty boolean value")
 			}
 
-			b := n.source()[0]
-			value = b == 't' || b == 'T'
+			value, err = ParseBoolLiteral(n.source())
+			if err != nil {
+				return nil, err
+			}
+
 			n.value = value
 
 		case Array:
@@ -240,6 +344,10 @@ func (n *Node) Value() (value interface{}, err error) {
 //
 //	result: "foo" (string)
 func (n *Node) Set(val interface{}) error {
+	if n == nil {
+		return ErrNilNode
+	}
+
 	if val == nil {
 		return n.SetNull()
 	}
@@ -274,6 +382,38 @@ func (n *Node) Set(val interface{}) error {
 	}
 }
 
+// Replace replaces the value of the current node in place, so that the pointers held
+// to the node stay valid. The value is any value accepted by Set, or a []byte holding
+// a JSON fragment which is parsed and copied into the node.
+//
+// Usage:
+//
+//	root := Must(Unmarshal([]byte(`{"a": 1}`)))
+//	node := root.MustKey("a")
+//	if err := node.Replace([]byte(`{"b": [true]}`)); err != nil {
+//		t.Errorf("Replace returns error: %v", err)
+//	}
+//
+//	result: node is {"b": [true]}, and so is root.MustKey("a")
+func (n *Node) Replace(value interface{}) error {
+	if n == nil {
+		return ErrNilNode
+	}
+
+	raw, ok := value.([]byte)
+	if !ok {
+		return n.Set(value)
+	}
+
+	// the fragment is copied, as the node keeps referring to its data.
+	node, err := UnmarshalSafe(raw)
+	if err != nil {
+		return fmt.Errorf("invalid JSON fragment: %w", err)
+	}
+
+	return n.SetNode(node)
+}
+
 // SetNull sets the current node to null type.
 //
 // If the current node is not null type, it will be updated to null type.
@@ -288,6 +428,55 @@ func (n *Node) SetNumber(val float64) error {
 	return n.update(Number, val)
 }
 
+// SetNumberString sets the current node to number type with the given number literal.
+//
+// Unlike SetNumber, the literal is kept as is and marshaled verbatim, so trailing zeros
+// (e.g. "123.40") and integers beyond the float64 precision are preserved.
+// The literal must be a valid JSON number within the float64 range.
+//
+// Usage:
+//
+//	root := Must(Unmarshal([]byte(`{"price": 0}`)))
+//	if err := root.MustKey("price").SetNumberString("123.40"); err != nil {
+//		t.Errorf("SetNumberString returns error: %v", err)
+//	}
+//
+//	result: {"price":123.40}
+func (n *Node) SetNumberString(literal string) error {
+	if n == nil {
+		return ErrNilNode
+	}
+
+	data := []byte(literal)
+
+	value, err := parseNumberLiteral(data)
+	if err != nil {
+		return err
+	}
+
+	if err := n.update(Number, value); err != nil {
+		return err
+	}
+
+	// keep the literal as the source, like IntNode.
+	n.data = data
+	n.borders = [2]int{0, len(data)}
+	n.modified = false
+
+	return nil
+}
+
+// parseNumberLiteral parses the data as a JSON number. Unlike ParseFloatLiteral, the data
+// must match the number grammar as a whole, so "-", "1.", ".5" or "01" are rejected.
+func parseNumberLiteral(data []byte) (float64, error) {
+	buf := newBuffer(data)
+	if err := buf.numeric(true); err != nil || buf.index != len(data) {
+		return 0, fmt.Errorf("invalid number literal: %q", data)
+	}
+
+	return ParseFloatLiteral(data)
+}
+
 // SetString sets the current node to string type.
 //
 // If the current node is not string type, it will be updated to string type.
@@ -320,8 +509,12 @@ func (n *Node) SetObject(val map[string]*Node) error {
 //
 // If the current node is not the same type as the given node, it will be updated to the given node type.
 func (n *Node) SetNode(val *Node) error {
-	if n == nil {
-		return errors.New("node is nil")
+	if n == nil || val == nil {
+		return ErrNilNode
+	}
+
+	if err := n.mutable(); err != nil {
+		return err
 	}
 
 	if n.isSameOrParentNode(val) {
@@ -333,6 +526,10 @@ func (n *Node) SetNode(val *Node) error {
 	n.setRef(nil, nil, nil)
 
 	*n = *node
+	for _, child := range n.next {
+		child.prev = n
+	}
+
 	if n.prev != nil {
 		n.prev.mark()
 	}
@@ -340,6 +537,101 @@ func (n *Node) SetNode(val *Node) error {
 	return nil
 }
 
+// ReplaceAll walks the subtree of the current node, including itself, and replaces
+// each node matching pred with the node returned by replacement, using SetNode.
+// It returns the number of replaced nodes.
+//
+// The replacement receives the matched node and must return a new node (or a node
+// that is not the matched node or its descendant), which is copied into place.
+// The children of a replaced node, and of its replacement, are not visited.
+//
+// Usage:
+//
+//	root := Must(Unmarshal([]byte(`{"a": 1.4, "b": [2.6, "x"]}`)))
+//	count, err := root.ReplaceAll(
+//		func(node *Node) bool { return node.IsNumber() },
+//		func(node *Node) *Node { return NumberNode("", math.Round(node.MustNumeric())) },
+//	)
+//
+//	result: count = 2, root = {"a":1,"b":[3,"x"]}
+func (n *Node) ReplaceAll(pred func(*Node) bool, replacement func(*Node) *Node) (int, error) {
+	if n == nil {
+		return 0, ErrNilNode
+	}
+
+	if pred(n) {
+		val := replacement(n)
+		if val == nil {
+			return 0, ErrNilNode
+		}
+
+		if err := n.SetNode(val); err != nil {
+			return 0, err
+		}
+
+		return 1, nil
+	}
+
+	if !n.isContainer() {
+		return 0, nil
+	}
+
+	count := 0
+	for _, child := range n.children() {
+		replaced, err := child.ReplaceAll(pred, replacement)
+		count += replaced
+		if err != nil {
+			return count, err
+		}
+	}
+
+	return count, nil
+}
+
+// Prune walks the subtree of the current node and removes each object member and
+// array element matching pred, along with its subtree. It returns the number of
+// removed nodes, not counting their descendants.
+//
+// The children of a removed node are not visited, and the current node itself is
+// never removed. The following elements of an array are shifted to fill the gaps.
+//
+// Usage:
+//
+//	root := Must(Unmarshal([]byte(`{"a": null, "b": [1, null, {"c": null}]}`)))
+//	count, err := root.Prune(func(node *Node) bool { return node.IsNull() })
+//
+//	result: count = 3, root = {"b":[1,{}]}
+func (n *Node) Prune(pred func(*Node) bool) (int, error) {
+	if n == nil {
+		return 0, ErrNilNode
+	}
+
+	if !n.isContainer() {
+		return 0, nil
+	}
+
+	count := 0
+	for _, child := range n.children() {
+		if !pred(child) {
+			pruned, err := child.Prune(pred)
+			count += pruned
+			if err != nil {
+				return count, err
+			}
+
+			continue
+		}
+
+		if err := n.remove(child); err != nil {
+			return count, err
+		}
+
+		count++
+	}
+
+	return count, nil
+}
+
 // Delete removes the current node from the parent node.
 //
 // Usage:
@@ -355,6 +647,10 @@ func (n *Node) Delete() error {
 		return errors.New("can't delete nil node")
 	}
 
+	if err := n.mutable(); err != nil {
+		return err
+	}
+
 	if n.prev == nil {
 		return nil
 	}
@@ -362,6 +658,95 @@ func (n *Node) Delete() error {
 	return n.prev.remove(n)
 }
 
+// EmptyMask selects the kinds of values that RemoveEmptyWith treats as empty.
+type EmptyMask uint8
+
+const (
+	EmptyNull   EmptyMask = 1 << iota // null
+	EmptyString                       // ""
+	EmptyArray                        // []
+	EmptyObject                       // {}
+
+	EmptyAll = EmptyNull | EmptyString | EmptyArray | EmptyObject
+)
+
+// RemoveEmpty recursively removes the null, empty string and empty container values
+// from the current node. It is equivalent to RemoveEmptyWith(EmptyAll).
+//
+// Usage:
+//
+//	root := Must(Unmarshal([]byte(`{"a": null, "b": ["", {}], "c": 1}`)))
+//	root.RemoveEmpty()
+//
+//	result: {"c":1}
+func (n *Node) RemoveEmpty() int {
+	return n.RemoveEmptyWith(EmptyAll)
+}
+
+// RemoveEmptyWith recursively removes the object members and array elements whose values
+// are empty according to the mask, and returns the number of removed values.
+//
+// The children are cleaned before their parent, so a container left empty by the removal
+// is removed as well. The current node itself is never removed.
+//
+// Usage:
+//
+//	root := Must(Unmarshal([]byte(`{"a": null, "b": "", "c": [null]}`)))
+//	root.RemoveEmptyWith(EmptyNull)
+//
+//	result: {"b":"","c":[]}
+func (n *Node) RemoveEmptyWith(mask EmptyMask) int {
+	if n == nil || !n.isContainer() {
+		return 0
+	}
+
+	removed := 0
+	if n.IsArray() {
+		// remove from the back so the index of the remaining values stays valid.
+		for i := len(n.next) - 1; i >= 0; i-- {
+			removed += n.removeIfEmpty(n.next[strconv.Itoa(i)], mask)
+		}
+
+		return removed
+	}
+
+	for _, key := range n.sortedKeys() {
+		removed += n.removeIfEmpty(n.next[key], mask)
+	}
+
+	return removed
+}
+
+// removeIfEmpty cleans the child node and removes it from the current node if it's empty.
+func (n *Node) removeIfEmpty(child *Node, mask EmptyMask) int {
+	removed := child.RemoveEmptyWith(mask)
+	if !child.isEmptyValue(mask) {
+		return removed
+	}
+
+	if err := n.remove(child); err != nil {
+		return removed
+	}
+
+	return removed + 1
+}
+
+func (n *Node) isEmptyValue(mask EmptyMask) bool {
+	switch n.Type() {
+	case Null:
+		return mask&EmptyNull != 0
+	case String:
+		str, err := n.GetString()
+		return err == nil && str == "" && mask&EmptyString != 0
+	case Array:
+		return len(n.next) == 0 && mask&EmptyArray != 0
+	case Object:
+		return len(n.next) == 0 && mask&EmptyObject != 0
+	default:
+		return false
+	}
+}
+
 // Size returns the number of sub-nodes of the current Array node.
 //
 // Usage:
@@ -382,6 +767,33 @@ func (n *Node) Size() int {
 	return len(n.next)
 }
 
+// ArrayLength returns the number of elements of the current Array node, without
+// materializing them as a slice.
+//
+// Unlike Size, it returns a TypeError if the node is not an array, so an object or a
+// scalar is never mistaken for an empty or a non-empty array.
+//
+// Usage:
+//
+//	root := Must(Unmarshal([]byte(`{"items": [1, 2, 3]}`)))
+//	length, err := root.MustKey("items").ArrayLength()
+//	if err != nil {
+//		return err
+//	}
+//
+//	result: 3
+func (n *Node) ArrayLength() (int, error) {
+	if n == nil {
+		return 0, ErrNilNode
+	}
+
+	if !n.IsArray() {
+		return 0, &TypeError{Expected: Array, Got: n.Type()}
+	}
+
+	return len(n.next), nil
+}
+
 // Index returns the index of the current node in the parent array node.
 //
 // Usage:
@@ -435,15 +847,15 @@ func (n *Node) MustIndex(expectIdx int) *Node {
 // if the index is negative, it returns the index is from the end of the array.
 func (n *Node) GetIndex(idx int) (*Node, error) {
 	if n == nil {
-		return nil, errors.New("node is nil")
+		return nil, ErrNilNode
 	}
 
 	if n.nodeType != Array {
-		return nil, errors.New("node is not array")
+		return nil, &TypeError{Expected: Array, Got: n.nodeType}
 	}
 
 	if idx > n.Size() {
-		return nil, errors.New("input index exceeds the array size")
+		return nil, ErrIndexOutOfRange
 	}
 
 	if idx < 0 {
@@ -452,7 +864,7 @@ func (n *Node) GetIndex(idx int) (*Node, error) {
 
 	child, ok := n.next[strconv.Itoa(idx)]
 	if !ok {
-		return nil, errors.New("index not found")
+		return nil, ErrIndexOutOfRange
 	}
 
 	return child, nil
@@ -468,55 +880,320 @@ func (n *Node) DeleteIndex(idx int) error {
 	return n.remove(node)
 }
 
-// NullNode creates a new null type node.
-//
-// Usage:
+// PopIndex removes the array element at the given index and returns it.
+// The following elements are shifted to fill the gap.
 //
-//	_ := NullNode("")
-func NullNode(key string) *Node {
-	return &Node{
-		key:      &key,
-		value:    nil,
-		nodeType: Null,
-		modified: true,
-	}
-}
-
-// NumberNode creates a new number type node.
+// Like GetIndex, a negative index counts from the end of the array.
 //
 // Usage:
 //
-//	root := NumberNode("", 1)
-//	if root == nil {
-//		t.Errorf("NumberNode returns nil")
+//	root := Must(Unmarshal([]byte(`[1, 2, 3]`)))
+//	last, err := root.PopIndex(-1)
+//	if err != nil {
+//		t.Errorf("PopIndex returns error: %v", err)
 //	}
-func NumberNode(key string, value float64) *Node {
-	return &Node{
-		key:      &key,
-		value:    value,
-		nodeType: Number,
-		modified: true,
+//
+//	result: last == 3, [1, 2]
+func (n *Node) PopIndex(idx int) (*Node, error) {
+	node, err := n.GetIndex(idx)
+	if err != nil {
+		return nil, err
 	}
+
+	if err := n.remove(node); err != nil {
+		return nil, err
+	}
+
+	node.setRef(nil, nil, nil)
+	return node, nil
 }
 
-// StringNode creates a new string type node.
+// SwapIndex swaps the array elements at the given indices.
+//
+// Negative indices are not allowed. It returns an error if the current node is not array type
+// or if any of the indices is out of range.
 //
 // Usage:
 //
-//	root := StringNode("", "foo")
-//	if root == nil {
-//		t.Errorf("StringNode returns nil")
+//	root := Must(Unmarshal([]byte(`[1, 2, 3]`)))
+//	if err := root.SwapIndex(0, 2); err != nil {
+//		t.Errorf("SwapIndex returns error: %v", err)
 //	}
-func StringNode(key string, value string) *Node {
-	return &Node{
-		key:      &key,
-		value:    value,
-		nodeType: String,
-		modified: true,
+//
+//	result: [3, 2, 1]
+func (n *Node) SwapIndex(i, j int) error {
+	if n == nil {
+		return ErrNilNode
 	}
-}
 
-// BoolNode creates a new given boolean value node.
+	if !n.IsArray() {
+		return &TypeError{Expected: Array, Got: n.nodeType}
+	}
+
+	if err := n.mutable(); err != nil {
+		return err
+	}
+
+	size := n.Size()
+	if i < 0 || i >= size || j < 0 || j >= size {
+		return ErrIndexOutOfRange
+	}
+
+	if i == j {
+		return nil
+	}
+
+	a, b := n.next[strconv.Itoa(i)], n.next[strconv.Itoa(j)]
+	a.index, b.index = b.index, a.index
+	n.next[strconv.Itoa(i)], n.next[strconv.Itoa(j)] = b, a
+
+	n.mark()
+	return nil
+}
+
+// SetIndex sets the array element at the given index to the given value.
+//
+// If the index is beyond the end of the array, the array grows to fit it and the gap is
+// filled with null elements, like an assignment to a JavaScript array.
+// A negative index counts from the end of the array, and it is an error if it points
+// before the first element.
+//
+// If the value already belongs to another node, its clone is set
+// so that the original node is left in place.
+//
+// Usage:
+//
+//	root := Must(Unmarshal([]byte(`[1, 2]`)))
+//	if err := root.SetIndex(4, StringNode("", "x")); err != nil {
+//		t.Errorf("SetIndex returns error: %v", err)
+//	}
+//
+//	result: [1, 2, null, null, "x"]
+func (n *Node) SetIndex(idx int, value *Node) error {
+	if n == nil || value == nil {
+		return ErrNilNode
+	}
+
+	if !n.IsArray() {
+		return &TypeError{Expected: Array, Got: n.nodeType}
+	}
+
+	if err := n.mutable(); err != nil {
+		return err
+	}
+
+	size := n.Size()
+	if idx < 0 {
+		idx += size
+		if idx < 0 {
+			return ErrIndexOutOfRange
+		}
+	}
+
+	if value.isSameOrParentNode(n) {
+		return errors.New("can't set same or parent node")
+	}
+
+	if value.prev != nil {
+		value = value.Clone()
+	}
+
+	for i := size; i < idx; i++ {
+		if err := n.append(nil, NullNode("")); err != nil {
+			return err
+		}
+	}
+
+	if idx < size {
+		key := strconv.Itoa(idx)
+		n.next[key].setRef(nil, nil, nil)
+
+		value.setRef(n, nil, &idx)
+		n.next[key] = value
+	} else if err := n.append(nil, value); err != nil {
+		return err
+	}
+
+	n.mark()
+	return nil
+}
+
+// MoveTo detaches the current node from its parent and attaches it to the given object node
+// under the given key. If the key already exists in the new parent, its value will be replaced.
+//
+// It returns an error if the new parent is not an object or if it is the current node itself
+// or one of its descendants.
+//
+// Usage:
+//
+//	root := Must(Unmarshal([]byte(`{"a": {"b": 1}, "c": {}}`)))
+//	if err := root.MustKey("a").MustKey("b").MoveTo(root.MustKey("c"), "d"); err != nil {
+//		t.Errorf("MoveTo returns error: %v", err)
+//	}
+//
+//	result: {"a": {}, "c": {"d": 1}}
+func (n *Node) MoveTo(newParent *Node, key string) error {
+	if n == nil || newParent == nil {
+		return ErrNilNode
+	}
+
+	if !newParent.IsObject() {
+		return &TypeError{Expected: Object, Got: newParent.nodeType}
+	}
+
+	if n.frozen || newParent.frozen {
+		return ErrImmutableNode
+	}
+
+	if n.isSameOrParentNode(newParent) {
+		return errors.New("can't move node to itself or its descendant")
+	}
+
+	if err := n.detach(); err != nil {
+		return err
+	}
+
+	if err := newParent.append(&key, n); err != nil {
+		return err
+	}
+
+	newParent.mark()
+	return nil
+}
+
+// MoveToIndex detaches the current node from its parent and inserts it into the given array node
+// at the given index. The elements at and after the index are shifted to the right.
+//
+// The index must be in range [0, size] of the new parent, where size means appending to the end.
+func (n *Node) MoveToIndex(newParent *Node, idx int) error {
+	if n == nil || newParent == nil {
+		return ErrNilNode
+	}
+
+	if !newParent.IsArray() {
+		return &TypeError{Expected: Array, Got: newParent.nodeType}
+	}
+
+	if n.frozen || newParent.frozen {
+		return ErrImmutableNode
+	}
+
+	if n.isSameOrParentNode(newParent) {
+		return errors.New("can't move node to itself or its descendant")
+	}
+
+	size := newParent.Size()
+	if n.prev == newParent {
+		size--
+	}
+
+	if idx < 0 || idx > size {
+		return ErrIndexOutOfRange
+	}
+
+	if err := n.detach(); err != nil {
+		return err
+	}
+
+	newParent.insert(idx, n)
+	newParent.mark()
+
+	return nil
+}
+
+// NullNode creates a new null type node.
+//
+// Usage:
+//
+//	_ := NullNode("")
+func NullNode(key string) *Node {
+	return &Node{
+		key:      &key,
+		value:    nil,
+		nodeType: Null,
+		modified: true,
+	}
+}
+
+// NumberNode creates a new number type node.
+//
+// Usage:
+//
+//	root := NumberNode("", 1)
+//	if root == nil {
+//		t.Errorf("NumberNode returns nil")
+//	}
+func NumberNode(key string, value float64) *Node {
+	return &Node{
+		key:      &key,
+		value:    value,
+		nodeType: Number,
+		modified: true,
+	}
+}
+
+// FloatNode creates a new number node classified as a float.
+//
+// It is a number in every respect (IsNumber, GetNumeric, Compare and EqualsExcept treat it
+// like NumberNode), except that it's marshaled with a decimal point even if it holds
+// an integral value, so 1 is written as 1.0 instead of 1.
+//
+// Usage:
+//
+//	root := FloatNode("", 1)
+//	value, _ := Marshal(root)
+//
+//	result: 1.0
+func FloatNode(key string, value float64) *Node {
+	return &Node{
+		key:      &key,
+		value:    value,
+		nodeType: Float,
+		modified: true,
+	}
+}
+
+// IntNode creates a new number type node from the given integer.
+//
+// Unlike NumberNode, the node keeps the integer literal as its source,
+// so it is always marshaled as an integer (e.g. 1000000000000000000
+// instead of 1e+18) without losing the precision beyond float64.
+//
+// Usage:
+//
+//	root := IntNode("", 9007199254740993)
+//	val, _ := Marshal(root)
+//	println(string(val)) // 9007199254740993
+func IntNode(key string, value int64) *Node {
+	literal := []byte(strconv.FormatInt(value, 10))
+
+	return &Node{
+		key:      &key,
+		data:     literal,
+		value:    float64(value),
+		nodeType: Number,
+		borders:  [2]int{0, len(literal)},
+	}
+}
+
+// StringNode creates a new string type node.
+//
+// Usage:
+//
+//	root := StringNode("", "foo")
+//	if root == nil {
+//		t.Errorf("StringNode returns nil")
+//	}
+func StringNode(key string, value string) *Node {
+	return &Node{
+		key:      &key,
+		value:    value,
+		nodeType: String,
+		modified: true,
+	}
+}
+
+// BoolNode creates a new given boolean value node.
 //
 // Usage:
 //
@@ -619,15 +1296,66 @@ func (n *Node) IsString() bool {
 	return n.nodeType == String
 }
 
-// IsNumber returns true if the current node is number type.
+// IsNumber returns true if the current node is number type, including the numbers
+// created by FloatNode.
 func (n *Node) IsNumber() bool {
-	return n.nodeType == Number
+	return isNumericType(n.nodeType)
+}
+
+// IsContainer returns true if the current node is object or array type.
+func (n *Node) IsContainer() bool {
+	return n.isContainer()
+}
+
+// IsScalar returns true if the current node is string, number, boolean or null type.
+func (n *Node) IsScalar() bool {
+	switch n.nodeType {
+	case String, Number, Float, Boolean, Null:
+		return true
+	default:
+		return false
+	}
+}
+
+// IsLeaf returns true if the current node has no children,
+// that is, it is a scalar or an empty object or array.
+func (n *Node) IsLeaf() bool {
+	return n.IsScalar() || (n.isContainer() && len(n.next) == 0)
 }
 
 func (n *Node) ready() bool {
 	return n.borders[1] != 0
 }
 
+// SourceRange returns the byte offsets of the current node within the data it was parsed from,
+// so that data[start:end] is the source of the node. The offsets are relative to the slice
+// given to Unmarshal, which for the stream functions is the slice of each value.
+//
+// ok is false for a modified node, and for a node that was created or set programmatically
+// (e.g. with IntNode or SetNumberString) rather than parsed. It's also false for the nodes of
+// a document with unquoted keys decoded with Options.AllowUnquotedKeys, whose source is a copy
+// of the data with the keys quoted, so the offsets don't match the data.
+//
+// Usage:
+//
+//	data := []byte(`{"a": [1, true]}`)
+//	root := Must(Unmarshal(data))
+//	start, end, ok := root.MustKey("a").SourceRange()
+//
+//	result: 6, 15, true (data[6:15] is `[1, true]`)
+func (n *Node) SourceRange() (start, end int, ok bool) {
+	if n.source() == nil || n.rewritten {
+		return 0, 0, false
+	}
+
+	// a literal node keeps its own data, which is not the input of the tree.
+	if root := n.root(); len(root.data) == 0 || &root.data[0] != &n.data[0] {
+		return 0, 0, false
+	}
+
+	return n.borders[0], n.borders[1], true
+}
+
 // source returns the source of the current node.
 func (n *Node) source() []byte {
 	if n == nil {
@@ -669,11 +1397,11 @@ func (n *Node) root() *Node {
 //	}
 func (n *Node) GetNull() (interface{}, error) {
 	if n == nil {
-		return nil, errors.New("node is nil")
+		return nil, ErrNilNode
 	}
 
 	if !n.IsNull() {
-		return nil, errors.New("node is not null")
+		return nil, &TypeError{Expected: Null, Got: n.nodeType}
 	}
 
 	return nil, nil
@@ -703,11 +1431,11 @@ func (n *Node) MustNull() interface{} {
 //	println(val) // 10.5
 func (n *Node) GetNumeric() (float64, error) {
 	if n == nil {
-		return 0, errors.New("node is nil")
+		return 0, ErrNilNode
 	}
 
-	if n.nodeType != Number {
-		return 0, errors.New("node is not number")
+	if !isNumericType(n.nodeType) {
+		return 0, &TypeError{Expected: Number, Got: n.nodeType}
 	}
 
 	val, err := n.Value()
@@ -717,73 +1445,267 @@ func (n *Node) GetNumeric() (float64, error) {
 
 	v, ok := val.(float64)
 	if !ok {
-		return 0, errors.New("node is not number")
+		return 0, &TypeError{Expected: Number, Got: n.nodeType}
 	}
 
 	return v, nil
 }
 
-// GetInts traverses the current JSON nodes using DFS and collects all integer values.
-func (n *Node) GetInts() []int {
-    var stack []*Node
-    var result []int
-
-    stack = append(stack, n) // add starting node to stack
-
-    for len(stack) > 0 {
-        var currentNode *Node
-        currentNode, stack = stack[len(stack)-1], stack[:len(stack)-1] // 스택에서 노드를 팝
-
-        if currentNode == nil {
-            continue
-        }
-
-        if currentNode.IsNumber() {
-            numVal, err := currentNode.GetNumeric()
-            if err == nil && math.Mod(numVal, 1) == 0 { // no decimal part
-                result = append(result, int(numVal))
-            }
-        }
+// NumericKind describes the narrowest Go type that holds the value of a number node without loss.
+type NumericKind int
 
-        for _, childNode := range currentNode.next { // append child nodes to stack
-            stack = append(stack, childNode)
-        }
-    }
+const (
+	NumericInt64   NumericKind = iota // an integer in the int64 range.
+	NumericUint64                     // an integer above the int64 range, in the uint64 range.
+	NumericFloat64                    // a fraction, an exponent, or an integer beyond uint64.
+)
 
-    return result
+func (k NumericKind) String() string {
+	switch k {
+	case NumericInt64:
+		return "int64"
+	case NumericUint64:
+		return "uint64"
+	case NumericFloat64:
+		return "float64"
+	default:
+		return "unknown"
+	}
 }
 
-// GetFloats traverses the current JSON nodes using DFS and collects all float values.
-func (n *Node) GetFloats() []float64 {
-    var stack []*Node
-    var result []float64
+// NumericKind reports how the value of the current number node can be stored without loss,
+// so that generic code can choose between integer and float accessors.
+//
+// It inspects the number literal when the node has one: a literal with a decimal point or an
+// exponent is NumericFloat64 even if its value is integral (e.g. 1.0 or 1e2), as is an integer
+// beyond the uint64 range. A number set from a float64 value is classified by its value.
+//
+// Usage:
+//
+//	root := Must(Unmarshal([]byte(`[1, 18446744073709551615, 1.5]`)))
+//	kind, err := root.MustIndex(1).NumericKind()
+//	if err != nil {
+//		t.Errorf("NumericKind returns error: %v", err)
+//	}
+//
+//	result: kind == NumericUint64
+func (n *Node) NumericKind() (NumericKind, error) {
+	if n == nil {
+		return 0, ErrNilNode
+	}
 
-    stack = append(stack, n) // add starting node to stack
+	if !isNumericType(n.nodeType) {
+		return 0, &TypeError{Expected: Number, Got: n.nodeType}
+	}
 
-    for len(stack) > 0 {
-        var currentNode *Node
-        currentNode, stack = stack[len(stack)-1], stack[:len(stack)-1]
+	if literal := n.source(); literal != nil {
+		if bytes.ContainsAny(literal, ".eE") {
+			return NumericFloat64, nil
+		}
 
-        if currentNode == nil {
-            continue
-        }
+		if _, err := strconv.ParseInt(string(literal), 10, 64); err == nil {
+			return NumericInt64, nil
+		}
 
-        if currentNode.IsNumber() {
-            numVal, err := currentNode.GetNumeric()
-            if err == nil && math.Mod(numVal, 1) != 0 { // has decimal part
-                result = append(result, numVal)
-            }
-        }
+		if _, err := strconv.ParseUint(string(literal), 10, 64); err == nil {
+			return NumericUint64, nil
+		}
 
-        for _, childNode := range currentNode.next { // append child nodes to stack
-            stack = append(stack, childNode)
-        }
-    }
+		// out of range integers, NaN and Infinity.
+		return NumericFloat64, nil
+	}
 
-    return result
-}
+	value, err := n.GetNumeric()
+	if err != nil {
+		return 0, err
+	}
 
-// MustNumeric returns the numeric (int/float) value if current node is number type.
+	switch {
+	case n.nodeType == Float || value != math.Trunc(value):
+		return NumericFloat64, nil
+	case value >= -(1<<63) && value < 1<<63:
+		return NumericInt64, nil
+	case value >= 0 && value < 1<<64:
+		return NumericUint64, nil
+	default:
+		return NumericFloat64, nil
+	}
+}
+
+// Number returns the value of the current number node as an int64 if NumericKind reports
+// NumericInt64, and as a float64 otherwise, so that integers are returned without loss.
+//
+// Usage:
+//
+//	root := Must(Unmarshal([]byte(`[9007199254740993, 1.5]`)))
+//	switch v, _ := root.MustIndex(0).Number(); v := v.(type) {
+//	case int64:
+//		println("int", v) // int 9007199254740993
+//	case float64:
+//		println("float", v)
+//	}
+func (n *Node) Number() (interface{}, error) {
+	kind, err := n.NumericKind()
+	if err != nil {
+		return nil, err
+	}
+
+	if kind == NumericInt64 {
+		if literal := n.source(); literal != nil {
+			return strconv.ParseInt(string(literal), 10, 64)
+		}
+
+		value, err := n.GetNumeric()
+		if err != nil {
+			return nil, err
+		}
+
+		return int64(value), nil
+	}
+
+	return n.GetNumeric()
+}
+
+// GetDecimal returns the exact value of the current number node as a rational number.
+//
+// The value is read from the number literal of the source, so a monetary value such as
+// 0.1 or 19.99 is returned exactly, and the integers beyond the float64 precision are
+// not rounded. A number set by SetNumber has no literal, so the shortest decimal form of
+// its float64 value is used instead (e.g. 0.1 for SetNumber(0.1)).
+//
+// Usage:
+//
+//	root := Must(Unmarshal([]byte(`{"price": 19.99}`)))
+//	price, err := root.MustKey("price").GetDecimal()
+//	if err != nil {
+//		t.Errorf("GetDecimal returns error: %v", err)
+//	}
+//
+//	result: price.FloatString(2) == "19.99", price == 1999/100
+func (n *Node) GetDecimal() (*big.Rat, error) {
+	if n == nil {
+		return nil, ErrNilNode
+	}
+
+	if !isNumericType(n.nodeType) {
+		return nil, &TypeError{Expected: Number, Got: n.nodeType}
+	}
+
+	literal := string(n.source())
+	if literal == "" {
+		value, err := n.GetNumeric()
+		if err != nil {
+			return nil, err
+		}
+
+		if math.IsNaN(value) || math.IsInf(value, 0) {
+			return nil, fmt.Errorf("number %v has no decimal value", value)
+		}
+
+		literal = formatNumber(value, false)
+	}
+
+	value, ok := new(big.Rat).SetString(literal)
+	if !ok {
+		return nil, fmt.Errorf("number %s has no decimal value", literal)
+	}
+
+	return value, nil
+}
+
+// SetDecimal sets the current node to the given rational number, written with scale
+// digits after the decimal point, e.g. SetDecimal(big.NewRat(3, 2), 2) writes 1.50.
+// The last digit is rounded to nearest, with halves rounded away from zero.
+//
+// Like SetNumberString, the literal is kept as is and marshaled verbatim, so the scale
+// is preserved and GetDecimal returns the exact rounded value.
+//
+// Usage:
+//
+//	root := Must(Unmarshal([]byte(`{"total": 0}`)))
+//	total := new(big.Rat).Add(big.NewRat(1999, 100), big.NewRat(1, 100))
+//	if err := root.MustKey("total").SetDecimal(total, 2); err != nil {
+//		t.Errorf("SetDecimal returns error: %v", err)
+//	}
+//
+//	result: {"total":20.00}
+func (n *Node) SetDecimal(value *big.Rat, scale int) error {
+	if n == nil {
+		return ErrNilNode
+	}
+
+	if value == nil {
+		return errors.New("decimal value is nil")
+	}
+
+	if scale < 0 {
+		return fmt.Errorf("invalid decimal scale: %d", scale)
+	}
+
+	return n.SetNumberString(value.FloatString(scale))
+}
+
+// GetInts traverses the current JSON nodes using DFS and collects all integer values.
+func (n *Node) GetInts() []int {
+    var stack []*Node
+    var result []int
+
+    stack = append(stack, n) // add starting node to stack
+
+    for len(stack) > 0 {
+        var currentNode *Node
+        currentNode, stack = stack[len(stack)-1], stack[:len(stack)-1] // 스택에서 노드를 팝
+
+        if currentNode == nil {
+            continue
+        }
+
+        if currentNode.IsNumber() {
+            numVal, err := currentNode.GetNumeric()
+            if err == nil && math.Mod(numVal, 1) == 0 { // no decimal part
+                result = append(result, int(numVal))
+            }
+        }
+
+        for _, childNode := range currentNode.next { // append child nodes to stack
+            stack = append(stack, childNode)
+        }
+    }
+
+    return result
+}
+
+// GetFloats traverses the current JSON nodes using DFS and collects all float values.
+func (n *Node) GetFloats() []float64 {
+    var stack []*Node
+    var result []float64
+
+    stack = append(stack, n) // add starting node to stack
+
+    for len(stack) > 0 {
+        var currentNode *Node
+        currentNode, stack = stack[len(stack)-1], stack[:len(stack)-1]
+
+        if currentNode == nil {
+            continue
+        }
+
+        if currentNode.IsNumber() {
+            numVal, err := currentNode.GetNumeric()
+            if err == nil && math.Mod(numVal, 1) != 0 { // has decimal part
+                result = append(result, numVal)
+            }
+        }
+
+        for _, childNode := range currentNode.next { // append child nodes to stack
+            stack = append(stack, childNode)
+        }
+    }
+
+    return result
+}
+
+// MustNumeric returns the numeric (int/float) value if current node is number type.
 //
 // It panics if the current node is not number type.
 func (n *Node) MustNumeric() float64 {
@@ -795,6 +1717,162 @@ func (n *Node) MustNumeric() float64 {
 	return v
 }
 
+// GetNumericLoose returns the numeric value of the current node, which is either
+// a number node or a string node holding a number literal (e.g. "42" or "-1.5e3").
+// It returns an error for the other types and for strings that are not numbers.
+//
+// Usage:
+//
+//	root := Must(Unmarshal([]byte(`{"a": 42, "b": "42"}`)))
+//	a, _ := root.MustKey("a").GetNumericLoose()
+//	b, _ := root.MustKey("b").GetNumericLoose()
+//
+//	result: a == b == 42
+func (n *Node) GetNumericLoose() (float64, error) {
+	if n == nil {
+		return 0, ErrNilNode
+	}
+
+	if n.nodeType != String {
+		return n.GetNumeric()
+	}
+
+	str, err := n.GetString()
+	if err != nil {
+		return 0, err
+	}
+
+	value, err := parseNumberLiteral([]byte(str))
+	if err != nil {
+		return 0, fmt.Errorf("string %q is not a number: %w", str, err)
+	}
+
+	return value, nil
+}
+
+// NumberFormat describes the separators of a formatted number string, for GetNumericFormatted.
+type NumberFormat struct {
+	// Grouping is the thousands separator, e.g. ',' in "1,234.56". Zero means no grouping.
+	Grouping rune
+
+	// Decimal is the decimal separator, e.g. ',' in "1.234,56". Zero means '.'.
+	Decimal rune
+}
+
+// GetNumericFormatted is like GetNumericLoose, but it accepts a string value formatted
+// with the separators of the given format: the grouping separators are stripped and
+// the decimal separator is replaced with '.'. The result must be a valid JSON number.
+//
+// This is not part of strict JSON, and is meant for cleaning up exported or legacy data.
+//
+// Usage:
+//
+//	root := Must(Unmarshal([]byte(`{"us": "1,234.56", "de": "1.234,56"}`)))
+//	us, _ := root.MustKey("us").GetNumericFormatted(NumberFormat{Grouping: ','})
+//	de, _ := root.MustKey("de").GetNumericFormatted(NumberFormat{Grouping: '.', Decimal: ','})
+//
+//	result: us == de == 1234.56
+func (n *Node) GetNumericFormatted(opts NumberFormat) (float64, error) {
+	if n == nil {
+		return 0, ErrNilNode
+	}
+
+	decimal := opts.Decimal
+	if decimal == 0 {
+		decimal = '.'
+	}
+
+	if opts.Grouping == decimal {
+		return 0, fmt.Errorf("grouping and decimal separators are both %q", decimal)
+	}
+
+	if n.nodeType != String {
+		return n.GetNumeric()
+	}
+
+	str, err := n.GetString()
+	if err != nil {
+		return 0, err
+	}
+
+	normalized := strings.Map(func(r rune) rune {
+		switch r {
+		case opts.Grouping:
+			return -1
+		case decimal:
+			return '.'
+		case '.':
+			// a '.' that is not a separator of the format must not be read as a decimal point.
+			return utf8.RuneError
+		default:
+			return r
+		}
+	}, str)
+
+	value, err := parseNumberLiteral([]byte(normalized))
+	if err != nil {
+		return 0, fmt.Errorf("string %q is not a formatted number: %w", str, err)
+	}
+
+	return value, nil
+}
+
+// Clamp limits the value of the current number node to the range [min, max].
+// The node is only modified if its value is outside the range.
+//
+// Usage:
+//
+//	root := Must(Unmarshal([]byte(`120`)))
+//	if err := root.Clamp(0, 100); err != nil {
+//		t.Errorf("Clamp returns error: %v", err)
+//	}
+//
+//	result: 100
+func (n *Node) Clamp(min, max float64) error {
+	if min > max {
+		return fmt.Errorf("invalid range: min %g is greater than max %g", min, max)
+	}
+
+	value, err := n.GetNumeric()
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case value < min:
+		return n.SetNumber(min)
+	case value > max:
+		return n.SetNumber(max)
+	default:
+		return nil
+	}
+}
+
+// ClampAll limits the value of every number node in the subtree of the current node
+// to the range [min, max]. The other nodes are left unchanged.
+//
+// Usage:
+//
+//	root := Must(Unmarshal([]byte(`{"a": -5, "b": [50, 500, "x"]}`)))
+//	if err := root.ClampAll(0, 100); err != nil {
+//		t.Errorf("ClampAll returns error: %v", err)
+//	}
+//
+//	result: {"a":0,"b":[50,100,"x"]}
+func (n *Node) ClampAll(min, max float64) error {
+	if min > max {
+		return fmt.Errorf("invalid range: min %g is greater than max %g", min, max)
+	}
+
+	return n.MapLeaves(func(leaf *Node) error {
+		if !leaf.IsNumber() {
+			return nil
+		}
+
+		return leaf.Clamp(min, max)
+	})
+}
+
 // GetString returns the string value if current node is string type.
 //
 // Usage:
@@ -812,11 +1890,11 @@ func (n *Node) MustNumeric() float64 {
 //	println(str) // "foo"
 func (n *Node) GetString() (string, error) {
 	if n == nil {
-		return "", errors.New("string node is empty")
+		return "", ErrNilNode
 	}
 
 	if !n.IsString() {
-		return "", errors.New("node type is not string")
+		return "", &TypeError{Expected: String, Got: n.nodeType}
 	}
 
 	val, err := n.Value()
@@ -826,7 +1904,7 @@ func (n *Node) GetString() (string, error) {
 
 	v, ok := val.(string)
 	if !ok {
-		return "", errors.New("node is not string")
+		return "", &TypeError{Expected: String, Got: n.nodeType}
 	}
 
 	return v, nil
@@ -930,11 +2008,11 @@ func (n *Node) GetBools() []bool {
 //	println(val) // true
 func (n *Node) GetBool() (bool, error) {
 	if n == nil {
-		return false, errors.New("node is nil")
+		return false, ErrNilNode
 	}
 
 	if n.nodeType != Boolean {
-		return false, errors.New("node is not boolean")
+		return false, &TypeError{Expected: Boolean, Got: n.nodeType}
 	}
 
 	val, err := n.Value()
@@ -944,7 +2022,7 @@ func (n *Node) GetBool() (bool, error) {
 
 	v, ok := val.(bool)
 	if !ok {
-		return false, errors.New("node is not boolean")
+		return false, &TypeError{Expected: Boolean, Got: n.nodeType}
 	}
 
 	return v, nil
@@ -979,11 +2057,11 @@ func (n *Node) MustBool() bool {
 //	 result: "foo", 1
 func (n *Node) GetArray() ([]*Node, error) {
 	if n == nil {
-		return nil, errors.New("node is nil")
+		return nil, ErrNilNode
 	}
 
 	if n.nodeType != Array {
-		return nil, fmt.Errorf("node is not array. got: %s", n.nodeType.String())
+		return nil, &TypeError{Expected: Array, Got: n.nodeType}
 	}
 
 	val, err := n.Value()
@@ -993,7 +2071,7 @@ func (n *Node) GetArray() ([]*Node, error) {
 
 	v, ok := val.([]*Node)
 	if !ok {
-		return nil, errors.New("node is not array")
+		return nil, &TypeError{Expected: Array, Got: n.nodeType}
 	}
 
 	return v, nil
@@ -1011,159 +2089,1996 @@ func (n *Node) MustArray() []*Node {
 	return v
 }
 
-// AppendArray appends the given values to the current array node.
-//
-// If the current node is not array type, it returns an error.
-//
-// Example 1:
-//
-//	root := Must(Unmarshal([]byte(`[{"foo":"bar"}]`)))
-//	if err := root.AppendArray(NullNode("")); err != nil {
-//		t.Errorf("should not return error: %s", err)
-//	}
+// AsArray returns the elements of the current node if it is an array, or a one-element
+// slice holding the node itself otherwise. It returns nil for a nil node.
 //
-//	result: [{"foo":"bar"}, null]
+// This treats a field that is sometimes a single value and sometimes a list of values
+// as always a list. The returned slice is new, but its elements are the nodes of the tree,
+// not clones.
 //
-// Example 2:
+// Usage:
 //
-//	root := Must(Unmarshal([]byte(`["bar", "baz"]`)))
-//	err := root.AppendArray(NumberNode("", 1), StringNode("", "foo"))
-//	if err != nil {
-//		t.Errorf("AppendArray returns error: %v", err)
-//	 }
+//	root := Must(Unmarshal([]byte(`{"tags": "a", "ids": [1, 2]}`)))
+//	tags := root.MustKey("tags").AsArray()
+//	ids := root.MustKey("ids").AsArray()
 //
-//	result: ["bar", "baz", 1, "foo"]
-func (n *Node) AppendArray(value ...*Node) error {
-	if !n.IsArray() {
-		return errors.New("can't append value to non-array node")
+//	result: tags == ["a"], ids == [1, 2]
+func (n *Node) AsArray() []*Node {
+	if n == nil {
+		return nil
 	}
 
-	for _, val := range value {
-		if err := n.append(nil, val); err != nil {
-			return err
-		}
+	if n.IsArray() {
+		return n.children()
 	}
 
-	n.mark()
-	return nil
+	return []*Node{n}
 }
 
-// ArrayEach executes the callback for each element in the JSON array.
+// AsObject is an alias of GetObject. Like the other As* assertions, it returns ErrNilNode
+// for a nil node and a *TypeError for a node of another type, so the caller can report
+// the expected and actual types with errors.As.
+//
+// AsArray is not the array assertion, but accepts a single value as a list. Use GetArray
+// to assert an array.
 //
 // Usage:
 //
-//	jsonArrayNode.ArrayEach(func(i int, valueNode *Node) {
-//	    fmt.Println(i, valueNode)
-//	})
-func (n *Node) ArrayEach(callback func(i int, target *Node)) {
-	if n == nil || !n.IsArray() {
-		return
-	}
+//	_, err := root.MustKey("tags").AsObject()
+//
+//	var te *TypeError
+//	if errors.As(err, &te) {
+//		println(te.Expected.String(), te.Got.String()) // object array
+//	}
+func (n *Node) AsObject() (map[string]*Node, error) {
+	return n.GetObject()
+}
 
-	for idx := 0; idx < len(n.next); idx++ {
-		element, err := n.GetIndex(idx)
-		if err != nil {
-			continue
-		}
+// AsString is an alias of GetString. See AsObject.
+func (n *Node) AsString() (string, error) {
+	return n.GetString()
+}
 
-		callback(idx, element)
-	}
+// AsNumber is an alias of GetNumeric. See AsObject.
+func (n *Node) AsNumber() (float64, error) {
+	return n.GetNumeric()
 }
 
-// GetObject returns the object value if current node is object type.
+// AsBool is an alias of GetBool. See AsObject.
+func (n *Node) AsBool() (bool, error) {
+	return n.GetBool()
+}
+
+// Head returns the first k elements of the current array node.
+// If k exceeds the length of the array, all elements are returned.
 //
 // Usage:
 //
-//	root := Must(Unmarshal([]byte(`{"key": "value"}`)))
-//	obj, err := root.GetObject()
+//	root := Must(Unmarshal([]byte(`[1, 2, 3]`)))
+//	head, err := root.Head(2)
 //	if err != nil {
-//		t.Errorf("GetObject returns error: %v", err)
+//		t.Errorf("Head returns error: %v", err)
+//	}
+//
+//	result: [1, 2]
+func (n *Node) Head(k int) ([]*Node, error) {
+	elems, err := n.arraySlice(k)
+	if err != nil {
+		return nil, err
+	}
+
+	return elems[:min(k, len(elems))], nil
+}
+
+// Tail returns the last k elements of the current array node.
+// If k exceeds the length of the array, all elements are returned.
+//
+// Usage:
+//
+//	root := Must(Unmarshal([]byte(`[1, 2, 3]`)))
+//	tail, err := root.Tail(2)
+//	if err != nil {
+//		t.Errorf("Tail returns error: %v", err)
+//	}
+//
+//	result: [2, 3]
+func (n *Node) Tail(k int) ([]*Node, error) {
+	elems, err := n.arraySlice(k)
+	if err != nil {
+		return nil, err
+	}
+
+	return elems[len(elems)-min(k, len(elems)):], nil
+}
+
+// Slice returns the elements of the current array node selected by [start:end:step],
+// with the same semantics as a slice segment of a JSON path (see Collect): a nil bound
+// is open, a negative bound counts from the end, and a negative step selects the elements
+// in reverse order. A nil step means 1, and a zero step selects nothing.
+//
+// Usage:
+//
+//	root := Must(Unmarshal([]byte(`[1, 2, 3, 4]`)))
+//	start, step := -2, -1
+//	elems, err := root.Slice(&start, nil, &step)
+//	if err != nil {
+//		t.Errorf("Slice returns error: %v", err)
+//	}
+//
+//	result: [3, 2, 1]
+func (n *Node) Slice(start, end, step *int) ([]*Node, error) {
+	if n == nil {
+		return nil, ErrNilNode
+	}
+
+	if !n.IsArray() {
+		return nil, &TypeError{Expected: Array, Got: n.nodeType}
+	}
+
+	indices := sliceIndices(n.Size(), start, end, step)
+
+	elems := make([]*Node, len(indices))
+	for i, idx := range indices {
+		elems[i] = n.next[strconv.Itoa(idx)]
+	}
+
+	return elems, nil
+}
+
+// arraySlice returns the elements of the current array node for Head and Tail.
+func (n *Node) arraySlice(k int) ([]*Node, error) {
+	if k < 0 {
+		return nil, fmt.Errorf("invalid count %d: %w", k, ErrIndexOutOfRange)
+	}
+
+	return n.GetArray()
+}
+
+// GetStringArray returns the string values of the direct elements of the current array node
+// in their original order.
+//
+// Unlike GetStrings, it does not traverse nested nodes and returns an error
+// if any element is not string type.
+//
+// Usage:
+//
+//	root := Must(Unmarshal([]byte(`["foo", "bar"]`)))
+//	strs, err := root.GetStringArray()
+//	if err != nil {
+//		t.Errorf("GetStringArray returns error: %v", err)
+//	}
+//
+//	result: []string{"foo", "bar"}
+func (n *Node) GetStringArray() ([]string, error) {
+	arr, err := n.GetArray()
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]string, len(arr))
+	for i, elem := range arr {
+		if result[i], err = elem.GetString(); err != nil {
+			return nil, fmt.Errorf("array element %d: %w", i, err)
+		}
+	}
+
+	return result, nil
+}
+
+// GetNumberArray returns the numeric values of the direct elements of the current array node
+// in their original order.
+//
+// Unlike GetFloats, it does not traverse nested nodes and returns an error
+// if any element is not number type.
+//
+// Usage:
+//
+//	root := Must(Unmarshal([]byte(`[1, 2.5, -3]`)))
+//	nums, err := root.GetNumberArray()
+//	if err != nil {
+//		t.Errorf("GetNumberArray returns error: %v", err)
+//	}
+//
+//	result: []float64{1, 2.5, -3}
+func (n *Node) GetNumberArray() ([]float64, error) {
+	arr, err := n.GetArray()
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]float64, len(arr))
+	for i, elem := range arr {
+		if result[i], err = elem.GetNumeric(); err != nil {
+			return nil, fmt.Errorf("array element %d: %w", i, err)
+		}
+	}
+
+	return result, nil
+}
+
+// AppendArray appends the given values to the current array node.
+//
+// If the current node is not array type, it returns an error.
+//
+// Example 1:
+//
+//	root := Must(Unmarshal([]byte(`[{"foo":"bar"}]`)))
+//	if err := root.AppendArray(NullNode("")); err != nil {
+//		t.Errorf("should not return error: %s", err)
+//	}
+//
+//	result: [{"foo":"bar"}, null]
+//
+// Example 2:
+//
+//	root := Must(Unmarshal([]byte(`["bar", "baz"]`)))
+//	err := root.AppendArray(NumberNode("", 1), StringNode("", "foo"))
+//	if err != nil {
+//		t.Errorf("AppendArray returns error: %v", err)
+//	 }
+//
+//	result: ["bar", "baz", 1, "foo"]
+func (n *Node) AppendArray(value ...*Node) error {
+	if !n.IsArray() {
+		return &TypeError{Expected: Array, Got: n.nodeType}
+	}
+
+	for _, val := range value {
+		if err := n.append(nil, val); err != nil {
+			return err
+		}
+	}
+
+	n.mark()
+	return nil
+}
+
+// AppendUnique appends the given value to the current array node only if
+// no structurally equal element exists yet. It reports whether the value was appended.
+//
+// If the value already belongs to another node, its clone is appended
+// so that the original node is left in place.
+//
+// Usage:
+//
+//	root := Must(Unmarshal([]byte(`["foo", "bar"]`)))
+//	added, err := root.AppendUnique(StringNode("", "foo"))
+//	if err != nil {
+//		t.Errorf("AppendUnique returns error: %v", err)
+//	}
+//
+//	result: added == false, ["foo", "bar"]
+func (n *Node) AppendUnique(value *Node) (bool, error) {
+	if n == nil {
+		return false, ErrNilNode
+	}
+
+	if !n.IsArray() {
+		return false, &TypeError{Expected: Array, Got: n.nodeType}
+	}
+
+	if value == nil {
+		return false, ErrNilNode
+	}
+
+	for _, elem := range n.next {
+		if equals(elem, value) {
+			return false, nil
+		}
+	}
+
+	if value.prev != nil {
+		value = value.Clone()
+	}
+
+	if err := n.append(nil, value); err != nil {
+		return false, err
+	}
+
+	n.mark()
+	return true, nil
+}
+
+// Prepend inserts the given values at the front of the current array node,
+// keeping their order. The existing elements are shifted to the right.
+//
+// Usage:
+//
+//	root := Must(Unmarshal([]byte(`[3]`)))
+//	if err := root.Prepend(NumberNode("", 1), NumberNode("", 2)); err != nil {
+//		t.Errorf("Prepend returns error: %v", err)
+//	}
+//
+//	result: [1, 2, 3]
+func (n *Node) Prepend(values ...*Node) error {
+	if n == nil {
+		return ErrNilNode
+	}
+
+	if !n.IsArray() {
+		return &TypeError{Expected: Array, Got: n.nodeType}
+	}
+
+	if err := n.mutable(); err != nil {
+		return err
+	}
+
+	for i, val := range values {
+		if val == nil {
+			return ErrNilNode
+		}
+
+		if err := val.mutable(); err != nil {
+			return err
+		}
+
+		if val.isSameOrParentNode(n) {
+			return errors.New("can't prepend same or parent node")
+		}
+
+		if err := val.detach(); err != nil {
+			return err
+		}
+
+		n.insert(i, val)
+	}
+
+	n.mark()
+	return nil
+}
+
+// Concat appends all elements of the given array nodes to the current array node.
+//
+// The elements are cloned, so the given arrays are left unchanged. Nothing is appended
+// if the current node or any of the given nodes is not array type.
+//
+// Usage:
+//
+//	root := Must(Unmarshal([]byte(`[1, 2]`)))
+//	other := Must(Unmarshal([]byte(`[3, 4]`)))
+//	if err := root.Concat(other); err != nil {
+//		t.Errorf("Concat returns error: %v", err)
+//	}
+//
+//	result: [1, 2, 3, 4]
+func (n *Node) Concat(others ...*Node) error {
+	if n == nil {
+		return ErrNilNode
+	}
+
+	if !n.IsArray() {
+		return &TypeError{Expected: Array, Got: n.nodeType}
+	}
+
+	var elems []*Node
+	for i, other := range others {
+		arr, err := other.GetArray()
+		if err != nil {
+			return fmt.Errorf("argument %d: %w", i, err)
+		}
+
+		for _, elem := range arr {
+			elems = append(elems, elem.Clone())
+		}
+	}
+
+	for _, elem := range elems {
+		if err := n.append(nil, elem); err != nil {
+			return err
+		}
+	}
+
+	n.mark()
+	return nil
+}
+
+// Resize grows or shrinks the current array node to the given length.
+//
+// When growing, fill is called with the index of each new element. If fill is nil,
+// the new elements are null. When shrinking, the elements from the given length
+// onwards are removed and detached.
+//
+// Usage:
+//
+//	root := Must(Unmarshal([]byte(`[1, 2]`)))
+//	err := root.Resize(4, func(i int) *Node { return NumberNode("", float64(i)) })
+//	if err != nil {
+//		t.Errorf("Resize returns error: %v", err)
+//	}
+//
+//	result: [1, 2, 2, 3]
+func (n *Node) Resize(length int, fill func(i int) *Node) error {
+	if n == nil {
+		return ErrNilNode
+	}
+
+	if !n.IsArray() {
+		return &TypeError{Expected: Array, Got: n.nodeType}
+	}
+
+	if length < 0 {
+		return fmt.Errorf("invalid length %d: %w", length, ErrIndexOutOfRange)
+	}
+
+	size := len(n.next)
+	if length == size {
+		return nil
+	}
+
+	if length < size {
+		for i := size - 1; i >= length; i-- {
+			elem := n.next[strconv.Itoa(i)]
+			if err := n.remove(elem); err != nil {
+				return err
+			}
+
+			elem.setRef(nil, nil, nil)
+		}
+
+		return nil
+	}
+
+	elems := make([]*Node, 0, length-size)
+	for i := size; i < length; i++ {
+		if fill == nil {
+			elems = append(elems, NullNode(""))
+			continue
+		}
+
+		elem := fill(i)
+		if elem == nil {
+			return fmt.Errorf("fill value %d: %w", i, ErrNilNode)
+		}
+
+		if elem.prev != nil {
+			elem = elem.Clone()
+		}
+
+		elems = append(elems, elem)
+	}
+
+	for _, elem := range elems {
+		if err := n.append(nil, elem); err != nil {
+			return err
+		}
+	}
+
+	n.mark()
+	return nil
+}
+
+// ArrayEach executes the callback for each element in the JSON array.
+//
+// Usage:
+//
+//	jsonArrayNode.ArrayEach(func(i int, valueNode *Node) {
+//	    fmt.Println(i, valueNode)
+//	})
+func (n *Node) ArrayEach(callback func(i int, target *Node)) {
+	if n == nil || !n.IsArray() {
+		return
+	}
+
+	for idx := 0; idx < len(n.next); idx++ {
+		element, err := n.GetIndex(idx)
+		if err != nil {
+			continue
+		}
+
+		callback(idx, element)
+	}
+}
+
+// ArrayEachErr executes the callback for each element in the JSON array, in index order,
+// and stops at the first error returned by the callback and returns it.
+//
+// Unlike ArrayEach, it returns an error if the current node is not an array
+// or an element can't be retrieved.
+//
+// Usage:
+//
+//	err := root.ArrayEachErr(func(i int, elem *Node) error {
+//		if !elem.IsNumber() {
+//			return fmt.Errorf("element %d is not a number", i)
+//		}
+//
+//		return elem.SetNumber(elem.MustNumeric() * 2)
+//	})
+func (n *Node) ArrayEachErr(callback func(i int, node *Node) error) error {
+	if n == nil {
+		return ErrNilNode
+	}
+
+	if !n.IsArray() {
+		return &TypeError{Expected: Array, Got: n.nodeType}
+	}
+
+	for idx := 0; idx < len(n.next); idx++ {
+		element, err := n.GetIndex(idx)
+		if err != nil {
+			return fmt.Errorf("array element %d: %w", idx, err)
+		}
+
+		if err := callback(idx, element); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// GetObject returns the object value if current node is object type.
+//
+// Usage:
+//
+//	root := Must(Unmarshal([]byte(`{"key": "value"}`)))
+//	obj, err := root.GetObject()
+//	if err != nil {
+//		t.Errorf("GetObject returns error: %v", err)
 //	}
 //
 //	result: map[string]*Node{"key": StringNode("key", "value")}
 func (n *Node) GetObject() (map[string]*Node, error) {
 	if n == nil {
-		return nil, errors.New("node is nil")
+		return nil, ErrNilNode
+	}
+
+	if !n.IsObject() {
+		return nil, &TypeError{Expected: Object, Got: n.nodeType}
+	}
+
+	val, err := n.Value()
+	if err != nil {
+		return nil, err
+	}
+
+	v, ok := val.(map[string]*Node)
+	if !ok {
+		return nil, &TypeError{Expected: Object, Got: n.nodeType}
+	}
+
+	return v, nil
+}
+
+// MustObject returns the object value if current node is object type.
+//
+// It panics if the current node is not object type.
+func (n *Node) MustObject() map[string]*Node {
+	v, err := n.GetObject()
+	if err != nil {
+		panic(err)
+	}
+
+	return v
+}
+
+// Keys returns the keys of the current object node in sorted order, like ObjectEachSorted.
+// Unlike UniqueKeys, only the direct keys are returned.
+//
+// Usage:
+//
+//	root := Must(Unmarshal([]byte(`{"b": 1, "a": {"c": 2}}`)))
+//	keys, err := root.Keys()
+//	if err != nil {
+//		t.Errorf("Keys returns error: %v", err)
+//	}
+//
+//	result: ["a", "b"]
+func (n *Node) Keys() ([]string, error) {
+	if n == nil {
+		return nil, ErrNilNode
+	}
+
+	if !n.IsObject() {
+		return nil, &TypeError{Expected: Object, Got: n.nodeType}
+	}
+
+	return n.sortedKeys(), nil
+}
+
+// Values returns the direct children of the current node: the member values of an object
+// in the order of Keys, or the elements of an array in index order. It returns nil for
+// a scalar or nil node. The children are the nodes of the tree, not clones.
+//
+// Usage:
+//
+//	root := Must(Unmarshal([]byte(`{"b": [1, 2], "a": "x"}`)))
+//	values := root.Values()
+//
+//	result: ["x", [1, 2]]
+func (n *Node) Values() []*Node {
+	if n == nil {
+		return nil
+	}
+
+	switch {
+	case n.IsArray():
+		return n.children()
+	case n.IsObject():
+		keys := n.sortedKeys()
+		values := make([]*Node, len(keys))
+		for i, key := range keys {
+			values[i] = n.next[key]
+		}
+
+		return values
+	default:
+		return nil
+	}
+}
+
+// AppendObject appends the given key and value to the current object node.
+//
+// If the current node is not object type, it returns an error.
+func (n *Node) AppendObject(key string, value *Node) error {
+	if !n.IsObject() {
+		return &TypeError{Expected: Object, Got: n.nodeType}
+	}
+
+	if err := n.append(&key, value); err != nil {
+		return err
+	}
+
+	n.mark()
+	return nil
+}
+
+// AppendObjectIfAbsent appends the given key and value to the current object node
+// only if the key doesn't exist yet, and reports whether the value was appended.
+// Unlike AppendObject, an existing member is never overwritten.
+//
+// Usage:
+//
+//	root := Must(Unmarshal([]byte(`{"name": "foo"}`)))
+//	added, err := root.AppendObjectIfAbsent("name", StringNode("", "bar"))
+//	if err != nil {
+//		t.Errorf("AppendObjectIfAbsent returns error: %v", err)
+//	}
+//
+//	result: added == false, {"name": "foo"}
+func (n *Node) AppendObjectIfAbsent(key string, value *Node) (bool, error) {
+	if n == nil {
+		return false, ErrNilNode
+	}
+
+	if !n.IsObject() {
+		return false, &TypeError{Expected: Object, Got: n.nodeType}
+	}
+
+	if n.HasKey(key) {
+		return false, nil
+	}
+
+	if err := n.AppendObject(key, value); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// RemoveKeys removes the members with the given keys from the current object node,
+// and returns the number of removed members. The absent keys are skipped.
+//
+// If the current node is not object type, it returns an error.
+//
+// Usage:
+//
+//	root := Must(Unmarshal([]byte(`{"user": "foo", "password": "bar", "token": "baz"}`)))
+//	removed, err := root.RemoveKeys("password", "token", "secret")
+//	if err != nil {
+//		t.Errorf("RemoveKeys returns error: %v", err)
+//	}
+//
+//	result: removed == 2, {"user": "foo"}
+func (n *Node) RemoveKeys(keys ...string) (int, error) {
+	if n == nil {
+		return 0, ErrNilNode
+	}
+
+	if !n.IsObject() {
+		return 0, &TypeError{Expected: Object, Got: n.nodeType}
+	}
+
+	removed := 0
+	for _, key := range keys {
+		child, ok := n.next[key]
+		if !ok {
+			continue
+		}
+
+		if err := n.remove(child); err != nil {
+			return removed, err
+		}
+
+		removed++
+	}
+
+	return removed, nil
+}
+
+// RetainKeys removes every member of the current object node whose key is not
+// in the given keys. It is the inverse of RemoveKeys, for whitelisting fields.
+//
+// If the current node is not object type, it returns an error.
+//
+// Usage:
+//
+//	root := Must(Unmarshal([]byte(`{"id": 1, "name": "foo", "password": "bar"}`)))
+//	if err := root.RetainKeys("id", "name"); err != nil {
+//		t.Errorf("RetainKeys returns error: %v", err)
+//	}
+//
+//	result: {"id": 1, "name": "foo"}
+func (n *Node) RetainKeys(keys ...string) error {
+	if n == nil {
+		return ErrNilNode
+	}
+
+	if !n.IsObject() {
+		return &TypeError{Expected: Object, Got: n.nodeType}
+	}
+
+	retain := make(map[string]bool, len(keys))
+	for _, key := range keys {
+		retain[key] = true
+	}
+
+	for _, key := range n.sortedKeys() {
+		if retain[key] {
+			continue
+		}
+
+		if err := n.remove(n.next[key]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ArrayMergeMode selects how DeepMergeWith merges two arrays found at the same location.
+type ArrayMergeMode int
+
+const (
+	// ArrayReplace replaces the array with the other one, like any other value.
+	ArrayReplace ArrayMergeMode = iota
+
+	// ArrayConcat appends the elements of the other array to the array.
+	ArrayConcat
+)
+
+// DeepMerge recursively merges the other node into the current node.
+// It is equivalent to DeepMergeWith(other, ArrayReplace).
+//
+// Usage:
+//
+//	config := Must(Unmarshal([]byte(`{"log": {"level": "info", "file": "app.log"}, "ports": [80]}`)))
+//	overlay := Must(Unmarshal([]byte(`{"log": {"level": "debug"}, "ports": [8080]}`)))
+//	if err := config.DeepMerge(overlay); err != nil {
+//		t.Errorf("DeepMerge returns error: %v", err)
+//	}
+//
+//	result: {"log": {"level": "debug", "file": "app.log"}, "ports": [8080]}
+func (n *Node) DeepMerge(other *Node) error {
+	return n.DeepMergeWith(other, ArrayReplace)
+}
+
+// DeepMergeWith recursively merges the other node into the current node, for overlaying configurations.
+//
+// When both nodes are objects, the members of the other object are merged into the
+// members with the same key, and the missing ones are added. When both are arrays,
+// they are merged according to the given mode. Otherwise, the other node wins and
+// replaces the current one, including a null value (unlike the RFC 7396 merge patch,
+// null doesn't delete the member).
+//
+// The merged values are cloned, so the other node is left unchanged.
+func (n *Node) DeepMergeWith(other *Node, arrays ArrayMergeMode) error {
+	if n == nil || other == nil {
+		return ErrNilNode
+	}
+
+	return n.deepMerge(other.Clone(), arrays)
+}
+
+// deepMerge merges the other node, which is owned by the merge, into the current node.
+func (n *Node) deepMerge(other *Node, arrays ArrayMergeMode) error {
+	switch {
+	case n.IsObject() && other.IsObject():
+		for _, key := range other.sortedKeys() {
+			value := other.next[key]
+
+			current, ok := n.next[key]
+			if !ok {
+				if err := n.AppendObject(key, value); err != nil {
+					return err
+				}
+
+				continue
+			}
+
+			if err := current.deepMerge(value, arrays); err != nil {
+				return err
+			}
+		}
+
+		return nil
+
+	case n.IsArray() && other.IsArray() && arrays == ArrayConcat:
+		return n.AppendArray(other.children()...)
+
+	default:
+		return n.SetNode(other)
+	}
+}
+
+// MergeStrategy selects how MergeAll resolves the values found at the same location
+// in several documents.
+type MergeStrategy int
+
+const (
+	// MergeLastWins merges the top-level members of objects, and the member of the later
+	// document replaces the earlier one as a whole, even if both are objects.
+	MergeLastWins MergeStrategy = iota
+
+	// MergeFirstWins merges the top-level members of objects, and the member of the earlier
+	// document is kept as a whole. The later documents only add the missing members.
+	MergeFirstWins
+
+	// MergeDeep merges the objects recursively like DeepMerge: the members of nested objects
+	// are merged, and any other value, including an array, is replaced by the later one.
+	MergeDeep
+
+	// MergeArrayConcat merges like MergeDeep, except that the arrays found at the same
+	// location are concatenated in document order.
+	MergeArrayConcat
+)
+
+// MergeAll folds the given documents together in order with the given strategy, and returns
+// the result as a new tree, leaving the documents unchanged. This suits layered configurations,
+// e.g. MergeAll(MergeDeep, defaults, env, flags).
+//
+// Whatever the strategy, two values that are not both objects (or both arrays for
+// MergeArrayConcat) conflict as scalars: the later one replaces the earlier one, except
+// for MergeFirstWins which keeps the earlier one. The top-level documents follow the same
+// rule, so merging an object with an array yields the array, or the object for MergeFirstWins.
+//
+// It returns ErrEmptyDocument if no document is given, and ErrNilNode if one of them is nil.
+//
+// Usage:
+//
+//	defaults := Must(Unmarshal([]byte(`{"log": {"level": "info", "file": "app.log"}, "ports": [80]}`)))
+//	env := Must(Unmarshal([]byte(`{"log": {"level": "debug"}, "ports": [8080]}`)))
+//	config, err := MergeAll(MergeArrayConcat, defaults, env)
+//
+//	result: {"log": {"level": "debug", "file": "app.log"}, "ports": [80, 8080]}
+func MergeAll(strategy MergeStrategy, docs ...*Node) (*Node, error) {
+	if len(docs) == 0 {
+		return nil, ErrEmptyDocument
+	}
+
+	for _, doc := range docs {
+		if doc == nil {
+			return nil, ErrNilNode
+		}
+	}
+
+	result := docs[0].Clone()
+	for _, doc := range docs[1:] {
+		var err error
+
+		switch strategy {
+		case MergeLastWins, MergeFirstWins:
+			result, err = mergeShallow(result, doc, strategy == MergeLastWins)
+		case MergeDeep:
+			err = result.DeepMergeWith(doc, ArrayReplace)
+		case MergeArrayConcat:
+			err = result.DeepMergeWith(doc, ArrayConcat)
+		default:
+			return nil, fmt.Errorf("unknown merge strategy: %d", strategy)
+		}
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return result, nil
+}
+
+// mergeShallow merges the top-level members of the other document into the result,
+// replacing the existing members if override is true.
+func mergeShallow(result, other *Node, override bool) (*Node, error) {
+	if !result.IsObject() || !other.IsObject() {
+		if override {
+			return other.Clone(), nil
+		}
+
+		return result, nil
+	}
+
+	for _, key := range other.sortedKeys() {
+		if !override && result.HasKey(key) {
+			continue
+		}
+
+		if err := result.AppendObject(key, other.next[key].Clone()); err != nil {
+			return nil, err
+		}
+	}
+
+	return result, nil
+}
+
+// FillDefaults copies the members of the defaults object that are missing in the
+// current object node. Existing members are never overwritten, but if both the existing
+// and the default value are objects, the defaults are applied to them recursively.
+//
+// The copied values are cloned, so the defaults node is left unchanged and can be reused.
+//
+// Usage:
+//
+//	config := Must(Unmarshal([]byte(`{"port": 8080, "log": {"level": "debug"}}`)))
+//	defaults := Must(Unmarshal([]byte(`{"port": 80, "host": "localhost", "log": {"level": "info", "file": "app.log"}}`)))
+//	if err := config.FillDefaults(defaults); err != nil {
+//		t.Errorf("FillDefaults returns error: %v", err)
+//	}
+//
+//	result: {"port": 8080, "host": "localhost", "log": {"level": "debug", "file": "app.log"}}
+func (n *Node) FillDefaults(defaults *Node) error {
+	if n == nil || defaults == nil {
+		return ErrNilNode
+	}
+
+	if !n.IsObject() {
+		return &TypeError{Expected: Object, Got: n.nodeType}
+	}
+
+	if !defaults.IsObject() {
+		return errors.New("defaults must be an object node")
+	}
+
+	return n.fillDefaults(defaults)
+}
+
+func (n *Node) fillDefaults(defaults *Node) error {
+	for _, key := range defaults.sortedKeys() {
+		def := defaults.next[key]
+
+		current, ok := n.next[key]
+		if !ok {
+			if err := n.mutable(); err != nil {
+				return err
+			}
+
+			val := def.Clone()
+			val.setRef(n, &key, nil)
+			n.next[key] = val
+			n.mark()
+			continue
+		}
+
+		if current.IsObject() && def.IsObject() {
+			if err := current.fillDefaults(def); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// ObjectEach executes the callback for each key-value pair in the JSON object.
+//
+// Usage:
+//
+//	jsonObjectNode.ObjectEach(func(key string, valueNode *Node) {
+//	    fmt.Println(key, valueNode)
+//	})
+func (n *Node) ObjectEach(callback func(key string, value *Node)) {
+	if n == nil || !n.IsObject() {
+		return
+	}
+
+	for key, child := range n.next {
+		callback(key, child)
+	}
+}
+
+// ObjectEachSorted executes the callback for each key-value pair in the JSON object,
+// in ascending order of the keys.
+//
+// Unlike ObjectEach, the iteration order is deterministic, like ArrayEach.
+//
+// Usage:
+//
+//	root := Must(Unmarshal([]byte(`{"b": 2, "a": 1}`)))
+//	root.ObjectEachSorted(func(key string, value *Node) {
+//		fmt.Println(key, value)
+//	})
+//
+//	result: a 1, b 2
+func (n *Node) ObjectEachSorted(callback func(key string, value *Node)) {
+	if n == nil || !n.IsObject() {
+		return
+	}
+
+	for _, key := range n.sortedKeys() {
+		callback(key, n.next[key])
+	}
+}
+
+// ObjectEachErr executes the callback for each key-value pair in the JSON object,
+// in ascending order of the keys, and stops at the first error returned by the
+// callback and returns it. It returns an error if the current node is not an object.
+//
+// Usage:
+//
+//	err := root.ObjectEachErr(func(key string, value *Node) error {
+//		if value.IsNull() {
+//			return fmt.Errorf("field %q is required", key)
+//		}
+//
+//		return nil
+//	})
+func (n *Node) ObjectEachErr(callback func(key string, node *Node) error) error {
+	if n == nil {
+		return ErrNilNode
+	}
+
+	if !n.IsObject() {
+		return &TypeError{Expected: Object, Got: n.nodeType}
+	}
+
+	for _, key := range n.sortedKeys() {
+		if err := callback(key, n.next[key]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Entry is a key-value pair of an object node.
+type Entry struct {
+	Key   string
+	Value *Node
+}
+
+// Entries returns the key-value pairs of the current object node sorted by key.
+//
+// Unlike ObjectEach, the order is deterministic. It returns nil if the current node
+// is not object type.
+//
+// Usage:
+//
+//	root := Must(Unmarshal([]byte(`{"b": 2, "a": 1}`)))
+//	for _, entry := range root.Entries() {
+//		println(entry.Key, entry.Value)
+//	}
+//
+//	result: a 1, b 2
+func (n *Node) Entries() []Entry {
+	if n == nil || !n.IsObject() {
+		return nil
+	}
+
+	keys := n.sortedKeys()
+	entries := make([]Entry, len(keys))
+	for i, key := range keys {
+		entries[i] = Entry{Key: key, Value: n.next[key]}
+	}
+
+	return entries
+}
+
+// MapLeaves calls fn for each scalar node in the subtree of the current node,
+// in document order, and stops at the first error returned by fn.
+//
+// The callback can modify the leaf in place with the Set* methods. If it replaces
+// a leaf with a container, the new children are not visited.
+//
+// Usage:
+//
+//	root := Must(Unmarshal([]byte(`{"a": " x ", "b": [" y "]}`)))
+//	err := root.MapLeaves(func(leaf *Node) error {
+//		if !leaf.IsString() {
+//			return nil
+//		}
+//
+//		return leaf.SetString(strings.TrimSpace(leaf.MustString()))
+//	})
+//
+//	result: {"a":"x","b":["y"]}
+func (n *Node) MapLeaves(fn func(leaf *Node) error) error {
+	if n == nil {
+		return ErrNilNode
+	}
+
+	if !n.isContainer() {
+		return fn(n)
+	}
+
+	for _, child := range n.children() {
+		if err := child.MapLeaves(fn); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// WalkPath calls fn for the current node and each of its descendants in document order,
+// along with the path of the node in the notation of Node.Path. If fn returns false,
+// the children of that node are not visited.
+//
+// The paths are built incrementally from the path of the parent, instead of calling
+// Path for each node which walks up to the root every time.
+//
+// Usage:
+//
+//	root := Must(Unmarshal([]byte(`{"a": [1, {"b": null}]}`)))
+//	root.WalkPath(func(path string, node *Node) bool {
+//		println(path)
+//		return true
+//	})
+//
+//	result: $, $['a'], $['a'][0], $['a'][1], $['a'][1]['b']
+func (n *Node) WalkPath(fn func(path string, node *Node) bool) {
+	if n == nil {
+		return
+	}
+
+	n.walkPath(n.Path(), fn)
+}
+
+func (n *Node) walkPath(path string, fn func(path string, node *Node) bool) {
+	if !fn(path, n) {
+		return
+	}
+
+	for _, child := range n.children() {
+		// the elements built by ArrayNode may keep a key, so the parent decides the segment.
+		if n.IsArray() {
+			child.walkPath(path+"["+strconv.Itoa(child.Index())+"]", fn)
+		} else {
+			child.walkPath(path+pathKey(child.Key()), fn)
+		}
+	}
+}
+
+// ForEachLeaf calls fn for each scalar node in the subtree of the current node, in document
+// order, with its JSON pointer (RFC 6901) relative to the current node and its native Go
+// value as described in ToSlice. It stops at the first error returned by fn.
+// Empty arrays and objects have no leaves and are not visited.
+//
+// Usage:
+//
+//	root := Must(Unmarshal([]byte(`{"a": [1, "x"], "b/c": null}`)))
+//	err := root.ForEachLeaf(func(pointer string, value interface{}) error {
+//		fmt.Println(pointer, value)
+//		return nil
+//	})
+//
+//	result: /a/0 1, /a/1 x, /b~1c <nil>
+func (n *Node) ForEachLeaf(fn func(pointer string, value interface{}) error) error {
+	if n == nil {
+		return ErrNilNode
+	}
+
+	return n.forEachLeaf("", fn)
+}
+
+func (n *Node) forEachLeaf(pointer string, fn func(pointer string, value interface{}) error) error {
+	if !n.isContainer() {
+		value, err := n.native()
+		if err != nil {
+			return err
+		}
+
+		return fn(pointer, value)
+	}
+
+	for _, child := range n.children() {
+		// the elements built by ArrayNode may keep a key, so the parent decides the token.
+		token := escapePointerToken(child.Key())
+		if n.IsArray() {
+			token = strconv.Itoa(child.Index())
+		}
+
+		if err := child.forEachLeaf(pointer+"/"+token, fn); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// FindN returns the nodes matching pred in the subtree of the current node, including itself,
+// in document order. The walk stops as soon as limit nodes are found, so the rest of
+// the tree is not visited. A limit of zero or less means no limit.
+//
+// Usage:
+//
+//	root := Must(Unmarshal([]byte(`[{"id": 1}, 2, {"id": 3}, {"id": 4}]`)))
+//	objects := root.FindN(func(node *Node) bool { return node.IsObject() }, 2)
+//
+//	result: [{"id": 1}, {"id": 3}]
+func (n *Node) FindN(pred func(*Node) bool, limit int) []*Node {
+	var found []*Node
+	if n == nil {
+		return found
+	}
+
+	n.findN(pred, limit, &found)
+	return found
+}
+
+// findN appends the matching nodes to found and reports whether the walk must go on.
+func (n *Node) findN(pred func(*Node) bool, limit int, found *[]*Node) bool {
+	if pred(n) {
+		*found = append(*found, n)
+		if limit > 0 && len(*found) >= limit {
+			return false
+		}
+	}
+
+	for _, child := range n.children() {
+		if !child.findN(pred, limit, found) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// CountMatches returns the number of nodes matching pred in the subtree of the current node,
+// including itself. Unlike len(FindN(pred, 0)), the matching nodes are not collected.
+// It returns 0 for a nil node.
+//
+// Usage:
+//
+//	root := Must(Unmarshal([]byte(`[{"id": 1}, {"name": "foo"}, {"id": 3}]`)))
+//	count := root.CountMatches(func(node *Node) bool { return node.HasKey("id") })
+//
+//	result: 2
+func (n *Node) CountMatches(pred func(*Node) bool) int {
+	if n == nil {
+		return 0
+	}
+
+	count := 0
+	if pred(n) {
+		count++
+	}
+
+	for _, child := range n.children() {
+		count += child.CountMatches(pred)
+	}
+
+	return count
+}
+
+// FindObjectsWithKeys returns the object nodes in the subtree of the current node,
+// including itself, that have all the given keys, in document order. Unlike FindN,
+// only the objects are checked, and each key is looked up once per object.
+//
+// Usage:
+//
+//	root := Must(Unmarshal([]byte(`{"store": {"book": [{"title": "a", "price": 1}, {"title": "b"}]}}`)))
+//	books := root.FindObjectsWithKeys("title", "price")
+//
+//	result: [{"title": "a", "price": 1}]
+func (n *Node) FindObjectsWithKeys(keys ...string) []*Node {
+	var found []*Node
+	if n == nil {
+		return found
+	}
+
+	n.findObjectsWithKeys(keys, &found)
+	return found
+}
+
+func (n *Node) findObjectsWithKeys(keys []string, found *[]*Node) {
+	if !n.isContainer() {
+		return
+	}
+
+	if n.IsObject() && n.hasKeys(keys) {
+		*found = append(*found, n)
+	}
+
+	for _, child := range n.children() {
+		child.findObjectsWithKeys(keys, found)
+	}
+}
+
+// hasKeys reports whether the current object node has all the given keys.
+func (n *Node) hasKeys(keys []string) bool {
+	for _, key := range keys {
+		if _, ok := n.next[key]; !ok {
+			return false
+		}
+	}
+
+	return true
+}
+
+// EnforceLimits checks the subtree of the current node against the given limits,
+// like the decoder does with its nesting limit, for trees built or modified with the node APIs.
+//
+// The depth is the number of nested arrays and objects, so a scalar has depth 0 and `[[1]]` has
+// depth 2. The node count includes the current node and all of its descendants.
+// A limit of zero or less is not checked. The walk stops at the first node over a limit,
+// and the error names its path.
+//
+// Usage:
+//
+//	root := Must(Unmarshal([]byte(`{"a": [[1]]}`)))
+//	if err := root.EnforceLimits(2, 100); err != nil {
+//		println(err.Error())
+//	}
+//
+//	result: depth exceeds the limit of 2 at $['a'][0]
+func (n *Node) EnforceLimits(maxDepth, maxNodes int) error {
+	if n == nil {
+		return ErrNilNode
 	}
 
-	if !n.IsObject() {
-		return nil, errors.New("node is not object")
+	count := 0
+	return n.enforceLimits(0, maxDepth, maxNodes, &count)
+}
+
+func (n *Node) enforceLimits(depth, maxDepth, maxNodes int, count *int) error {
+	*count++
+	if maxNodes > 0 && *count > maxNodes {
+		return fmt.Errorf("node count exceeds the limit of %d at %s", maxNodes, n.Path())
 	}
 
-	val, err := n.Value()
+	if !n.isContainer() {
+		return nil
+	}
+
+	depth++
+	if maxDepth > 0 && depth > maxDepth {
+		return fmt.Errorf("depth exceeds the limit of %d at %s", maxDepth, n.Path())
+	}
+
+	for _, child := range n.children() {
+		if err := child.enforceLimits(depth, maxDepth, maxNodes, count); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ToMap converts the current object node into a native Go map.
+// The member values are converted recursively, see ToSlice for the value types.
+//
+// Usage:
+//
+//	root := Must(Unmarshal([]byte(`{"name": "foo", "tags": ["a", "b"]}`)))
+//	m, err := root.ToMap()
+//	if err != nil {
+//		t.Errorf("ToMap returns error: %v", err)
+//	}
+//
+//	result: map[string]interface{}{"name": "foo", "tags": []interface{}{"a", "b"}}
+func (n *Node) ToMap() (map[string]interface{}, error) {
+	obj, err := n.GetObject()
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(map[string]interface{}, len(obj))
+	for key, child := range obj {
+		if result[key], err = child.native(); err != nil {
+			return nil, err
+		}
+	}
+
+	return result, nil
+}
+
+// ToSlice converts the current array node into a native Go slice.
+// The elements are converted recursively as follows:
+//
+//   - Null: nil
+//   - Number: float64
+//   - String: string
+//   - Boolean: bool
+//   - Array: []interface{}
+//   - Object: map[string]interface{}
+//
+// Usage:
+//
+//	root := Must(Unmarshal([]byte(`[1, "foo", null]`)))
+//	s, err := root.ToSlice()
+//	if err != nil {
+//		t.Errorf("ToSlice returns error: %v", err)
+//	}
+//
+//	result: []interface{}{1.0, "foo", nil}
+func (n *Node) ToSlice() ([]interface{}, error) {
+	arr, err := n.GetArray()
 	if err != nil {
 		return nil, err
 	}
 
-	v, ok := val.(map[string]*Node)
-	if !ok {
-		return nil, errors.New("node is not object")
+	result := make([]interface{}, len(arr))
+	for i, elem := range arr {
+		if result[i], err = elem.native(); err != nil {
+			return nil, err
+		}
+	}
+
+	return result, nil
+}
+
+// Pair is a member of an object converted by OrderedPairs.
+type Pair struct {
+	Key   string
+	Value interface{}
+}
+
+// OrderedPairs converts the current object node into its members in document order,
+// for the encoders that preserve the key order, such as YAML or TOML encoders.
+//
+// The member values are converted recursively like ToSlice, except that the objects are
+// converted to []Pair instead of maps, so the order is kept at every depth. The members
+// parsed from the source come first in their original order, followed by the added
+// ones sorted by key.
+//
+// Usage:
+//
+//	root := Must(Unmarshal([]byte(`{"name": "foo", "meta": {"z": 1, "a": 2}, "tags": ["b", "a"]}`)))
+//	pairs, err := root.OrderedPairs()
+//	if err != nil {
+//		t.Errorf("OrderedPairs returns error: %v", err)
+//	}
+//
+//	result: []Pair{{"name", "foo"}, {"meta", []Pair{{"z", 1.0}, {"a", 2.0}}}, {"tags", []interface{}{"b", "a"}}}
+func (n *Node) OrderedPairs() ([]Pair, error) {
+	if n == nil {
+		return nil, ErrNilNode
+	}
+
+	if !n.IsObject() {
+		return nil, &TypeError{Expected: Object, Got: n.nodeType}
+	}
+
+	children := n.children()
+	pairs := make([]Pair, len(children))
+	for i, child := range children {
+		value, err := child.ordered()
+		if err != nil {
+			return nil, err
+		}
+
+		pairs[i] = Pair{Key: *child.key, Value: value}
+	}
+
+	return pairs, nil
+}
+
+// ordered converts the node like native, with the objects converted by OrderedPairs.
+func (n *Node) ordered() (interface{}, error) {
+	switch n.nodeType {
+	case Array:
+		children := n.children()
+		elems := make([]interface{}, len(children))
+		for i, child := range children {
+			value, err := child.ordered()
+			if err != nil {
+				return nil, err
+			}
+
+			elems[i] = value
+		}
+
+		return elems, nil
+	case Object:
+		return n.OrderedPairs()
+	default:
+		return n.native()
+	}
+}
+
+// native converts the current node into the native Go value described in ToSlice.
+func (n *Node) native() (interface{}, error) {
+	if n == nil {
+		return nil, ErrNilNode
+	}
+
+	switch n.nodeType {
+	case Null:
+		return nil, nil
+	case Number, Float:
+		return n.GetNumeric()
+	case String:
+		return n.GetString()
+	case Boolean:
+		return n.GetBool()
+	case Array:
+		return n.ToSlice()
+	case Object:
+		return n.ToMap()
+	default:
+		return nil, fmt.Errorf("can't convert %s node", n.nodeType.String())
+	}
+}
+
+// String converts the node to a string representation.
+func (n *Node) String() string {
+	if n == nil {
+		return ""
+	}
+
+	if n.ready() && !n.modified {
+		return string(n.source())
+	}
+
+	val, err := Marshal(n)
+	if err != nil {
+		return "error: " + err.Error()
+	}
+
+	return string(val)
+}
+
+// GoString returns the debug representation of the node's internal state.
+// It is used by the `%#v` verb of the fmt package.
+//
+// Usage:
+//
+//	root := Must(Unmarshal([]byte(`{"key": "value"}`)))
+//	fmt.Printf("%#v", root.MustKey("key"))
+//
+//	result: Node{key: "key", nodeType: string, index: nil, borders: [8 15], modified: false}
+func (n *Node) GoString() string {
+	if n == nil {
+		return "Node(nil)"
+	}
+
+	key := "nil"
+	if n.key != nil {
+		key = strconv.Quote(*n.key)
+	}
+
+	index := "nil"
+	if n.index != nil {
+		index = strconv.Itoa(*n.index)
+	}
+
+	return fmt.Sprintf(
+		"Node{key: %s, nodeType: %s, index: %s, borders: %v, modified: %t}",
+		key, n.nodeType.String(), index, n.borders, n.modified,
+	)
+}
+
+// Format implements fmt.Formatter to control how the node is printed
+// by the fmt package (and the loggers built on it).
+//
+// The supported verbs are:
+//
+//	%v, %s  compact JSON
+//	%+v     debug form of the node, same as GoString
+//	%#v     debug form of the node, same as GoString
+//	%q      compact JSON as a double-quoted JSON string
+//
+// For %v and %s, the width sets the number of spaces and the precision the number of tabs
+// written per nesting level, as IndentWith does. If both are given, the width is used.
+//
+// Usage:
+//
+//	root := Must(Unmarshal([]byte(`{"key": [1, 2]}`)))
+//	fmt.Printf("%v", root)  // {"key":[1,2]}
+//	fmt.Printf("%2v", root) // indented with "  "
+//	fmt.Printf("%+v", root) // Node{key: nil, nodeType: object, ...}
+func (n *Node) Format(f fmt.State, verb rune) {
+	switch verb {
+	case 'v':
+		if f.Flag('+') || f.Flag('#') {
+			io.WriteString(f, n.GoString())
+			return
+		}
+
+		fallthrough
+
+	case 's':
+		io.WriteString(f, n.formatJSON(f))
+
+	case 'q':
+		f.Write(Quote(n.formatJSON(f), doubleQuote))
+
+	default:
+		fmt.Fprintf(f, "%%!%c(*json.Node=%s)", verb, n.String())
+	}
+}
+
+// formatJSON returns the compact JSON of the node, indented by the width or precision of f.
+func (n *Node) formatJSON(f fmt.State) string {
+	if n == nil {
+		return ""
+	}
+
+	data, err := Marshal(n)
+	if err != nil {
+		return "error: " + err.Error()
+	}
+
+	data = compact(data)
+
+	indent := ""
+	if width, ok := f.Width(); ok {
+		indent = strings.Repeat(" ", width)
+	} else if prec, ok := f.Precision(); ok {
+		indent = strings.Repeat("\t", prec)
+	}
+
+	if indent != "" {
+		if indented, err := IndentWith(data, indent); err == nil {
+			data = indented
+		}
+	}
+
+	return string(data)
+}
+
+// Compare compares the value of the current node with the given node.
+//
+// It returns -1 if the current node is less than the other, 0 if they are equal,
+// and 1 if the current node is greater than the other.
+//
+// Numbers are compared numerically whether they are integers or floats, strings
+// lexicographically, and booleans are ordered as false < true. Two null nodes are always equal.
+// Comparing nodes of different types or containers (array, object) returns an error.
+//
+// Usage:
+//
+//	sort.Slice(nodes, func(i, j int) bool {
+//		c, _ := nodes[i].Compare(nodes[j])
+//		return c < 0
+//	})
+func (n *Node) Compare(other *Node) (int, error) {
+	if n == nil || other == nil {
+		return 0, ErrNilNode
+	}
+
+	// numbers are always comparable with each other regardless of
+	// whether they are classified as integer or float.
+	if isNumericType(n.nodeType) && isNumericType(other.nodeType) {
+		return compareNumeric(n, other)
+	}
+
+	if n.nodeType != other.nodeType {
+		return 0, fmt.Errorf("can't compare %s with %s", n.nodeType.String(), other.nodeType.String())
+	}
+
+	switch n.nodeType {
+	case Null:
+		return 0, nil
+
+	case String:
+		a, err := n.GetString()
+		if err != nil {
+			return 0, err
+		}
+
+		b, err := other.GetString()
+		if err != nil {
+			return 0, err
+		}
+
+		return strings.Compare(a, b), nil
+
+	case Boolean:
+		a, err := n.GetBool()
+		if err != nil {
+			return 0, err
+		}
+
+		b, err := other.GetBool()
+		if err != nil {
+			return 0, err
+		}
+
+		if a == b {
+			return 0, nil
+		} else if b {
+			return -1, nil
+		}
+
+		return 1, nil
+
+	default:
+		return 0, fmt.Errorf("can't compare %s values", n.nodeType.String())
+	}
+}
+
+// compareNumeric compares the numeric values of the given nodes.
+func compareNumeric(n, other *Node) (int, error) {
+	a, err := n.GetNumeric()
+	if err != nil {
+		return 0, err
+	}
+
+	b, err := other.GetNumeric()
+	if err != nil {
+		return 0, err
+	}
+
+	return compareOrdered(a, b), nil
+}
+
+func isNumericType(t ValueType) bool {
+	return t == Number || t == Float
+}
+
+func compareOrdered(a, b float64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// EqualScalar reports whether the current node and the other are scalars holding equal values.
+// It returns false if either node is nil, an array or an object.
+//
+// The values are compared like Compare, so a number classified as integer is equal to the same
+// number classified as float. Unlike EqualsExcept, there is no container logic, and two
+// unmodified nodes with identical literals are equal without decoding their values.
+//
+// Usage:
+//
+//	root := Must(Unmarshal([]byte(`["a", "\u0061", 1, 1.0]`)))
+//	root.MustIndex(0).EqualScalar(root.MustIndex(1))
+//	root.MustIndex(2).EqualScalar(root.MustIndex(3))
+//
+//	result: true, true
+func (n *Node) EqualScalar(other *Node) bool {
+	if n == nil || other == nil || n.isContainer() || other.isContainer() {
+		return false
+	}
+
+	if n.nodeType == other.nodeType {
+		if a, b := n.source(), other.source(); a != nil && b != nil && bytes.Equal(a, b) {
+			return true
+		}
+	}
+
+	res, err := n.Compare(other)
+	return err == nil && res == 0
+}
+
+// EqualsExcept reports whether the current node and the other hold structurally equal values,
+// ignoring the object members whose key is in ignoreKeys.
+//
+// The keys are ignored at all depths, and an ignored member is skipped on both sides,
+// so it may have any value or be missing from either node. Otherwise the nodes are
+// compared like Compare for scalars, element-wise for arrays and regardless of the key
+// order for objects.
+//
+// Usage:
+//
+//	a := Must(Unmarshal([]byte(`{"id": 1, "items": [{"id": 2, "name": "foo"}]}`)))
+//	b := Must(Unmarshal([]byte(`{"id": 3, "items": [{"name": "foo"}]}`)))
+//	a.EqualsExcept(b, []string{"id"})
+//
+//	result: true
+func (n *Node) EqualsExcept(other *Node, ignoreKeys []string) bool {
+	ignore := make(map[string]bool, len(ignoreKeys))
+	for _, key := range ignoreKeys {
+		ignore[key] = true
+	}
+
+	return equalsExcept(n, other, ignore)
+}
+
+// EqualJSON parses the JSON-encoded data and reports whether it holds a value structurally
+// equal to the current node, compared like EqualsExcept with no ignored keys. The order of
+// object members and the formatting of the data don't matter.
+//
+// A parse error is returned as an error, so an invalid document is told apart from an unequal one.
+//
+// Usage:
+//
+//	root := Must(Unmarshal([]byte(`{"a": 1, "b": [true]}`)))
+//	ok, err := root.EqualJSON([]byte(`{"b":[true],"a":1.0}`))
+//	if err != nil {
+//		t.Fatalf("EqualJSON returns error: %v", err)
+//	}
+//
+//	result: ok == true
+func (n *Node) EqualJSON(data []byte) (bool, error) {
+	if n == nil {
+		return false, ErrNilNode
+	}
+
+	other, err := Unmarshal(data)
+	if err != nil {
+		return false, err
 	}
 
-	return v, nil
+	return equals(n, other), nil
 }
 
-// MustObject returns the object value if current node is object type.
+// equals reports whether the two nodes hold structurally equal values.
 //
-// It panics if the current node is not object type.
-func (n *Node) MustObject() map[string]*Node {
-	v, err := n.GetObject()
-	if err != nil {
-		panic(err)
-	}
-
-	return v
+// Numbers are compared by value, arrays element-wise in order and
+// objects by their key sets regardless of the key order.
+func equals(a, b *Node) bool {
+	return equalsExcept(a, b, nil)
 }
 
-// AppendObject appends the given key and value to the current object node.
-//
-// If the current node is not object type, it returns an error.
-func (n *Node) AppendObject(key string, value *Node) error {
-	if !n.IsObject() {
-		return errors.New("can't append value to non-object node")
+// equalsExcept is equals skipping the object members whose key is in ignore.
+func equalsExcept(a, b *Node, ignore map[string]bool) bool {
+	if a == nil || b == nil {
+		return a == b
 	}
 
-	if err := n.append(&key, value); err != nil {
-		return err
+	if a.nodeType != b.nodeType && !(isNumericType(a.nodeType) && isNumericType(b.nodeType)) {
+		return false
 	}
 
-	n.mark()
-	return nil
+	// the nodes with different fingerprints can't be equal, unless members are ignored.
+	if len(ignore) == 0 && a.hashed && b.hashed && a.hash != b.hash {
+		return false
+	}
+
+	switch a.nodeType {
+	case Null:
+		return true
+
+	case Array:
+		if len(a.next) != len(b.next) {
+			return false
+		}
+
+		for key, child := range a.next {
+			other, ok := b.next[key]
+			if !ok || !equalsExcept(child, other, ignore) {
+				return false
+			}
+		}
+
+		return true
+
+	case Object:
+		size := 0
+		for key, child := range a.next {
+			if ignore[key] {
+				continue
+			}
+
+			other, ok := b.next[key]
+			if !ok || !equalsExcept(child, other, ignore) {
+				return false
+			}
+
+			size++
+		}
+
+		// b must not have other members than the ones compared above.
+		for key := range b.next {
+			if !ignore[key] {
+				size--
+			}
+		}
+
+		return size == 0
+
+	default:
+		res, err := a.Compare(b)
+		return err == nil && res == 0
+	}
 }
 
-// ObjectEach executes the callback for each key-value pair in the JSON object.
+// Hash returns a fingerprint of the value of the current node. Structurally equal nodes,
+// in the sense of EqualsExcept with no ignored keys, have the same hash: the order of
+// object members doesn't matter, and numbers are hashed by value, so 1.0 and 1 collide.
+// Different values usually have different hashes, but it is not guaranteed.
+//
+// The hash is computed lazily and cached on each node of the subtree, and the cache is
+// invalidated whenever the node or one of its descendants is modified. Once two nodes
+// are hashed, comparing them with EqualsExcept or AppendUnique returns early if their
+// hashes differ, which speeds up repeated comparisons of large unchanged trees.
 //
 // Usage:
 //
-//	jsonObjectNode.ObjectEach(func(key string, valueNode *Node) {
-//	    fmt.Println(key, valueNode)
-//	})
-func (n *Node) ObjectEach(callback func(key string, value *Node)) {
-	if n == nil || !n.IsObject() {
-		return
+//	a := Must(Unmarshal([]byte(`{"a": 1, "b": [true]}`)))
+//	b := Must(Unmarshal([]byte(`{"b": [true], "a": 1.0}`)))
+//
+//	result: a.Hash() == b.Hash()
+func (n *Node) Hash() uint64 {
+	if n == nil {
+		return 0
 	}
 
-	for key, child := range n.next {
-		callback(key, child)
+	if !n.hashed {
+		n.hash = n.computeHash()
+		n.hashed = true
 	}
+
+	return n.hash
 }
 
-// String converts the node to a string representation.
-func (n *Node) String() string {
-	if n == nil {
-		return ""
+// FNV-1a parameters used to compute the hashes.
+const (
+	hashOffset uint64 = 14695981039346656037
+	hashPrime  uint64 = 1099511628211
+)
+
+func (n *Node) computeHash() uint64 {
+	// the float classification only affects the formatting, so it's hashed as a number.
+	typ := n.nodeType
+	if typ == Float {
+		typ = Number
 	}
 
-	if n.ready() && !n.modified {
-		return string(n.source())
+	h := hashMix(hashOffset, uint64(typ))
+
+	switch n.nodeType {
+	case Boolean:
+		if v, _ := n.GetBool(); v {
+			h = hashMix(h, 1)
+		}
+
+	case Number, Float:
+		f, _ := n.GetNumeric()
+		switch {
+		case f == 0:
+			f = 0 // -0 equals 0
+		case math.IsNaN(f):
+			f = math.NaN()
+		}
+
+		h = hashMix(h, math.Float64bits(f))
+
+	case String:
+		s, _ := n.GetString()
+		h = hashMix(h, hashString(s))
+
+	case Array:
+		for i := 0; i < len(n.next); i++ {
+			h = hashMix(h, n.next[strconv.Itoa(i)].Hash())
+		}
+
+	case Object:
+		// the sum of the member hashes doesn't depend on the member order.
+		var sum uint64
+		for key, child := range n.next {
+			sum += hashMix(hashString(key), child.Hash())
+		}
+
+		h = hashMix(h, sum)
 	}
 
-	val, err := Marshal(n)
-	if err != nil {
-		return "error: " + err.Error()
+	return h
+}
+
+func hashMix(h, v uint64) uint64 {
+	for i := 0; i < 8; i++ {
+		h ^= v & 0xff
+		h *= hashPrime
+		v >>= 8
 	}
 
-	return string(val)
+	return h
+}
+
+func hashString(s string) uint64 {
+	h := hashOffset
+	for i := 0; i < len(s); i++ {
+		h ^= uint64(s[i])
+		h *= hashPrime
+	}
+
+	return h
 }
 
 // Path builds the path of the current node.
@@ -1173,6 +4088,9 @@ func (n *Node) String() string {
 //	{ "key": { "sub": [ "val1", "val2" ] }}
 //
 // The path of "val2" is: $.key.sub[1]
+//
+// Keys are written in single quotes, with `'` and `\` escaped by a backslash,
+// so the path can always be parsed back by ParsePathTyped and Collect.
 func (n *Node) Path() string {
 	if n == nil {
 		return ""
@@ -1186,7 +4104,7 @@ func (n *Node) Path() string {
 		sb.WriteString(n.prev.Path())
 
 		if n.key != nil {
-			sb.WriteString("['" + n.Key() + "']")
+			sb.WriteString(pathKey(n.Key()))
 		} else {
 			sb.WriteString("[" + strconv.Itoa(n.Index()) + "]")
 		}
@@ -1195,12 +4113,273 @@ func (n *Node) Path() string {
 	return sb.String()
 }
 
+// pathKey returns the bracket segment of the key in the notation of Path.
+func pathKey(key string) string {
+	if !strings.ContainsAny(key, `'\`) {
+		return "['" + key + "']"
+	}
+
+	var sb strings.Builder
+	sb.WriteString("['")
+	for i := 0; i < len(key); i++ {
+		if key[i] == '\'' || key[i] == '\\' {
+			sb.WriteByte('\\')
+		}
+
+		sb.WriteByte(key[i])
+	}
+	sb.WriteString("']")
+
+	return sb.String()
+}
+
+// PathsUnique returns the distinct paths of the given nodes, preserving the first-seen order.
+//
+// This is useful for reporting the matched locations of a result set
+// where the same node may appear multiple times. nil nodes are ignored.
+func PathsUnique(nodes []*Node) []string {
+	seen := make(map[string]bool, len(nodes))
+	paths := make([]string, 0, len(nodes))
+
+	for _, node := range nodes {
+		if node == nil {
+			continue
+		}
+
+		path := node.Path()
+		if seen[path] {
+			continue
+		}
+
+		seen[path] = true
+		paths = append(paths, path)
+	}
+
+	return paths
+}
+
+// SortNodes sorts the given nodes in place with the less function, keeping the original order of equal nodes.
+//
+// Unlike SortArrayByPath, which reorders the elements of an array node in place, it doesn't
+// touch the tree the nodes belong to, only the slice, which makes it suitable for an already
+// extracted result set. Note that the slice returned
+// by GetArray is cached by the array node, so copy it before sorting.
+//
+// Usage:
+//
+//	SortNodes(nodes, func(a, b *Node) bool {
+//		return a.MustKey("price").MustNumeric() < b.MustKey("price").MustNumeric()
+//	})
+func SortNodes(nodes []*Node, less func(a, b *Node) bool) {
+	sort.SliceStable(nodes, func(i, j int) bool {
+		return less(nodes[i], nodes[j])
+	})
+}
+
+// SortOptions holds the options of SortArrayByPathWithOptions.
+type SortOptions struct {
+	// Numeric compares the values as numbers. Otherwise, they are compared as strings.
+	Numeric bool
+
+	// Descending sorts the elements from the greatest value to the smallest one.
+	Descending bool
+
+	// MissingLast moves the elements without a value at the path to the end, in their
+	// original order. Otherwise, such an element is an error.
+	MissingLast bool
+}
+
+// SortArrayByPath sorts the elements of the current array node by the value at the given
+// path relative to each element, e.g. "price" or "meta.created". The values are compared
+// as numbers if numeric is true, and as strings otherwise. It is equivalent to
+// SortArrayByPathWithOptions with the default options.
+//
+// Usage:
+//
+//	root := Must(Unmarshal([]byte(`[{"price": 12.99}, {"price": 8.95}, {"price": 22.99}]`)))
+//	if err := root.SortArrayByPath("price", true); err != nil {
+//		t.Errorf("SortArrayByPath returns error: %v", err)
+//	}
+//
+//	result: [{"price": 8.95}, {"price": 12.99}, {"price": 22.99}]
+func (n *Node) SortArrayByPath(path string, numeric bool) error {
+	return n.SortArrayByPathWithOptions(path, SortOptions{Numeric: numeric})
+}
+
+// SortArrayByPathWithOptions sorts the elements of the current array node by the value
+// at the given path relative to each element. The sort is stable, so the elements with
+// equal values keep their order.
+//
+// The path is a JSON path relative to each element, where the leading `$` may be omitted
+// (e.g. "meta.created", "[0]" or "$['a b']"), and must match exactly one node in each
+// element. The matched nodes must be numbers if opts.Numeric is set, and strings otherwise.
+// The array is left unchanged on error.
+func (n *Node) SortArrayByPathWithOptions(path string, opts SortOptions) error {
+	if n == nil {
+		return ErrNilNode
+	}
+
+	if !n.IsArray() {
+		return &TypeError{Expected: Array, Got: n.nodeType}
+	}
+
+	if err := n.mutable(); err != nil {
+		return err
+	}
+
+	switch {
+	case strings.HasPrefix(path, "$"), strings.HasPrefix(path, "@"):
+	case strings.HasPrefix(path, "["):
+		path = "$" + path
+	default:
+		path = "$." + path
+	}
+
+	type sortKey struct {
+		elem    *Node
+		number  float64
+		text    string
+		missing bool
+	}
+
+	elems := n.children()
+	keys := make([]sortKey, len(elems))
+	for i, elem := range elems {
+		keys[i].elem = elem
+
+		nodes, err := elem.Collect(path)
+		if err != nil {
+			return err
+		}
+
+		if len(nodes) != 1 {
+			if len(nodes) == 0 && opts.MissingLast {
+				keys[i].missing = true
+				continue
+			}
+
+			return fmt.Errorf("%s: path %s matches %d nodes, want 1", elem.Path(), path, len(nodes))
+		}
+
+		if opts.Numeric {
+			keys[i].number, err = nodes[0].GetNumeric()
+		} else {
+			keys[i].text, err = nodes[0].GetString()
+		}
+
+		if err != nil {
+			return fmt.Errorf("%s: %w", nodes[0].Path(), err)
+		}
+	}
+
+	sort.SliceStable(keys, func(i, j int) bool {
+		a, b := keys[i], keys[j]
+		if a.missing || b.missing {
+			return !a.missing
+		}
+
+		if opts.Descending {
+			a, b = b, a
+		}
+
+		if opts.Numeric {
+			return a.number < b.number
+		}
+
+		return a.text < b.text
+	})
+
+	for i, key := range keys {
+		idx := i
+		key.elem.index = &idx
+		n.next[strconv.Itoa(i)] = key.elem
+	}
+
+	n.mark()
+	return nil
+}
+
+// MinNode returns the smallest of the given nodes according to the less function.
+// If several nodes are the smallest, the first one is returned. It returns nil for an empty slice.
+//
+// Usage:
+//
+//	cheapest := MinNode(nodes, func(a, b *Node) bool {
+//		return a.MustKey("price").MustNumeric() < b.MustKey("price").MustNumeric()
+//	})
+func MinNode(nodes []*Node, less func(a, b *Node) bool) *Node {
+	var result *Node
+	for i, node := range nodes {
+		if i == 0 || less(node, result) {
+			result = node
+		}
+	}
+
+	return result
+}
+
+// MaxNode returns the largest of the given nodes according to the less function.
+// If several nodes are the largest, the first one is returned. It returns nil for an empty slice.
+//
+// check the MinNode function for detailed usage.
+func MaxNode(nodes []*Node, less func(a, b *Node) bool) *Node {
+	var result *Node
+	for i, node := range nodes {
+		if i == 0 || less(result, node) {
+			result = node
+		}
+	}
+
+	return result
+}
+
+// PathSegments returns the path of the current node as ordered segments from the root.
+//
+// Object keys are returned as-is, and array indices are returned as decimal strings.
+// The root node returns an empty slice.
+//
+// For example:
+//
+//	{ "key": { "sub": [ "val1", "val2" ] }}
+//
+// The path segments of "val2" are: ["key", "sub", "1"]
+func (n *Node) PathSegments() []string {
+	if n == nil {
+		return nil
+	}
+
+	var segments []string
+	for curr := n; curr.prev != nil; curr = curr.prev {
+		// the elements built by ArrayNode may keep a key, so the parent decides the segment.
+		if curr.prev.IsArray() {
+			segments = append(segments, strconv.Itoa(curr.Index()))
+		} else {
+			segments = append(segments, curr.Key())
+		}
+	}
+
+	// reverse the order to start from the root
+	for i, j := 0, len(segments)-1; i < j; i, j = i+1, j-1 {
+		segments[i], segments[j] = segments[j], segments[i]
+	}
+
+	if segments == nil {
+		segments = []string{}
+	}
+
+	return segments
+}
+
 // update updates the current node value with the given type and value.
 func (n *Node) update(vt ValueType, val interface{}) error {
 	if err := n.validate(vt, val); err != nil {
 		return err
 	}
 
+	if err := n.mutable(); err != nil {
+		return err
+	}
+
 	n.mark()
 	n.clear()
 
@@ -1241,15 +4420,33 @@ func (n *Node) update(vt ValueType, val interface{}) error {
 	return nil
 }
 
-// mark marks the current node as modified.
+// mark marks the current node as modified, and invalidates the cached hash
+// of the node and its ancestors, and the loaded value of a container node.
 func (n *Node) mark() {
+	// the loaded value of a container holds its children, which may have changed.
+	if n != nil && n.isContainer() {
+		n.value = nil
+	}
+
 	node := n
-	for node != nil && !node.modified {
+	// a hashed node has hashed descendants, so the walk can stop at the first node
+	// that is already modified and not hashed.
+	for node != nil && (!node.modified || node.hashed) {
 		node.modified = true
+		node.hashed = false
 		node = node.prev
 	}
 }
 
+// mutable returns ErrImmutableNode if the current node is frozen.
+func (n *Node) mutable() error {
+	if n.frozen {
+		return ErrImmutableNode
+	}
+
+	return nil
+}
+
 // clear clears the current node's value
 func (n *Node) clear() {
 	n.data = nil
@@ -1265,7 +4462,7 @@ func (n *Node) clear() {
 // validate checks the current node value is matching the given type.
 func (n *Node) validate(t ValueType, v interface{}) error {
 	if n == nil {
-		return errors.New("node is nil")
+		return ErrNilNode
 	}
 
 	switch t {
@@ -1275,7 +4472,7 @@ func (n *Node) validate(t ValueType, v interface{}) error {
 		}
 
 	// TODO: support uint256, int256 type later.
-	case Number:
+	case Number, Float:
 		switch v.(type) {
 		case float64, int, uint:
 			return nil
@@ -1312,6 +4509,52 @@ func (n *Node) isContainer() bool {
 	return n.nodeType == Array || n.nodeType == Object
 }
 
+// sortedKeys returns the keys of the current node's children in ascending order.
+func (n *Node) sortedKeys() []string {
+	keys := make([]string, 0, len(n.next))
+	for key := range n.next {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+	return keys
+}
+
+// children returns the child nodes of the current container node in document order.
+//
+// Array elements are ordered by index. Object members are ordered by their position
+// in the parsed data, and members without one (e.g. appended ones) follow in key order.
+func (n *Node) children() []*Node {
+	if n.IsArray() {
+		nodes := make([]*Node, len(n.next))
+		for i := range nodes {
+			nodes[i] = n.next[strconv.Itoa(i)]
+		}
+
+		return nodes
+	}
+
+	keys := n.sortedKeys()
+	position := func(key string) int {
+		if child := n.next[key]; child.ready() && child.data != nil {
+			return child.borders[0]
+		}
+
+		return math.MaxInt
+	}
+
+	sort.SliceStable(keys, func(i, j int) bool {
+		return position(keys[i]) < position(keys[j])
+	})
+
+	nodes := make([]*Node, len(keys))
+	for i, key := range keys {
+		nodes[i] = n.next[key]
+	}
+
+	return nodes
+}
+
 // remove removes the value from the current container type node.
 func (n *Node) remove(v *Node) error {
 	if !n.isContainer() {
@@ -1322,6 +4565,10 @@ func (n *Node) remove(v *Node) error {
 		return errors.New("invalid parent node")
 	}
 
+	if err := n.mutable(); err != nil {
+		return err
+	}
+
 	n.mark()
 	if n.IsArray() {
 		delete(n.next, strconv.Itoa(*v.index))
@@ -1343,8 +4590,34 @@ func (n *Node) dropIndex(idx int) {
 			n.next[strconv.Itoa(prv)] = curr
 		}
 
-		delete(n.next, strconv.Itoa(i))
+		delete(n.next, strconv.Itoa(i))
+	}
+}
+
+// insert places the given value at the given index of the current array node,
+// shifting the index of the following values to the right.
+func (n *Node) insert(idx int, val *Node) {
+	for i := len(n.next) - 1; i >= idx; i-- {
+		nxt := i + 1
+		curr := n.next[strconv.Itoa(i)]
+		curr.index = &nxt
+		n.next[strconv.Itoa(nxt)] = curr
+	}
+
+	pos := idx
+	val.prev = n
+	val.key = nil
+	val.index = &pos
+	n.next[strconv.Itoa(idx)] = val
+}
+
+// detach removes the current node from its parent node, if any.
+func (n *Node) detach() error {
+	if n.prev == nil {
+		return nil
 	}
+
+	return n.prev.remove(n)
 }
 
 // append is a helper function to append the given value to the current container type node.
@@ -1353,6 +4626,11 @@ func (n *Node) append(key *string, val *Node) error {
 		return errors.New("can't append same or parent node")
 	}
 
+	// a frozen node can't be moved either, it must be cloned first.
+	if n.frozen || val.frozen {
+		return ErrImmutableNode
+	}
+
 	if val.prev != nil {
 		if err := val.prev.remove(val); err != nil {
 			return err
@@ -1418,6 +4696,166 @@ func (n *Node) Clone() *Node {
 	return node
 }
 
+// CloneAs creates a detached copy of the current node like Clone, with the given object key preset.
+//
+// Usage:
+//
+//	user := root.MustKey("users").MustIndex(0).CloneAs("owner")
+//	if err := other.AppendObject(user.Key(), user); err != nil {
+//		t.Errorf("AppendObject returns error: %v", err)
+//	}
+func (n *Node) CloneAs(key string) *Node {
+	node := n.clone()
+	node.setRef(nil, &key, nil)
+
+	return node
+}
+
+// CloneAtIndex creates a detached copy of the current node like Clone, with the given array index preset.
+func (n *Node) CloneAtIndex(idx int) *Node {
+	node := n.clone()
+	node.setRef(nil, nil, &idx)
+
+	return node
+}
+
+// CopyFrom deep-copies the value of src into the current node, keeping the position of
+// the current node in its tree. It's like SetNode(src), but the child nodes and the child
+// maps already allocated under the current node are reused where the keys match, instead
+// of allocating fresh ones like Clone. Use it to reset a pooled node from a template
+// document in a hot loop.
+//
+// After the call, the current node and src share no mutable state: the nodes of src are
+// never referenced by the current node, so either tree can be mutated independently.
+// Only the immutable parsed data is shared, as with Clone. The descendants previously held
+// by the current node may be reused, so references to them shouldn't be kept across calls.
+//
+// If either node is nil, it returns ErrNilNode. If one node is the descendant of the
+// other, it returns an error.
+//
+// Usage:
+//
+//	template := Must(Unmarshal([]byte(`{"id": 0, "tags": []}`)))
+//	doc := ObjectNode("", nil)
+//	for _, id := range ids {
+//		if err := doc.CopyFrom(template); err != nil {
+//			return err
+//		}
+//
+//		doc.MustKey("id").SetNumber(float64(id))
+//		// ... use doc ...
+//	}
+func (n *Node) CopyFrom(src *Node) error {
+	if n == nil || src == nil {
+		return ErrNilNode
+	}
+
+	if err := n.mutable(); err != nil {
+		return err
+	}
+
+	if n == src {
+		return nil
+	}
+
+	if n.isParentNode(src) || src.isParentNode(n) {
+		return errors.New("can't copy between a node and its descendant")
+	}
+
+	prev, key, idx := n.prev, n.key, n.index
+	n.copyFrom(src)
+	n.prev, n.key, n.index = prev, key, idx
+
+	if n.prev != nil {
+		n.prev.mark()
+	}
+
+	return nil
+}
+
+// copyFrom copies src into the current node like clone, reusing the child maps and
+// the child nodes of the current node.
+func (n *Node) copyFrom(src *Node) {
+	next := n.next
+	*n = Node{
+		prev:     src.prev,
+		key:      cptrs(src.key),
+		data:     src.data,
+		value:    src.value,
+		nodeType: src.nodeType,
+		index:    cptri(src.index),
+		borders:  src.borders,
+		modified: src.modified,
+
+		rewritten: src.rewritten,
+	}
+
+	if src.next == nil {
+		for _, child := range next {
+			child.prev = nil
+		}
+
+		return
+	}
+
+	// the loaded value of a container holds the original children, so it's rebuilt on demand.
+	if src.isContainer() {
+		n.value = nil
+	}
+
+	// a frozen child is only detached, as it can't be overwritten.
+	for k, child := range next {
+		if _, ok := src.next[k]; !ok || child.frozen {
+			child.prev = nil
+			delete(next, k)
+		}
+	}
+
+	if next == nil {
+		next = make(map[string]*Node, len(src.next))
+	}
+
+	for k, v := range src.next {
+		child := next[k]
+		if child == nil {
+			child = &Node{}
+			next[k] = child
+		}
+
+		child.copyFrom(v)
+		child.prev = n
+	}
+
+	n.next = next
+}
+
+// Apply returns a deep copy of the current node updated by fn, leaving the current node unchanged.
+//
+// The copy is detached from the tree and from the parsed data, like Extract, so fn can
+// mutate it freely with the Set* methods without affecting the nodes that share the
+// original tree. If fn returns an error, Apply returns it and discards the copy.
+//
+// Usage:
+//
+//	root := Must(Unmarshal([]byte(`{"name": "foo", "tags": ["a"]}`)))
+//	updated, err := root.Apply(func(clone *Node) error {
+//		return clone.MustKey("tags").AppendArray(StringNode("", "b"))
+//	})
+//
+//	result: root == {"name": "foo", "tags": ["a"]}, updated == {"name":"foo","tags":["a","b"]}
+func (n *Node) Apply(fn func(clone *Node) error) (*Node, error) {
+	if n == nil {
+		return nil, ErrNilNode
+	}
+
+	clone := n.Extract()
+	if err := fn(clone); err != nil {
+		return nil, err
+	}
+
+	return clone, nil
+}
+
 // clone clones the current node and returns a new node instance with same value.
 func (n *Node) clone() *Node {
 	node := &Node{
@@ -1430,12 +4868,391 @@ func (n *Node) clone() *Node {
 		index:    cptri(n.index),
 		borders:  n.borders,
 		modified: n.modified,
+
+		rewritten: n.rewritten,
+	}
+
+	// the loaded value of a container holds the original children, so it's rebuilt on demand.
+	if n.isContainer() {
+		node.value = nil
 	}
 
 	for k, v := range n.next {
-		node.next[k] = v.clone()
+		child := v.clone()
+		child.prev = node
+		node.next[k] = child
+	}
+
+	return node
+}
+
+// Extract creates a deep copy of the current node that doesn't refer to the parsed data.
+//
+// The nodes created by Unmarshal share the whole input buffer, so keeping a small
+// subtree of a large document alive also keeps the entire buffer alive. Extract copies
+// the values out of the buffer instead, so the buffer can be garbage collected once
+// the original tree is no longer referenced. Use it to retain a small part of a large
+// parse result.
+//
+// The extracted nodes are marked as modified, so they are re-encoded when marshaled
+// (e.g. the number `1.50` is written as `1.5`).
+//
+// Usage:
+//
+//	root := Must(Unmarshal(largeDocument))
+//	user := root.MustKey("users").MustIndex(0).Extract()
+//	root = nil // the buffer can be collected while user stays valid
+func (n *Node) Extract() *Node {
+	if n == nil {
+		return nil
+	}
+
+	node := n.extract()
+	node.setRef(nil, nil, nil)
+
+	return node
+}
+
+// Freeze makes the current node and its descendants read-only, so that a shared tree,
+// e.g. a cached configuration or template, can't be changed by accident. The mutations
+// of a frozen node, such as the Set*, Append*, Delete and Remove* methods, return
+// ErrImmutableNode and leave it unchanged, while the reads work as usual.
+//
+// A frozen node can't be moved under another parent either. Use Clone or Extract to get
+// a mutable copy of it. The parent of a frozen subtree is not frozen, so it can still
+// replace or remove the subtree as a whole.
+//
+// Freeze doesn't make the node safe for concurrent use, as the values are still loaded
+// and cached lazily by the reads.
+//
+// Usage:
+//
+//	config := Must(Unmarshal([]byte(`{"port": 80}`)))
+//	config.Freeze()
+//
+//	err := config.MustKey("port").SetNumber(8080) // ErrImmutableNode
+//	port := config.MustKey("port").MustNumeric()  // 80
+//
+//	local := config.Clone()
+//	err = local.MustKey("port").SetNumber(8080) // nil
+func (n *Node) Freeze() {
+	if n == nil || n.frozen {
+		return
+	}
+
+	n.frozen = true
+	for _, child := range n.next {
+		child.Freeze()
+	}
+}
+
+// IsFrozen reports whether the current node is read-only. See Freeze.
+func (n *Node) IsFrozen() bool {
+	return n != nil && n.frozen
+}
+
+// Normalize detaches the current node and its descendants from the parsed data in place.
+//
+// The sources of the unmodified nodes are copied into a new buffer which holds only them,
+// and the data of the modified nodes is cleared, so the tree no longer keeps the original
+// buffer alive. The nodes keep their modified state, so Marshal writes the same output as
+// before: the number literals (e.g. `1.50`) and the key order of the unmodified objects are
+// kept. Use it before retaining a mutated document for a long time, or Extract to get a
+// detached copy instead.
+//
+// Nothing is changed if a scalar value can't be loaded from the source.
+//
+// Usage:
+//
+//	root := Must(Unmarshal(data))
+//	// ... edit root ...
+//	if err := root.Normalize(); err != nil {
+//		return err
+//	}
+func (n *Node) Normalize() error {
+	if n == nil {
+		return ErrNilNode
+	}
+
+	if err := n.mutable(); err != nil {
+		return err
+	}
+
+	if err := n.loadValues(); err != nil {
+		return err
+	}
+
+	n.normalize()
+	return nil
+}
+
+// ValidateUTF8 checks that the string values and the object keys of the current node and
+// its descendants are valid UTF-8. It returns an error wrapping ErrInvalidUTF8 for the
+// first invalid string in document order, with its path and the offset of the first
+// invalid byte in the string. The offset is counted in the source literal for a parsed
+// string, and in the Go string for a string set by the Set* methods or built by StringNode.
+//
+// Unmarshal replaces the invalid bytes with U+FFFD when a string is loaded, but Marshal
+// writes the unmodified nodes from the source as is, so the output may still hold invalid
+// UTF-8. Use SanitizeUTF8 to repair the strings in place.
+//
+// Usage:
+//
+//	root := Must(Unmarshal([]byte("{\"a\": [\"ok\", \"bad\xff\"]}")))
+//	err := root.ValidateUTF8()
+//
+//	result: $['a'][1]: invalid UTF-8 encoding in string value at byte 3
+func (n *Node) ValidateUTF8() error {
+	if n == nil {
+		return ErrNilNode
+	}
+
+	return n.walkInvalidUTF8(func(node *Node, key bool, offset int) error {
+		if key {
+			return fmt.Errorf("%s: %w in object key at byte %d", node.Path(), ErrInvalidUTF8, offset)
+		}
+
+		return fmt.Errorf("%s: %w in string value at byte %d", node.Path(), ErrInvalidUTF8, offset)
+	})
+}
+
+// SanitizeUTF8 replaces each byte of the invalid UTF-8 sequences in the string values and
+// the object keys of the current node and its descendants with U+FFFD, in place, the same
+// way Unmarshal loads them and Marshal encodes them. It returns the number of repaired
+// strings. Once repaired, ValidateUTF8 succeeds and Marshal writes valid UTF-8.
+//
+// If two keys of an object would be the same once repaired, or a string value can't be
+// loaded, it returns an error and nothing is changed.
+//
+// Usage:
+//
+//	root := ObjectNode("", map[string]*Node{"name": StringNode("", "caf\xe9")})
+//	count, err := root.SanitizeUTF8()
+//
+//	result: count == 1, root == {"name": "caf\ufffd"}
+func (n *Node) SanitizeUTF8() (int, error) {
+	if n == nil {
+		return 0, ErrNilNode
+	}
+
+	var values, keys []*Node
+	type member struct {
+		parent *Node
+		key    string
+	}
+
+	repairedKeys := make(map[member]bool)
+	err := n.walkInvalidUTF8(func(node *Node, key bool, _ int) error {
+		if !key {
+			if err := node.mutable(); err != nil {
+				return err
+			}
+
+			values = append(values, node)
+			return nil
+		}
+
+		if err := node.prev.mutable(); err != nil {
+			return err
+		}
+
+		// a parsed key is already repaired when loaded, only its source is invalid.
+		if repaired := sanitizeUTF8(*node.key); repaired != *node.key {
+			if _, ok := node.prev.next[repaired]; ok || repairedKeys[member{node.prev, repaired}] {
+				return fmt.Errorf("%s: repaired key %q already exists", node.prev.Path(), repaired)
+			}
+
+			repairedKeys[member{node.prev, repaired}] = true
+		}
+
+		keys = append(keys, node)
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	for _, node := range values {
+		value, _ := node.GetString()
+		if err := node.SetString(sanitizeUTF8(value)); err != nil {
+			return 0, err
+		}
+	}
+
+	for _, node := range keys {
+		parent, key := node.prev, sanitizeUTF8(*node.key)
+		if key != *node.key {
+			delete(parent.next, *node.key)
+			parent.next[key] = node
+			node.key = &key
+		}
+
+		parent.mark()
+	}
+
+	return len(values) + len(keys), nil
+}
+
+// walkInvalidUTF8 calls fn, in document order, for each string value and each object
+// member whose key holds invalid UTF-8, with the offset of the first invalid byte.
+// The walk stops at the first error returned by fn.
+func (n *Node) walkInvalidUTF8(fn func(node *Node, key bool, offset int) error) error {
+	switch n.nodeType {
+	case String:
+		var offset int
+		if src := n.source(); src != nil {
+			offset = invalidUTF8Offset(src[1 : len(src)-1])
+		} else {
+			value, err := n.GetString()
+			if err != nil {
+				return fmt.Errorf("%s: %w", n.Path(), err)
+			}
+
+			offset = invalidUTF8StringOffset(value)
+		}
+
+		if offset >= 0 {
+			return fn(n, false, offset)
+		}
+
+	case Array, Object:
+		// the keys of a parsed object are only found in its source, between its members.
+		src, lower := n.source(), 1
+		for _, child := range n.children() {
+			if n.IsObject() {
+				offset := invalidUTF8StringOffset(*child.key)
+				if src != nil {
+					between := src[lower : child.borders[0]-n.borders[0]]
+					if offset = invalidUTF8Offset(between); offset >= 0 {
+						offset -= bytes.IndexByte(between, doubleQuote) + 1
+					}
+
+					lower = child.borders[1] - n.borders[0]
+				}
+
+				if offset >= 0 {
+					if err := fn(child, true, offset); err != nil {
+						return err
+					}
+				}
+			}
+
+			if err := child.walkInvalidUTF8(fn); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// invalidUTF8StringOffset returns the offset of the first invalid UTF-8 sequence in s, or -1.
+func invalidUTF8StringOffset(s string) int {
+	if utf8.ValidString(s) {
+		return -1
+	}
+
+	return invalidUTF8Offset([]byte(s))
+}
+
+// sanitizeUTF8 replaces each invalid UTF-8 byte of s with U+FFFD.
+func sanitizeUTF8(s string) string {
+	return strings.Map(func(r rune) rune { return r }, s)
+}
+
+// loadValues caches the value of every scalar node in the subtree.
+func (n *Node) loadValues() error {
+	if n.isContainer() {
+		for _, child := range n.next {
+			if err := child.loadValues(); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+
+	if _, err := n.Value(); err != nil {
+		return fmt.Errorf("%s: %w", n.Path(), err)
+	}
+
+	return nil
+}
+
+func (n *Node) normalize() {
+	var subtrees []*Node
+	size := 0
+
+	var collect func(node *Node)
+	collect = func(node *Node) {
+		if src := node.source(); src != nil {
+			subtrees = append(subtrees, node)
+			size += len(src)
+			return
+		}
+
+		// the children are ordered by their borders, so before the borders are cleared.
+		children := node.children()
+		node.data = nil
+		node.borders = [2]int{}
+
+		for _, child := range children {
+			if !child.frozen {
+				collect(child)
+			}
+		}
+	}
+	collect(n)
+
+	// the subtrees are copied in document order, so their borders keep the order of the nodes.
+	data := make([]byte, 0, size)
+	shifts := make([]int, len(subtrees))
+	for i, node := range subtrees {
+		shifts[i] = len(data) - node.borders[0]
+		data = append(data, node.source()...)
+	}
+
+	for i, node := range subtrees {
+		node.rebase(data, shifts[i])
+	}
+}
+
+// rebase makes the current unmodified node and its descendants refer to the given data,
+// where their sources are moved by shift bytes.
+func (n *Node) rebase(data []byte, shift int) {
+	n.data = data
+	n.borders[0] += shift
+	n.borders[1] += shift
+
+	for _, child := range n.next {
+		if !child.frozen {
+			child.rebase(data, shift)
+		}
+	}
+}
+
+// extract copies the current node and its children without the data backing.
+func (n *Node) extract() *Node {
+	node := &Node{
+		key:      cptrs(n.key),
+		nodeType: n.nodeType,
+		index:    cptri(n.index),
+		modified: true,
+	}
+
+	if n.isContainer() {
+		node.next = make(map[string]*Node, len(n.next))
+		for k, v := range n.next {
+			child := v.extract()
+			child.prev = node
+			node.next[k] = child
+		}
+
+		return node
 	}
 
+	// load the scalar value while the data is still available.
+	node.value, _ = n.Value()
 	return node
 }
 
@@ -2,11 +2,16 @@ package json
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
+	"math"
+	"math/big"
+	"regexp"
 	"sort"
 	"strconv"
 	"strings"
 	"testing"
+	"unicode/utf8"
 )
 
 var (
@@ -296,6 +301,393 @@ func TestNode_Set(t *testing.T) {
 	}
 }
 
+func TestNode_Replace(t *testing.T) {
+	tests := []struct {
+		name     string
+		value    interface{}
+		expected string
+	}{
+		{name: "null", value: nil, expected: `null`},
+		{name: "number", value: 3, expected: `3`},
+		{name: "string", value: "foo", expected: `"foo"`},
+		{name: "bool", value: true, expected: `true`},
+		{name: "node", value: Must(Unmarshal([]byte(`[1]`))), expected: `[1]`},
+		{name: "array", value: []*Node{StringNode("", "x")}, expected: `["x"]`},
+		{name: "raw object", value: []byte(`{"b": [true, null]}`), expected: `{"b": [true, null]}`},
+		{name: "raw scalar", value: []byte(` "bar" `), expected: `"bar"`},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			root := Must(Unmarshal([]byte(`{"a": {"c": 1}}`)))
+			node := root.MustKey("a")
+
+			if err := node.Replace(tc.value); err != nil {
+				t.Fatalf("Replace returns error: %v", err)
+			}
+
+			if root.MustKey("a") != node {
+				t.Fatalf("Replace must keep the node identity")
+			}
+
+			value, err := Marshal(root)
+			if err != nil {
+				t.Fatalf("Marshal returns error: %v", err)
+			}
+
+			if expected := `{"a":` + tc.expected + `}`; string(value) != expected {
+				t.Errorf("Replace(%v) = %s, want %s", tc.value, value, expected)
+			}
+		})
+	}
+
+	raw := []byte(`["x"]`)
+	root := Must(Unmarshal([]byte(`[0]`)))
+	if err := root.MustIndex(0).Replace(raw); err != nil {
+		t.Fatalf("Replace returns error: %v", err)
+	}
+
+	raw[2] = 'y'
+	if got := root.MustIndex(0).MustIndex(0).MustString(); got != "x" {
+		t.Errorf("Replace must copy the fragment, got %q", got)
+	}
+
+	if err := root.MustIndex(0).Replace([]byte(`{"a": }`)); err == nil {
+		t.Errorf("Replace with an invalid fragment must fail")
+	}
+
+	if err := root.MustIndex(0).Replace(struct{}{}); err == nil {
+		t.Errorf("Replace with an unsupported type must fail")
+	}
+
+	if err := (*Node)(nil).Replace(1); !errors.Is(err, ErrNilNode) {
+		t.Errorf("Replace on nil node = %v, want %v", err, ErrNilNode)
+	}
+}
+
+func TestNode_SetNumberString(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		literal  string
+		expected string
+		value    float64
+	}{
+		{name: "trailing zero", input: `{"price": 0}`, literal: "123.40", expected: `{"price":123.40}`, value: 123.4},
+		{name: "large integer", input: `{"id": "x"}`, literal: "12345678901234567890123", expected: `{"id":12345678901234567890123}`, value: 1.2345678901234568e22},
+		{name: "exponent", input: `{"n": [1]}`, literal: "-1.5E+10", expected: `{"n":-1.5E+10}`, value: -1.5e10},
+		{name: "zero", input: `{"n": null}`, literal: "0", expected: `{"n":0}`, value: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := Must(Unmarshal([]byte(tt.input)))
+			for _, child := range root.next {
+				if err := child.SetNumberString(tt.literal); err != nil {
+					t.Fatalf("SetNumberString returns error: %v", err)
+				}
+
+				if child.MustNumeric() != tt.value {
+					t.Errorf("value = %v, want %v", child.MustNumeric(), tt.value)
+				}
+
+				if child.Size() != 0 {
+					t.Errorf("previous children must be removed")
+				}
+			}
+
+			if !root.Changed() {
+				t.Errorf("parent must be marked as modified")
+			}
+
+			if value, err := Marshal(root); err != nil {
+				t.Errorf("Marshal returns error: %v", err)
+			} else if string(value) != tt.expected {
+				t.Errorf("got %s, want %s", value, tt.expected)
+			}
+		})
+	}
+}
+
+func TestNode_SetNumberString_Invalid(t *testing.T) {
+	tests := []string{"", "abc", "01", "1.", ".5", "+1", "1e", "--1", "1 ", " 1", "0x10", "NaN", "Infinity", "1e400"}
+
+	for _, literal := range tests {
+		t.Run(literal, func(t *testing.T) {
+			node := StringNode("", "keep")
+			if err := node.SetNumberString(literal); err == nil {
+				t.Errorf("expected error for %q", literal)
+			}
+
+			if node.Type() != String || node.MustString() != "keep" {
+				t.Errorf("node must be left unchanged on error")
+			}
+		})
+	}
+}
+
+func TestNode_Set_NilNode(t *testing.T) {
+	tests := []struct {
+		name string
+		set  func(n *Node) error
+	}{
+		{"Set", func(n *Node) error { return n.Set("foo") }},
+		{"Set null", func(n *Node) error { return n.Set(nil) }},
+		{"Set invalid type", func(n *Node) error { return n.Set(struct{}{}) }},
+		{"SetNull", func(n *Node) error { return n.SetNull() }},
+		{"SetNumber", func(n *Node) error { return n.SetNumber(1) }},
+		{"SetNumberString", func(n *Node) error { return n.SetNumberString("1.50") }},
+		{"SetDecimal", func(n *Node) error { return n.SetDecimal(big.NewRat(1, 2), 1) }},
+		{"SetDecimal nil value", func(n *Node) error { return n.SetDecimal(nil, 1) }},
+		{"SetString", func(n *Node) error { return n.SetString("foo") }},
+		{"SetBool", func(n *Node) error { return n.SetBool(true) }},
+		{"SetArray", func(n *Node) error { return n.SetArray([]*Node{NullNode("")}) }},
+		{"SetObject", func(n *Node) error { return n.SetObject(map[string]*Node{"a": NullNode("")}) }},
+		{"SetNode", func(n *Node) error { return n.SetNode(NullNode("")) }},
+		{"SetIndex", func(n *Node) error { return n.SetIndex(0, NullNode("")) }},
+		{"Replace", func(n *Node) error { return n.Replace([]byte(`1`)) }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// a missing path result is a nil node.
+			node, _ := Must(Unmarshal([]byte(`{"a": 1}`))).GetKey("missing")
+
+			defer func() {
+				if r := recover(); r != nil {
+					t.Errorf("%s on a nil node panics: %v", tt.name, r)
+				}
+			}()
+
+			if err := tt.set(node); !errors.Is(err, ErrNilNode) {
+				t.Errorf("%s on a nil node = %v, want ErrNilNode", tt.name, err)
+			}
+		})
+	}
+
+	root := Must(Unmarshal([]byte(`{"a": 1}`)))
+	if err := root.MustKey("a").SetNode(nil); !errors.Is(err, ErrNilNode) {
+		t.Errorf("SetNode(nil) = %v, want ErrNilNode", err)
+	}
+
+	if got := root.String(); got != `{"a": 1}` {
+		t.Errorf("root = %s, must be unchanged", got)
+	}
+}
+
+func TestNode_ReplaceAll(t *testing.T) {
+	isNumber := func(node *Node) bool { return node.IsNumber() }
+	round := func(node *Node) *Node { return NumberNode("", math.Round(node.MustNumeric())) }
+
+	tests := []struct {
+		name        string
+		input       string
+		pred        func(*Node) bool
+		replacement func(*Node) *Node
+		expected    string
+		count       int
+	}{
+		{
+			name:        "round numbers",
+			input:       `{"a": 1.4, "b": [2.6, "x", {"c": -0.4}]}`,
+			pred:        isNumber,
+			replacement: round,
+			expected:    `{"a": 1, "b": [3, "x", {"c": -0}]}`,
+			count:       3,
+		},
+		{
+			name:  "strings matching a pattern",
+			input: `["id-1", "name", ["id-22"]]`,
+			pred: func(node *Node) bool {
+				s, err := node.GetString()
+				return err == nil && regexp.MustCompile(`^id-\d+$`).MatchString(s)
+			},
+			replacement: func(*Node) *Node { return StringNode("", "<redacted>") },
+			expected:    `["<redacted>", "name", ["<redacted>"]]`,
+			count:       2,
+		},
+		{
+			name:  "replace containers",
+			input: `{"a": {"secret": 1}, "b": [{"secret": 2}]}`,
+			pred:  func(node *Node) bool { return node.HasKey("secret") },
+			replacement: func(*Node) *Node {
+				return ObjectNode("", map[string]*Node{"hidden": ArrayNode("", []*Node{NumberNode("", 0)})})
+			},
+			expected: `{"a": {"hidden": [0]}, "b": [{"hidden": [0]}]}`,
+			count:    2,
+		},
+		{
+			name:        "root",
+			input:       `[1]`,
+			pred:        func(node *Node) bool { return node.IsArray() },
+			replacement: func(*Node) *Node { return NullNode("") },
+			expected:    `null`,
+			count:       1,
+		},
+		{
+			name:        "no match",
+			input:       `{"a": "b"}`,
+			pred:        isNumber,
+			replacement: round,
+			expected:    `{"a": "b"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := Must(Unmarshal([]byte(tt.input)))
+
+			count, err := root.ReplaceAll(tt.pred, tt.replacement)
+			if err != nil {
+				t.Fatalf("ReplaceAll returns error: %v", err)
+			}
+
+			if count != tt.count {
+				t.Errorf("count = %d, want %d", count, tt.count)
+			}
+
+			if expected := Must(Unmarshal([]byte(tt.expected))); !equals(root, expected) {
+				t.Errorf("got %s, want %s", root, tt.expected)
+			}
+
+			var check func(node *Node)
+			check = func(node *Node) {
+				for _, child := range node.next {
+					if child.prev != node {
+						t.Errorf("child %s has a wrong parent", child.Path())
+					}
+
+					check(child)
+				}
+			}
+			check(root)
+		})
+	}
+}
+
+func TestNode_ReplaceAll_Fail(t *testing.T) {
+	root := Must(Unmarshal([]byte(`{"a": {"b": 1}}`)))
+
+	_, err := root.ReplaceAll(
+		func(node *Node) bool { return node.IsNumber() },
+		func(*Node) *Node { return nil },
+	)
+	if err != ErrNilNode {
+		t.Errorf("expected ErrNilNode for nil replacement, got %v", err)
+	}
+
+	_, err = root.ReplaceAll(
+		func(node *Node) bool { return node.HasKey("b") },
+		func(node *Node) *Node { return node.MustKey("b") },
+	)
+	if err == nil {
+		t.Errorf("replacing a node with its descendant must fail")
+	}
+
+	if _, err := (*Node)(nil).ReplaceAll(nil, nil); err != ErrNilNode {
+		t.Errorf("expected ErrNilNode for nil receiver, got %v", err)
+	}
+}
+
+func TestNode_Prune(t *testing.T) {
+	isNull := func(node *Node) bool { return node.IsNull() }
+
+	tests := []struct {
+		name     string
+		input    string
+		pred     func(*Node) bool
+		expected string
+		count    int
+	}{
+		{
+			name:     "null leaves",
+			input:    `{"a": null, "b": [1, null, {"c": null}]}`,
+			pred:     isNull,
+			expected: `{"b": [1, {}]}`,
+			count:    3,
+		},
+		{
+			name:     "consecutive elements",
+			input:    `[null, null, 1, null, 2, null]`,
+			pred:     isNull,
+			expected: `[1, 2]`,
+			count:    4,
+		},
+		{
+			name:     "objects missing a field",
+			input:    `{"books": [{"id": 1, "tags": ["a"]}, {"title": "foo"}, {"id": 3}, {}]}`,
+			pred:     func(node *Node) bool { return node.IsObject() && !node.HasKey("id") },
+			expected: `{"books": [{"id": 1, "tags": ["a"]}, {"id": 3}]}`,
+			count:    2,
+		},
+		{
+			name:     "subtree is not visited",
+			input:    `{"a": [null, [null]], "b": null}`,
+			pred:     func(node *Node) bool { return node.IsArray() || node.IsNull() },
+			expected: `{}`,
+			count:    2,
+		},
+		{
+			name:     "root is kept",
+			input:    `[1]`,
+			pred:     func(node *Node) bool { return true },
+			expected: `[]`,
+			count:    1,
+		},
+		{
+			name:     "scalar",
+			input:    `null`,
+			pred:     isNull,
+			expected: `null`,
+		},
+		{
+			name:     "no match",
+			input:    `{"a": [1, "b"]}`,
+			pred:     isNull,
+			expected: `{"a": [1, "b"]}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := Must(Unmarshal([]byte(tt.input)))
+
+			count, err := root.Prune(tt.pred)
+			if err != nil {
+				t.Fatalf("Prune returns error: %v", err)
+			}
+
+			if count != tt.count {
+				t.Errorf("count = %d, want %d", count, tt.count)
+			}
+
+			if expected := Must(Unmarshal([]byte(tt.expected))); !equals(root, expected) {
+				t.Errorf("got %s, want %s", root, tt.expected)
+			}
+
+			var check func(node *Node)
+			check = func(node *Node) {
+				for i, child := range node.children() {
+					if child.prev != node {
+						t.Errorf("child %s has a wrong parent", child.Path())
+					}
+
+					if node.IsArray() && child.Index() != i {
+						t.Errorf("child %s has index %d, want %d", child.Path(), child.Index(), i)
+					}
+
+					check(child)
+				}
+			}
+			check(root)
+		})
+	}
+
+	if _, err := (*Node)(nil).Prune(nil); err != ErrNilNode {
+		t.Errorf("expected ErrNilNode for nil receiver, got %v", err)
+	}
+}
+
 func TestNode_Delete(t *testing.T) {
 	root := Must(Unmarshal([]byte(`{"foo":"bar"}`)))
 	if err := root.Delete(); err != nil {
@@ -330,6 +722,49 @@ func TestNode_Delete(t *testing.T) {
 	}
 }
 
+func TestNode_RemoveEmpty(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		mask     EmptyMask
+		expected string
+		removed  int
+	}{
+		{name: "all", input: `{"a": null, "b": "", "c": [], "d": {}, "e": [0, false]}`, mask: EmptyAll, expected: `{"e":[0, false]}`, removed: 4},
+		{name: "nested becomes empty", input: `{"a": {"b": [null, ""]}, "c": 1}`, mask: EmptyAll, expected: `{"c":1}`, removed: 4},
+		{name: "array elements", input: `[null, 1, "", [], "x", {}]`, mask: EmptyAll, expected: `[1,"x"]`, removed: 4},
+		{name: "null only", input: `{"a": null, "c": [null, ""]}`, mask: EmptyNull, expected: `{"c":[""]}`, removed: 2},
+		{name: "keep strings", input: `["", null, {}]`, mask: EmptyAll &^ EmptyString, expected: `[""]`, removed: 2},
+		{name: "nothing to remove", input: `{"a": [1, "b"]}`, mask: EmptyAll, expected: `{"a": [1, "b"]}`},
+		{name: "root is kept", input: `[[]]`, mask: EmptyAll, expected: `[]`, removed: 1},
+		{name: "scalar", input: `null`, mask: EmptyAll, expected: `null`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := Must(Unmarshal([]byte(tt.input)))
+			if removed := root.RemoveEmptyWith(tt.mask); removed != tt.removed {
+				t.Errorf("removed = %d, want %d", removed, tt.removed)
+			}
+
+			if value, err := Marshal(root); err != nil {
+				t.Errorf("Marshal returns error: %v", err)
+			} else if string(value) != tt.expected {
+				t.Errorf("got %s, want %s", value, tt.expected)
+			}
+		})
+	}
+
+	root := Must(Unmarshal([]byte(`{"a": null, "b": {"c": ""}, "d": 1}`)))
+	if removed := root.RemoveEmpty(); removed != 3 {
+		t.Errorf("RemoveEmpty removed %d, want 3", removed)
+	}
+
+	if !root.Changed() {
+		t.Errorf("root must be marked as modified")
+	}
+}
+
 func TestNode_ObjectNode(t *testing.T) {
 	objs := map[string]*Node{
 		"key1": NullNode("null"),
@@ -471,6 +906,33 @@ func TestNode_GetBool_Fail(t *testing.T) {
 	}
 }
 
+func TestNode_GetBool_InvalidLiteral(t *testing.T) {
+	tests := []struct {
+		name    string
+		literal string
+	}{
+		{"truncated", "tru"},
+		{"trailing bytes", "trueish"},
+		{"capitalized", "True"},
+		{"false prefix", "fals"},
+		{"other literal", "null"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			node := &Node{
+				data:     []byte(tt.literal),
+				nodeType: Boolean,
+				borders:  [2]int{0, len(tt.literal)},
+			}
+
+			if _, err := node.GetBool(); err == nil {
+				t.Errorf("%q should be an error", tt.literal)
+			}
+		})
+	}
+}
+
 // countBooleans test function that counts the number of true and false values in a slice of booleans.
 // because map doesn't maintain order, we can't use it to test the order of the values.
 func countBooleans(bools []bool) (trueCount, falseCount int) {
@@ -702,74 +1164,408 @@ func TestNode_GetNumeric_Fail(t *testing.T) {
 	}
 }
 
-func TestNode_GetAllIntsFromNode(t *testing.T) {
+func TestNode_NumericKind(t *testing.T) {
 	tests := []struct {
 		name     string
-		json     string
-		expected []int
+		node     *Node
+		expected NumericKind
 	}{
-		{
-			name:     "no numbers",
-			json:     `{"foo": "bar", "baz": "qux"}`,
-			expected: []int{},
-		},
-		{
-			name:     "mixed numbers",
-			json:     `{"int": 42, "float": 3.14, "nested": {"int2": 24, "array": [1, 2.34, 3]}}`,
-			expected: []int{42, 24, 1, 3},
-		},
-		{
-			name:     "all floats",
-			json:     `{"float1": 0.1, "float2": 4.5}`,
-			expected: []int{},
-		},
+		{"zero", Must(Unmarshal([]byte(`0`))), NumericInt64},
+		{"negative", Must(Unmarshal([]byte(`-42`))), NumericInt64},
+		{"max int64", Must(Unmarshal([]byte(`9223372036854775807`))), NumericInt64},
+		{"min int64", Must(Unmarshal([]byte(`-9223372036854775808`))), NumericInt64},
+		{"above int64", Must(Unmarshal([]byte(`9223372036854775808`))), NumericUint64},
+		{"max uint64", Must(Unmarshal([]byte(`18446744073709551615`))), NumericUint64},
+		{"above uint64", Must(Unmarshal([]byte(`18446744073709551616`))), NumericFloat64},
+		{"below int64", Must(Unmarshal([]byte(`-9223372036854775809`))), NumericFloat64},
+		{"fraction", Must(Unmarshal([]byte(`1.5`))), NumericFloat64},
+		{"integral fraction", Must(Unmarshal([]byte(`1.0`))), NumericFloat64},
+		{"exponent", Must(Unmarshal([]byte(`1e2`))), NumericFloat64},
+		{"int node", IntNode("", math.MinInt64), NumericInt64},
+		{"float node", FloatNode("", 3), NumericFloat64},
+		{"integral value", NumberNode("", 3), NumericInt64},
+		{"large value", NumberNode("", 1<<63), NumericUint64},
+		{"fractional value", NumberNode("", 0.25), NumericFloat64},
+		{"huge value", NumberNode("", 1e30), NumericFloat64},
 	}
 
-	for _, tc := range tests {
-		t.Run(tc.name, func(t *testing.T) {
-			root, err := Unmarshal([]byte(tc.json))
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			kind, err := tt.node.NumericKind()
 			if err != nil {
-				t.Fatalf("Unmarshal failed: %v", err)
+				t.Fatalf("NumericKind returns error: %v", err)
 			}
 
-			got := root.GetInts()
-			for _, exp := range tc.expected {
-				if !containsInts(got, exp) {
-					t.Errorf("Expected integer %d is missing", exp)
-				}
+			if kind != tt.expected {
+				t.Errorf("NumericKind() = %s, want %s", kind, tt.expected)
 			}
 		})
 	}
-}
 
-// containsInts checks if a slice of integers contains a specific value.
-func containsInts(slice []int, val int) bool {
-	for _, item := range slice {
-		if item == val {
-			return true
+	for _, node := range []*Node{nil, StringNode("", "1"), NullNode("")} {
+		if _, err := node.NumericKind(); err == nil {
+			t.Errorf("NumericKind of %v must fail", node)
 		}
 	}
-	return false
 }
 
-func TestNode_GetAllFloatsFromNode(t *testing.T) {
+func TestNode_Number(t *testing.T) {
 	tests := []struct {
 		name     string
-		json     string
-		expected []float64
+		node     *Node
+		expected interface{}
 	}{
-		{
-			name:     "no numbers",
-			json:     `{"foo": "bar", "baz": "qux"}`,
-			expected: []float64{},
-		},
-		{
-			name:     "mixed numbers",
-			json:     `{"int": 42, "float": 3.14, "nested": {"float2": 2.34, "array": [1, 2.34, 3]}}`,
-			expected: []float64{3.14, 2.34, 2.34},
-		},
-		{
-			name:     "all ints",
+		{"integer", Must(Unmarshal([]byte(`42`))), int64(42)},
+		{"beyond float64 precision", Must(Unmarshal([]byte(`9007199254740993`))), int64(9007199254740993)},
+		{"min int64", Must(Unmarshal([]byte(`-9223372036854775808`))), int64(math.MinInt64)},
+		{"above int64", Must(Unmarshal([]byte(`9223372036854775808`))), float64(1 << 63)},
+		{"fraction", Must(Unmarshal([]byte(`1.5`))), 1.5},
+		{"integral fraction", Must(Unmarshal([]byte(`2.0`))), 2.0},
+		{"exponent", Must(Unmarshal([]byte(`1e3`))), 1000.0},
+		{"int node", IntNode("", -7), int64(-7)},
+		{"integral value", NumberNode("", 12), int64(12)},
+		{"fractional value", NumberNode("", 0.5), 0.5},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			value, err := tt.node.Number()
+			if err != nil {
+				t.Fatalf("Number returns error: %v", err)
+			}
+
+			if value != tt.expected {
+				t.Errorf("Number() = %v (%T), want %v (%T)", value, value, tt.expected, tt.expected)
+			}
+		})
+	}
+
+	if _, err := StringNode("", "1").Number(); err == nil {
+		t.Errorf("Number of a string node must fail")
+	}
+}
+
+func TestNode_GetDecimal(t *testing.T) {
+	tests := []struct {
+		name     string
+		node     *Node
+		expected string
+	}{
+		{"fraction", Must(Unmarshal([]byte(`19.99`))), "1999/100"},
+		{"tenth", Must(Unmarshal([]byte(`0.1`))), "1/10"},
+		{"trailing zeros", Must(Unmarshal([]byte(`1.50`))), "3/2"},
+		{"beyond float64 precision", Must(Unmarshal([]byte(`9007199254740993`))), "9007199254740993/1"},
+		{"exponent", Must(Unmarshal([]byte(`-1.25E-2`))), "-1/80"},
+		{"beyond float64 range", Must(Unmarshal([]byte(`{"a": 1e400}`))).MustKey("a"), "1" + strings.Repeat("0", 400) + "/1"},
+		{"number node", NumberNode("", 0.1), "1/10"},
+		{"int node", IntNode("", -7), "-7/1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			value, err := tt.node.GetDecimal()
+			if err != nil {
+				t.Fatalf("GetDecimal returns error: %v", err)
+			}
+
+			if value.String() != tt.expected {
+				t.Errorf("GetDecimal() = %s, want %s", value, tt.expected)
+			}
+		})
+	}
+
+	for _, node := range []*Node{nil, StringNode("", "1"), NumberNode("", math.Inf(1))} {
+		if value, err := node.GetDecimal(); err == nil {
+			t.Errorf("GetDecimal of %v = %s, must fail", node, value)
+		}
+	}
+}
+
+func TestNode_SetDecimal(t *testing.T) {
+	tests := []struct {
+		name     string
+		value    *big.Rat
+		scale    int
+		expected string
+	}{
+		{"scale", big.NewRat(3, 2), 2, `1.50`},
+		{"integer", big.NewRat(20, 1), 0, `20`},
+		{"sum of cents", new(big.Rat).Add(big.NewRat(1999, 100), big.NewRat(1, 100)), 2, `20.00`},
+		{"rounded", big.NewRat(1, 3), 4, `0.3333`},
+		{"half away from zero", big.NewRat(-5, 1000), 2, `-0.01`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := Must(Unmarshal([]byte(`{"price": 0, "other": 1.000}`)))
+			price := root.MustKey("price")
+
+			if err := price.SetDecimal(tt.value, tt.scale); err != nil {
+				t.Fatalf("SetDecimal returns error: %v", err)
+			}
+
+			if got := price.String(); got != tt.expected {
+				t.Errorf("SetDecimal() = %s, want %s", got, tt.expected)
+			}
+
+			value, err := Marshal(root)
+			if err != nil {
+				t.Fatalf("Marshal returns error: %v", err)
+			}
+
+			parsed := Must(Unmarshal(value))
+			if got := parsed.MustKey("price").String(); got != tt.expected {
+				t.Errorf("marshaled price = %s, want %s", got, tt.expected)
+			}
+
+			if got := parsed.MustKey("other").String(); got != `1.000` {
+				t.Errorf("marshaled other = %s, want 1.000", got)
+			}
+
+			decimal, err := price.GetDecimal()
+			if err != nil {
+				t.Fatalf("GetDecimal returns error: %v", err)
+			}
+
+			if expected, _ := new(big.Rat).SetString(tt.expected); decimal.Cmp(expected) != 0 {
+				t.Errorf("GetDecimal() = %s, want %s", decimal, expected)
+			}
+		})
+	}
+
+	if err := NumberNode("", 0).SetDecimal(nil, 2); err == nil {
+		t.Errorf("SetDecimal(nil) must fail")
+	}
+
+	if err := NumberNode("", 0).SetDecimal(big.NewRat(1, 2), -1); err == nil {
+		t.Errorf("SetDecimal with a negative scale must fail")
+	}
+}
+
+func TestNode_GetNumericLoose(t *testing.T) {
+	tests := []struct {
+		name     string
+		json     string
+		expected float64
+		isErr    bool
+	}{
+		{name: "number", json: `42`, expected: 42},
+		{name: "string integer", json: `"42"`, expected: 42},
+		{name: "string float", json: `"-1.5e3"`, expected: -1500},
+		{name: "escaped string", json: `"\u0031\u0030"`, expected: 10},
+		{name: "not a number", json: `"abc"`, isErr: true},
+		{name: "empty string", json: `""`, isErr: true},
+		{name: "spaces", json: `" 1"`, isErr: true},
+		{name: "sign only", json: `"-"`, isErr: true},
+		{name: "empty exponent", json: `"1e"`, isErr: true},
+		{name: "empty fraction", json: `"1."`, isErr: true},
+		{name: "no integer part", json: `".5"`, isErr: true},
+		{name: "leading zero", json: `"01"`, isErr: true},
+		{name: "plus sign", json: `"+1"`, isErr: true},
+		{name: "bool", json: `true`, isErr: true},
+		{name: "null", json: `null`, isErr: true},
+		{name: "array", json: `["1"]`, isErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Must(Unmarshal([]byte(tt.json))).GetNumericLoose()
+			if tt.isErr {
+				if err == nil {
+					t.Errorf("expected error, got %v", got)
+				}
+				return
+			}
+
+			if err != nil || got != tt.expected {
+				t.Errorf("got %v, %v, want %v", got, err, tt.expected)
+			}
+		})
+	}
+
+	if _, err := (*Node)(nil).GetNumericLoose(); err != ErrNilNode {
+		t.Errorf("expected ErrNilNode, got %v", err)
+	}
+}
+
+func TestNode_GetNumericFormatted(t *testing.T) {
+	us := NumberFormat{Grouping: ','}
+	eu := NumberFormat{Grouping: '.', Decimal: ','}
+
+	tests := []struct {
+		name     string
+		json     string
+		format   NumberFormat
+		expected float64
+		isErr    bool
+	}{
+		{name: "grouping", json: `"1,234.56"`, format: us, expected: 1234.56},
+		{name: "multiple groups", json: `"-1,234,567"`, format: us, expected: -1234567},
+		{name: "decimal comma", json: `"1.234,56"`, format: eu, expected: 1234.56},
+		{name: "space grouping", json: `"1 234,5"`, format: NumberFormat{Grouping: ' ', Decimal: ','}, expected: 1234.5},
+		{name: "no separators", json: `"42"`, format: eu, expected: 42},
+		{name: "zero format", json: `"1.5"`, format: NumberFormat{}, expected: 1.5},
+		{name: "number", json: `1.5`, format: eu, expected: 1.5},
+		{name: "foreign decimal point", json: `"1.5"`, format: NumberFormat{Decimal: ','}, isErr: true},
+		{name: "unknown separator", json: `"1'234"`, format: us, isErr: true},
+		{name: "not a number", json: `"abc"`, format: us, isErr: true},
+		{name: "sign only", json: `"-"`, format: us, isErr: true},
+		{name: "empty exponent", json: `"1e"`, format: us, isErr: true},
+		{name: "empty fraction", json: `"1,234."`, format: us, isErr: true},
+		{name: "no integer part", json: `",5"`, format: eu, isErr: true},
+		{name: "leading zero", json: `"01"`, format: us, isErr: true},
+		{name: "same separators", json: `"1,5"`, format: NumberFormat{Grouping: ',', Decimal: ','}, isErr: true},
+		{name: "bool", json: `true`, format: us, isErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Must(Unmarshal([]byte(tt.json))).GetNumericFormatted(tt.format)
+			if tt.isErr {
+				if err == nil {
+					t.Errorf("expected error, got %v", got)
+				}
+				return
+			}
+
+			if err != nil || got != tt.expected {
+				t.Errorf("got %v, %v, want %v", got, err, tt.expected)
+			}
+		})
+	}
+
+	if _, err := (*Node)(nil).GetNumericFormatted(us); err != ErrNilNode {
+		t.Errorf("expected ErrNilNode, got %v", err)
+	}
+}
+
+func TestNode_Clamp(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected float64
+		changed  bool
+	}{
+		{name: "below", input: `-3.5`, expected: 0, changed: true},
+		{name: "above", input: `120`, expected: 100, changed: true},
+		{name: "inside", input: `42`, expected: 42},
+		{name: "bound", input: `100`, expected: 100},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := Must(Unmarshal([]byte(tt.input)))
+			if err := root.Clamp(0, 100); err != nil {
+				t.Fatalf("Clamp returns error: %v", err)
+			}
+
+			if got := root.MustNumeric(); got != tt.expected {
+				t.Errorf("got %v, want %v", got, tt.expected)
+			}
+
+			if root.Changed() != tt.changed {
+				t.Errorf("changed = %v, want %v", root.Changed(), tt.changed)
+			}
+		})
+	}
+
+	if err := StringNode("", "1").Clamp(0, 1); err == nil {
+		t.Errorf("expected error for non-number node")
+	}
+
+	if err := NumberNode("", 1).Clamp(2, 1); err == nil {
+		t.Errorf("expected error for invalid range")
+	}
+}
+
+func TestNode_ClampAll(t *testing.T) {
+	root := Must(Unmarshal([]byte(`{"a": -5, "b": [50, 500, "x", {"c": 101}], "d": null}`)))
+	if err := root.ClampAll(0, 100); err != nil {
+		t.Fatalf("ClampAll returns error: %v", err)
+	}
+
+	expected := Must(Unmarshal([]byte(`{"a": 0, "b": [50, 100, "x", {"c": 100}], "d": null}`)))
+	if !equals(root, expected) {
+		t.Errorf("got %s, want %s", root, expected)
+	}
+
+	if root.MustKey("b").MustIndex(0).Changed() {
+		t.Errorf("value inside the range must not be modified")
+	}
+
+	if err := root.ClampAll(1, 0); err == nil {
+		t.Errorf("expected error for invalid range")
+	}
+}
+
+func TestNode_GetAllIntsFromNode(t *testing.T) {
+	tests := []struct {
+		name     string
+		json     string
+		expected []int
+	}{
+		{
+			name:     "no numbers",
+			json:     `{"foo": "bar", "baz": "qux"}`,
+			expected: []int{},
+		},
+		{
+			name:     "mixed numbers",
+			json:     `{"int": 42, "float": 3.14, "nested": {"int2": 24, "array": [1, 2.34, 3]}}`,
+			expected: []int{42, 24, 1, 3},
+		},
+		{
+			name:     "all floats",
+			json:     `{"float1": 0.1, "float2": 4.5}`,
+			expected: []int{},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			root, err := Unmarshal([]byte(tc.json))
+			if err != nil {
+				t.Fatalf("Unmarshal failed: %v", err)
+			}
+
+			got := root.GetInts()
+			for _, exp := range tc.expected {
+				if !containsInts(got, exp) {
+					t.Errorf("Expected integer %d is missing", exp)
+				}
+			}
+		})
+	}
+}
+
+// containsInts checks if a slice of integers contains a specific value.
+func containsInts(slice []int, val int) bool {
+	for _, item := range slice {
+		if item == val {
+			return true
+		}
+	}
+	return false
+}
+
+func TestNode_GetAllFloatsFromNode(t *testing.T) {
+	tests := []struct {
+		name     string
+		json     string
+		expected []float64
+	}{
+		{
+			name:     "no numbers",
+			json:     `{"foo": "bar", "baz": "qux"}`,
+			expected: []float64{},
+		},
+		{
+			name:     "mixed numbers",
+			json:     `{"int": 42, "float": 3.14, "nested": {"float2": 2.34, "array": [1, 2.34, 3]}}`,
+			expected: []float64{3.14, 2.34, 2.34},
+		},
+		{
+			name:     "all ints",
 			json:     `{"int1": 1, "int2": 2}`,
 			expected: []float64{},
 		},
@@ -991,41 +1787,310 @@ func TestNode_GetArray_Fail(t *testing.T) {
 	}
 }
 
-func TestNode_IsArray(t *testing.T) {
-	root, err := Unmarshal(sampleArr)
-	if err != nil {
-		t.Errorf("Error on Unmarshal(): %s", err)
-		return
+func TestNode_GetStringArray(t *testing.T) {
+	tests := []struct {
+		name     string
+		json     string
+		expected []string
+		fail     bool
+	}{
+		{name: "strings", json: `["foo", "bar", "baz"]`, expected: []string{"foo", "bar", "baz"}},
+		{name: "empty", json: `[]`, expected: []string{}},
+		{name: "nested array is not flattened", json: `["foo", ["bar"]]`, fail: true},
+		{name: "mixed types", json: `["foo", 1]`, fail: true},
+		{name: "not array", json: `{"foo": "bar"}`, fail: true},
 	}
 
-	if root.Type() != Array {
-		t.Errorf(fmt.Sprintf("Must be an array. got: %s", root.Type().String()))
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := Must(Unmarshal([]byte(tt.json)))
+
+			got, err := root.GetStringArray()
+			if tt.fail {
+				if err == nil {
+					t.Errorf("expected error, got %v", got)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if len(got) != len(tt.expected) {
+				t.Fatalf("GetStringArray() = %v, want %v", got, tt.expected)
+			}
+
+			for i := range got {
+				if got[i] != tt.expected[i] {
+					t.Errorf("GetStringArray() = %v, want %v", got, tt.expected)
+				}
+			}
+		})
 	}
 }
 
-func TestNode_ArrayEach(t *testing.T) {
+func TestNode_GetNumberArray(t *testing.T) {
 	tests := []struct {
 		name     string
 		json     string
-		expected []int
+		expected []float64
+		fail     bool
 	}{
-		{
-			name:     "empty array",
-			json:     `[]`,
-			expected: []int{},
-		},
-		{
-			name:     "single element",
-			json:     `[42]`,
-			expected: []int{42},
-		},
-		{
-			name:     "multiple elements",
-			json:     `[1, 2, 3, 4, 5]`,
-			expected: []int{1, 2, 3, 4, 5},
-		},
-		{
-			name:     "multiple elements but all values are same",
+		{name: "numbers", json: `[3, 1.5, -2, 1e2]`, expected: []float64{3, 1.5, -2, 100}},
+		{name: "empty", json: `[]`, expected: []float64{}},
+		{name: "string element", json: `[1, "2"]`, fail: true},
+		{name: "nested object", json: `[1, {"a": 2}]`, fail: true},
+		{name: "not array", json: `1`, fail: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := Must(Unmarshal([]byte(tt.json)))
+
+			got, err := root.GetNumberArray()
+			if tt.fail {
+				if err == nil {
+					t.Errorf("expected error, got %v", got)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if len(got) != len(tt.expected) {
+				t.Fatalf("GetNumberArray() = %v, want %v", got, tt.expected)
+			}
+
+			for i := range got {
+				if got[i] != tt.expected[i] {
+					t.Errorf("GetNumberArray() = %v, want %v", got, tt.expected)
+				}
+			}
+		})
+	}
+}
+
+func TestNode_AsArray(t *testing.T) {
+	root := Must(Unmarshal([]byte(`{"list": [1, {"a": 2}], "empty": [], "object": {"b": 3}, "scalar": "x", "null": null}`)))
+
+	tests := []struct {
+		name     string
+		node     *Node
+		expected []string
+	}{
+		{"array", root.MustKey("list"), []string{`1`, `{"a": 2}`}},
+		{"empty array", root.MustKey("empty"), []string{}},
+		{"object", root.MustKey("object"), []string{`{"b": 3}`}},
+		{"scalar", root.MustKey("scalar"), []string{`"x"`}},
+		{"null", root.MustKey("null"), []string{`null`}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			elems := tt.node.AsArray()
+			if len(elems) != len(tt.expected) {
+				t.Fatalf("AsArray() has %d elements, want %d", len(elems), len(tt.expected))
+			}
+
+			for i, elem := range elems {
+				if elem.String() != tt.expected[i] {
+					t.Errorf("AsArray()[%d] = %s, want %s", i, elem, tt.expected[i])
+				}
+			}
+		})
+	}
+
+	if elems := root.MustKey("object").AsArray(); elems[0] != root.MustKey("object") {
+		t.Errorf("AsArray must not clone the node")
+	}
+
+	if elems := root.MustKey("list").AsArray(); elems[1] != root.MustKey("list").MustIndex(1) {
+		t.Errorf("AsArray must not clone the elements")
+	}
+
+	if elems := (*Node)(nil).AsArray(); elems != nil {
+		t.Errorf("AsArray of nil = %v, want nil", elems)
+	}
+}
+
+func TestNode_AsTypes(t *testing.T) {
+	root := Must(Unmarshal([]byte(`{"obj": {"a": 1}, "str": "x", "num": 1.5, "bool": true, "arr": [1]}`)))
+
+	if obj, err := root.MustKey("obj").AsObject(); err != nil || len(obj) != 1 {
+		t.Errorf("AsObject() = %v, %v", obj, err)
+	}
+
+	if str, err := root.MustKey("str").AsString(); err != nil || str != "x" {
+		t.Errorf("AsString() = %q, %v", str, err)
+	}
+
+	if num, err := root.MustKey("num").AsNumber(); err != nil || num != 1.5 {
+		t.Errorf("AsNumber() = %v, %v", num, err)
+	}
+
+	if b, err := root.MustKey("bool").AsBool(); err != nil || !b {
+		t.Errorf("AsBool() = %v, %v", b, err)
+	}
+
+	arr := root.MustKey("arr")
+	tests := []struct {
+		name     string
+		assert   func(n *Node) error
+		expected ValueType
+	}{
+		{"AsObject", func(n *Node) error { _, err := n.AsObject(); return err }, Object},
+		{"AsString", func(n *Node) error { _, err := n.AsString(); return err }, String},
+		{"AsNumber", func(n *Node) error { _, err := n.AsNumber(); return err }, Number},
+		{"AsBool", func(n *Node) error { _, err := n.AsBool(); return err }, Boolean},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var te *TypeError
+			if err := tt.assert(arr); !errors.As(err, &te) || te.Expected != tt.expected || te.Got != Array {
+				t.Errorf("%s() on an array = %v, want a TypeError expecting %s", tt.name, err, tt.expected)
+			}
+
+			if err := tt.assert(nil); err != ErrNilNode {
+				t.Errorf("%s() on a nil node = %v, want ErrNilNode", tt.name, err)
+			}
+		})
+	}
+}
+
+func TestNode_Head_Tail(t *testing.T) {
+	root := Must(Unmarshal([]byte(`[1, 2, 3, 4]`)))
+
+	tests := []struct {
+		k    int
+		head string
+		tail string
+	}{
+		{k: 0, head: ``, tail: ``},
+		{k: 1, head: `1`, tail: `4`},
+		{k: 3, head: `1 2 3`, tail: `2 3 4`},
+		{k: 4, head: `1 2 3 4`, tail: `1 2 3 4`},
+		{k: 10, head: `1 2 3 4`, tail: `1 2 3 4`},
+	}
+
+	join := func(nodes []*Node) string {
+		values := make([]string, len(nodes))
+		for i, node := range nodes {
+			values[i] = node.String()
+		}
+
+		return strings.Join(values, " ")
+	}
+
+	for _, tt := range tests {
+		t.Run(strconv.Itoa(tt.k), func(t *testing.T) {
+			head, err := root.Head(tt.k)
+			if err != nil {
+				t.Fatalf("Head returns error: %v", err)
+			}
+
+			if got := join(head); got != tt.head {
+				t.Errorf("Head(%d) = %s, want %s", tt.k, got, tt.head)
+			}
+
+			tail, err := root.Tail(tt.k)
+			if err != nil {
+				t.Fatalf("Tail returns error: %v", err)
+			}
+
+			if got := join(tail); got != tt.tail {
+				t.Errorf("Tail(%d) = %s, want %s", tt.k, got, tt.tail)
+			}
+		})
+	}
+
+	if _, err := root.Head(-1); !errors.Is(err, ErrIndexOutOfRange) {
+		t.Errorf("expected ErrIndexOutOfRange, got %v", err)
+	}
+
+	if _, err := Must(Unmarshal([]byte(`{"a": 1}`))).Tail(1); err == nil {
+		t.Errorf("expected error for non-array node")
+	}
+
+	if head, err := Must(Unmarshal([]byte(`[]`))).Head(2); err != nil || len(head) != 0 {
+		t.Errorf("Head of empty array = %v, %v", head, err)
+	}
+}
+
+func TestNode_IsArray(t *testing.T) {
+	root, err := Unmarshal(sampleArr)
+	if err != nil {
+		t.Errorf("Error on Unmarshal(): %s", err)
+		return
+	}
+
+	if root.Type() != Array {
+		t.Errorf(fmt.Sprintf("Must be an array. got: %s", root.Type().String()))
+	}
+}
+
+func TestNode_IsContainer_IsScalar_IsLeaf(t *testing.T) {
+	tests := []struct {
+		json      string
+		container bool
+		scalar    bool
+		leaf      bool
+	}{
+		{json: `"foo"`, scalar: true, leaf: true},
+		{json: `1.5`, scalar: true, leaf: true},
+		{json: `true`, scalar: true, leaf: true},
+		{json: `null`, scalar: true, leaf: true},
+		{json: `[]`, container: true, leaf: true},
+		{json: `{}`, container: true, leaf: true},
+		{json: `[1]`, container: true},
+		{json: `{"a": null}`, container: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.json, func(t *testing.T) {
+			root := Must(Unmarshal([]byte(tt.json)))
+
+			if root.IsContainer() != tt.container {
+				t.Errorf("IsContainer() = %t, want %t", root.IsContainer(), tt.container)
+			}
+
+			if root.IsScalar() != tt.scalar {
+				t.Errorf("IsScalar() = %t, want %t", root.IsScalar(), tt.scalar)
+			}
+
+			if root.IsLeaf() != tt.leaf {
+				t.Errorf("IsLeaf() = %t, want %t", root.IsLeaf(), tt.leaf)
+			}
+		})
+	}
+}
+
+func TestNode_ArrayEach(t *testing.T) {
+	tests := []struct {
+		name     string
+		json     string
+		expected []int
+	}{
+		{
+			name:     "empty array",
+			json:     `[]`,
+			expected: []int{},
+		},
+		{
+			name:     "single element",
+			json:     `[42]`,
+			expected: []int{42},
+		},
+		{
+			name:     "multiple elements",
+			json:     `[1, 2, 3, 4, 5]`,
+			expected: []int{1, 2, 3, 4, 5},
+		},
+		{
+			name:     "multiple elements but all values are same",
 			json:     `[1, 1, 1, 1, 1]`,
 			expected: []int{1, 1, 1, 1, 1},
 		},
@@ -1071,6 +2136,47 @@ func TestNode_ArrayEach(t *testing.T) {
 	}
 }
 
+func TestNode_ArrayEachErr(t *testing.T) {
+	root := Must(Unmarshal([]byte(`[1, 2, "x", 4]`)))
+
+	var visited []int
+	err := root.ArrayEachErr(func(i int, elem *Node) error {
+		visited = append(visited, i)
+		if !elem.IsNumber() {
+			return errStop
+		}
+
+		return elem.SetNumber(elem.MustNumeric() * 2)
+	})
+
+	if err != errStop {
+		t.Errorf("expected callback error, got %v", err)
+	}
+
+	if fmt.Sprint(visited) != "[0 1 2]" {
+		t.Errorf("visited = %v, want [0 1 2]", visited)
+	}
+
+	if got := root.MustIndex(1).MustNumeric(); got != 4 {
+		t.Errorf("element 1 = %v, want 4", got)
+	}
+
+	broken := ArrayNode("", []*Node{NullNode("")})
+	broken.next["5"] = broken.next["0"]
+	delete(broken.next, "0")
+	if err := broken.ArrayEachErr(func(int, *Node) error { return nil }); !errors.Is(err, ErrIndexOutOfRange) {
+		t.Errorf("expected retrieval error, got %v", err)
+	}
+
+	if err := NullNode("").ArrayEachErr(func(int, *Node) error { return nil }); !errors.Is(err, ErrTypeMismatch) {
+		t.Errorf("expected type error, got %v", err)
+	}
+
+	if err := (*Node)(nil).ArrayEachErr(nil); err != ErrNilNode {
+		t.Errorf("expected ErrNilNode, got %v", err)
+	}
+}
+
 func TestNode_Key(t *testing.T) {
 	root, err := Unmarshal([]byte(`{"foo": true, "bar": null, "baz": 123, "biz": [1,2,3]}`))
 	if err != nil {
@@ -1116,6 +2222,45 @@ func TestNode_Size(t *testing.T) {
 	}
 }
 
+func TestNode_ArrayLength(t *testing.T) {
+	tests := []struct {
+		name     string
+		node     *Node
+		expected int
+		err      error
+	}{
+		{"array", Must(Unmarshal(sampleArr)), 6, nil},
+		{"empty array", Must(Unmarshal([]byte(`[]`))), 0, nil},
+		{"built array", ArrayNode("", []*Node{NumberNode("", 1), NullNode("")}), 2, nil},
+		{"object", Must(Unmarshal([]byte(`{"a": 1, "b": 2}`))), 0, ErrTypeMismatch},
+		{"string", StringNode("", "foo"), 0, ErrTypeMismatch},
+		{"null", NullNode(""), 0, ErrTypeMismatch},
+		{"nil", nil, 0, ErrNilNode},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			length, err := tt.node.ArrayLength()
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("ArrayLength() error = %v, want %v", err, tt.err)
+			}
+
+			if length != tt.expected {
+				t.Errorf("ArrayLength() = %d, want %d", length, tt.expected)
+			}
+		})
+	}
+
+	root := Must(Unmarshal([]byte(`[1, 2]`)))
+	if err := root.AppendArray(NumberNode("", 3)); err != nil {
+		t.Fatalf("AppendArray returns error: %v", err)
+	}
+
+	if length, _ := root.ArrayLength(); length != 3 {
+		t.Errorf("ArrayLength() after AppendArray = %d, want 3", length)
+	}
+}
+
 func TestNode_Index(t *testing.T) {
 	root, err := Unmarshal([]byte(`[1, 2, 3, 4, 5, 6]`))
 	if err != nil {
@@ -1231,6 +2376,98 @@ func TestNode_GetKey_Fail(t *testing.T) {
 	}
 }
 
+func TestNode_Coalesce(t *testing.T) {
+	root := Must(Unmarshal([]byte(`{"nickname": null, "name": "foo", "id": 1, "empty": ""}`)))
+
+	tests := []struct {
+		name     string
+		keys     []string
+		expected string
+	}{
+		{name: "first present", keys: []string{"name", "id"}, expected: `"foo"`},
+		{name: "skip null", keys: []string{"nickname", "name"}, expected: `"foo"`},
+		{name: "skip missing", keys: []string{"alias", "id"}, expected: `1`},
+		{name: "empty string is not null", keys: []string{"empty", "name"}, expected: `""`},
+		{name: "none", keys: []string{"nickname", "alias"}},
+		{name: "no keys"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			value := root.Coalesce(tt.keys...)
+			if tt.expected == "" {
+				if value != nil {
+					t.Errorf("expected nil, got %s", value)
+				}
+				return
+			}
+
+			if value == nil || value.String() != tt.expected {
+				t.Errorf("expected %s, got %v", tt.expected, value)
+			}
+		})
+	}
+
+	if (*Node)(nil).Coalesce("a") != nil || ArrayNode("", nil).Coalesce("0") != nil {
+		t.Errorf("non-object node must return nil")
+	}
+}
+
+func TestNode_Dig(t *testing.T) {
+	root := Must(Unmarshal([]byte(`{"store": {"book": [{"title": "foo"}, {"title": "bar"}], "name": "baz"}}`)))
+
+	tests := []struct {
+		name     string
+		segments []interface{}
+		expected string
+		errIs    error
+		errMsg   string
+	}{
+		{name: "no segments", segments: nil, expected: root.String()},
+		{name: "object key", segments: []interface{}{"store", "name"}, expected: `"baz"`},
+		{name: "array index", segments: []interface{}{"store", "book", 1, "title"}, expected: `"bar"`},
+		{name: "negative index", segments: []interface{}{"store", "book", -2, "title"}, expected: `"foo"`},
+		{name: "missing key", segments: []interface{}{"store", "author"}, errIs: ErrKeyNotFound, errMsg: "segment 1 (author)"},
+		{name: "index out of range", segments: []interface{}{"store", "book", 5}, errIs: ErrIndexOutOfRange, errMsg: "segment 2 (5)"},
+		{name: "key on array", segments: []interface{}{"store", "book", "title"}, errIs: ErrTypeMismatch, errMsg: "segment 2 (title)"},
+		{name: "index on object", segments: []interface{}{0}, errIs: ErrTypeMismatch, errMsg: "segment 0 (0)"},
+		{name: "unsupported type", segments: []interface{}{"store", 1.5}, errMsg: "segment 1: unsupported segment type float64"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			node, err := root.Dig(tc.segments...)
+			if tc.errMsg != "" {
+				if err == nil {
+					t.Fatalf("Dig(%v) must fail", tc.segments)
+				}
+
+				if !strings.Contains(err.Error(), tc.errMsg) {
+					t.Errorf("Dig(%v) error = %q, want it to contain %q", tc.segments, err, tc.errMsg)
+				}
+
+				if tc.errIs != nil && !errors.Is(err, tc.errIs) {
+					t.Errorf("Dig(%v) error = %v, want %v", tc.segments, err, tc.errIs)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("Dig(%v) returns error: %v", tc.segments, err)
+			}
+
+			if node.String() != tc.expected {
+				t.Errorf("Dig(%v) = %s, want %s", tc.segments, node, tc.expected)
+			}
+		})
+	}
+
+	if _, err := (*Node)(nil).Dig("a"); !errors.Is(err, ErrNilNode) {
+		t.Errorf("Dig on nil node = %v, want %v", err, ErrNilNode)
+	}
+}
+
 func TestNode_EachKey(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -1324,6 +2561,32 @@ func TestNode_IsEmpty(t *testing.T) {
 	}
 }
 
+func TestNode_IsEmptyContainer(t *testing.T) {
+	tests := []struct {
+		name     string
+		node     *Node
+		expected bool
+	}{
+		{"nil node", (*Node)(nil), false},
+		{"null node", NullNode(""), false},
+		{"empty string", StringNode("", ""), false},
+		{"number", NumberNode("", 0), false},
+		{"empty object", ObjectNode("", nil), true},
+		{"empty array", ArrayNode("", nil), true},
+		{"parsed empty object", Must(Unmarshal([]byte(`{ }`))), true},
+		{"non-empty object", ObjectNode("", map[string]*Node{"foo": BoolNode("foo", true)}), false},
+		{"non-empty array", ArrayNode("", []*Node{BoolNode("0", true)}), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.node.IsEmptyContainer(); got != tt.expected {
+				t.Errorf("%s = %v, want %v", tt.name, got, tt.expected)
+			}
+		})
+	}
+}
+
 func TestNode_Index_EmptyList(t *testing.T) {
 	root, err := Unmarshal([]byte(`[]`))
 	if err != nil {
@@ -1359,11 +2622,74 @@ func TestNode_GetObject(t *testing.T) {
 	}
 }
 
-func TestNode_GetObject_Fail(t *testing.T) {
-	tests := []simpleNode{
-		{"nil node", (*Node)(nil)},
-		{"get object from null node", NullNode("")},
-		{"not object node", NumberNode("", 123)},
+func TestNode_Keys(t *testing.T) {
+	tests := []struct {
+		json     string
+		expected []string
+	}{
+		{`{}`, []string{}},
+		{`{"b": 1, "a": {"c": 2}, "A": null}`, []string{"A", "a", "b"}},
+		{`{"z": 1, "y": 2, "x": 3}`, []string{"x", "y", "z"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.json, func(t *testing.T) {
+			keys, err := Must(Unmarshal([]byte(tt.json))).Keys()
+			if err != nil {
+				t.Fatalf("Keys returns error: %v", err)
+			}
+
+			if strings.Join(keys, ",") != strings.Join(tt.expected, ",") || len(keys) != len(tt.expected) {
+				t.Errorf("Keys() = %v, want %v", keys, tt.expected)
+			}
+		})
+	}
+
+	for _, node := range []*Node{nil, Must(Unmarshal([]byte(`["a"]`))), StringNode("", "a")} {
+		if _, err := node.Keys(); err == nil {
+			t.Errorf("Keys of %v must fail", node)
+		}
+	}
+}
+
+func TestNode_Values(t *testing.T) {
+	tests := []struct {
+		json     string
+		expected []string
+	}{
+		{`{"b": [1, 2], "a": "x", "c": null}`, []string{`"x"`, `[1, 2]`, `null`}},
+		{`[3, {"k": 1}, "y"]`, []string{`3`, `{"k": 1}`, `"y"`}},
+		{`{}`, []string{}},
+		{`[]`, []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.json, func(t *testing.T) {
+			values := Must(Unmarshal([]byte(tt.json))).Values()
+			if values == nil || len(values) != len(tt.expected) {
+				t.Fatalf("Values() = %v, want %v", values, tt.expected)
+			}
+
+			for i, value := range values {
+				if value.String() != tt.expected[i] {
+					t.Errorf("Values()[%d] = %s, want %s", i, value, tt.expected[i])
+				}
+			}
+		})
+	}
+
+	for _, node := range []*Node{nil, StringNode("", "a"), NullNode("")} {
+		if values := node.Values(); values != nil {
+			t.Errorf("Values of %v = %v, want nil", node, values)
+		}
+	}
+}
+
+func TestNode_GetObject_Fail(t *testing.T) {
+	tests := []simpleNode{
+		{"nil node", (*Node)(nil)},
+		{"get object from null node", NullNode("")},
+		{"not object node", NumberNode("", 123)},
 	}
 
 	for _, tt := range tests {
@@ -1375,6 +2701,363 @@ func TestNode_GetObject_Fail(t *testing.T) {
 	}
 }
 
+func TestNode_DeepMerge(t *testing.T) {
+	tests := []struct {
+		name     string
+		base     string
+		other    string
+		arrays   ArrayMergeMode
+		expected string
+	}{
+		{
+			name:     "nested objects",
+			base:     `{"log": {"level": "info", "file": "app.log"}, "port": 80}`,
+			other:    `{"log": {"level": "debug", "format": {"color": true}}, "host": "localhost"}`,
+			expected: `{"log": {"level": "debug", "file": "app.log", "format": {"color": true}}, "port": 80, "host": "localhost"}`,
+		},
+		{
+			name:     "scalar conflict",
+			base:     `{"a": {"b": 1}, "c": "x"}`,
+			other:    `{"a": 2, "c": {"d": true}}`,
+			expected: `{"a": 2, "c": {"d": true}}`,
+		},
+		{
+			name:     "null overwrites",
+			base:     `{"a": {"b": 1}}`,
+			other:    `{"a": null}`,
+			expected: `{"a": null}`,
+		},
+		{
+			name:     "arrays replaced",
+			base:     `{"ports": [80, 443]}`,
+			other:    `{"ports": [8080]}`,
+			expected: `{"ports": [8080]}`,
+		},
+		{
+			name:     "arrays concatenated",
+			base:     `{"ports": [80, 443], "nested": {"tags": ["a"]}}`,
+			other:    `{"ports": [8080], "nested": {"tags": ["b", {"c": 1}]}}`,
+			arrays:   ArrayConcat,
+			expected: `{"ports": [80, 443, 8080], "nested": {"tags": ["a", "b", {"c": 1}]}}`,
+		},
+		{
+			name:     "top-level arrays",
+			base:     `[1]`,
+			other:    `[2, 3]`,
+			arrays:   ArrayConcat,
+			expected: `[1, 2, 3]`,
+		},
+		{
+			name:     "top-level type conflict",
+			base:     `{"a": 1}`,
+			other:    `[1]`,
+			expected: `[1]`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			base := Must(Unmarshal([]byte(tt.base)))
+			other := Must(Unmarshal([]byte(tt.other)))
+
+			if err := base.DeepMergeWith(other, tt.arrays); err != nil {
+				t.Fatalf("DeepMergeWith returns error: %v", err)
+			}
+
+			value, err := Marshal(base)
+			if err != nil {
+				t.Fatalf("Marshal returns error: %v", err)
+			}
+
+			if !equals(Must(Unmarshal(value)), Must(Unmarshal([]byte(tt.expected)))) {
+				t.Errorf("DeepMergeWith = %s, want %s", value, tt.expected)
+			}
+
+			if other.String() != tt.other {
+				t.Errorf("the other node must be left unchanged, got %s", other)
+			}
+		})
+	}
+
+	base := Must(Unmarshal([]byte(`{"a": {"b": 1}}`)))
+	inner := base.MustKey("a")
+	if err := base.DeepMerge(Must(Unmarshal([]byte(`{"a": {"c": 2}}`)))); err != nil {
+		t.Fatalf("DeepMerge returns error: %v", err)
+	}
+
+	if base.MustKey("a") != inner || inner.Size() != 2 {
+		t.Errorf("the nested objects must be merged in place")
+	}
+
+	if err := base.DeepMerge(nil); !errors.Is(err, ErrNilNode) {
+		t.Errorf("DeepMerge with nil = %v, want %v", err, ErrNilNode)
+	}
+}
+
+func TestMergeAll(t *testing.T) {
+	docs := []string{
+		`{"name": "app", "log": {"level": "info", "file": "app.log"}, "ports": [80], "debug": false}`,
+		`{"log": {"level": "debug"}, "ports": [8080], "tags": ["a"]}`,
+		`{"debug": true, "tags": ["b"], "ports": 9090}`,
+	}
+
+	tests := []struct {
+		name     string
+		strategy MergeStrategy
+		expected string
+	}{
+		{
+			name:     "last wins",
+			strategy: MergeLastWins,
+			expected: `{"name": "app", "log": {"level": "debug"}, "ports": 9090, "debug": true, "tags": ["b"]}`,
+		},
+		{
+			name:     "first wins",
+			strategy: MergeFirstWins,
+			expected: `{"name": "app", "log": {"level": "info", "file": "app.log"}, "ports": [80], "debug": false, "tags": ["a"]}`,
+		},
+		{
+			name:     "deep",
+			strategy: MergeDeep,
+			expected: `{"name": "app", "log": {"level": "debug", "file": "app.log"}, "ports": 9090, "debug": true, "tags": ["b"]}`,
+		},
+		{
+			name:     "array concat",
+			strategy: MergeArrayConcat,
+			expected: `{"name": "app", "log": {"level": "debug", "file": "app.log"}, "ports": 9090, "debug": true, "tags": ["a", "b"]}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			nodes := make([]*Node, len(docs))
+			for i, doc := range docs {
+				nodes[i] = Must(Unmarshal([]byte(doc)))
+			}
+
+			result, err := MergeAll(tt.strategy, nodes...)
+			if err != nil {
+				t.Fatalf("MergeAll returns error: %v", err)
+			}
+
+			if expected := Must(Unmarshal([]byte(tt.expected))); !equals(result, expected) {
+				t.Errorf("MergeAll() = %s, want %s", result, tt.expected)
+			}
+
+			for i, node := range nodes {
+				if node.String() != docs[i] || node.Changed() {
+					t.Errorf("document %d = %s, must be unchanged", i, node)
+				}
+			}
+
+			value, err := Marshal(result)
+			if err != nil {
+				t.Fatalf("Marshal returns error: %v", err)
+			}
+
+			if !equals(Must(Unmarshal(value)), result) {
+				t.Errorf("Marshal() = %s, want %s", value, result)
+			}
+		})
+	}
+}
+
+func TestMergeAll_Conflicts(t *testing.T) {
+	tests := []struct {
+		name     string
+		a, b     string
+		expected map[MergeStrategy]string
+	}{
+		{
+			name: "scalars",
+			a:    `{"v": 1}`, b: `{"v": "x"}`,
+			expected: map[MergeStrategy]string{
+				MergeLastWins: `{"v": "x"}`, MergeFirstWins: `{"v": 1}`, MergeDeep: `{"v": "x"}`, MergeArrayConcat: `{"v": "x"}`,
+			},
+		},
+		{
+			name: "arrays",
+			a:    `{"v": [1]}`, b: `{"v": [2]}`,
+			expected: map[MergeStrategy]string{
+				MergeLastWins: `{"v": [2]}`, MergeFirstWins: `{"v": [1]}`, MergeDeep: `{"v": [2]}`, MergeArrayConcat: `{"v": [1, 2]}`,
+			},
+		},
+		{
+			name: "objects",
+			a:    `{"v": {"a": 1, "b": 1}}`, b: `{"v": {"b": 2}}`,
+			expected: map[MergeStrategy]string{
+				MergeLastWins: `{"v": {"b": 2}}`, MergeFirstWins: `{"v": {"a": 1, "b": 1}}`, MergeDeep: `{"v": {"a": 1, "b": 2}}`, MergeArrayConcat: `{"v": {"a": 1, "b": 2}}`,
+			},
+		},
+		{
+			name: "object and array",
+			a:    `{"v": {"a": 1}}`, b: `{"v": [1]}`,
+			expected: map[MergeStrategy]string{
+				MergeLastWins: `{"v": [1]}`, MergeFirstWins: `{"v": {"a": 1}}`, MergeDeep: `{"v": [1]}`, MergeArrayConcat: `{"v": [1]}`,
+			},
+		},
+		{
+			name: "null",
+			a:    `{"v": 1}`, b: `{"v": null}`,
+			expected: map[MergeStrategy]string{
+				MergeLastWins: `{"v": null}`, MergeFirstWins: `{"v": 1}`, MergeDeep: `{"v": null}`, MergeArrayConcat: `{"v": null}`,
+			},
+		},
+		{
+			name: "top-level types",
+			a:    `{"v": 1}`, b: `[1]`,
+			expected: map[MergeStrategy]string{
+				MergeLastWins: `[1]`, MergeFirstWins: `{"v": 1}`, MergeDeep: `[1]`, MergeArrayConcat: `[1]`,
+			},
+		},
+		{
+			name: "top-level arrays",
+			a:    `[1]`, b: `[2]`,
+			expected: map[MergeStrategy]string{
+				MergeLastWins: `[2]`, MergeFirstWins: `[1]`, MergeDeep: `[2]`, MergeArrayConcat: `[1, 2]`,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		for strategy, expected := range tt.expected {
+			t.Run(fmt.Sprintf("%s/%d", tt.name, strategy), func(t *testing.T) {
+				a := Must(Unmarshal([]byte(tt.a)))
+				b := Must(Unmarshal([]byte(tt.b)))
+
+				result, err := MergeAll(strategy, a, b)
+				if err != nil {
+					t.Fatalf("MergeAll returns error: %v", err)
+				}
+
+				if !equals(result, Must(Unmarshal([]byte(expected)))) {
+					t.Errorf("MergeAll() = %s, want %s", result, expected)
+				}
+
+				if result == a || result == b || a.Changed() || b.Changed() {
+					t.Errorf("MergeAll must return a new tree and leave the documents unchanged")
+				}
+			})
+		}
+	}
+}
+
+func TestMergeAll_Fail(t *testing.T) {
+	doc := Must(Unmarshal([]byte(`{"a": 1}`)))
+
+	if _, err := MergeAll(MergeDeep); err != ErrEmptyDocument {
+		t.Errorf("MergeAll() without documents = %v, want ErrEmptyDocument", err)
+	}
+
+	if _, err := MergeAll(MergeDeep, doc, nil); err != ErrNilNode {
+		t.Errorf("MergeAll() with a nil document = %v, want ErrNilNode", err)
+	}
+
+	if _, err := MergeAll(MergeStrategy(-1), doc, doc); err == nil {
+		t.Errorf("MergeAll() with an unknown strategy must fail")
+	}
+
+	single, err := MergeAll(MergeFirstWins, doc)
+	if err != nil || single == doc || !equals(single, doc) {
+		t.Errorf("MergeAll() of a single document = %v, %v, want a copy", single, err)
+	}
+}
+func TestNode_FillDefaults(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		defaults string
+		expected string
+	}{
+		{
+			name:     "missing keys",
+			input:    `{"port": 8080}`,
+			defaults: `{"port": 80, "host": "localhost"}`,
+			expected: `{"port": 8080, "host": "localhost"}`,
+		},
+		{
+			name:     "nested objects",
+			input:    `{"log": {"level": "debug"}}`,
+			defaults: `{"log": {"level": "info", "file": "app.log", "rotate": {"size": 10}}}`,
+			expected: `{"log": {"level": "debug", "file": "app.log", "rotate": {"size": 10}}}`,
+		},
+		{
+			name:     "existing values are kept",
+			input:    `{"a": null, "b": [1], "c": "x"}`,
+			defaults: `{"a": 1, "b": [2, 3], "c": {"d": 1}}`,
+			expected: `{"a": null, "b": [1], "c": "x"}`,
+		},
+		{
+			name:     "empty",
+			input:    `{}`,
+			defaults: `{"a": [{"b": 1}]}`,
+			expected: `{"a": [{"b": 1}]}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := Must(Unmarshal([]byte(tt.input)))
+			defaults := Must(Unmarshal([]byte(tt.defaults)))
+
+			if err := root.FillDefaults(defaults); err != nil {
+				t.Fatalf("FillDefaults returns error: %v", err)
+			}
+
+			if expected := Must(Unmarshal([]byte(tt.expected))); !equals(root, expected) {
+				t.Errorf("got %s, want %s", root, tt.expected)
+			}
+
+			if defaults.Changed() || defaults.String() != tt.defaults {
+				t.Errorf("defaults must not be changed: %s", defaults)
+			}
+		})
+	}
+}
+
+func TestNode_FillDefaults_NotAliased(t *testing.T) {
+	root := Must(Unmarshal([]byte(`{}`)))
+	defaults := Must(Unmarshal([]byte(`{"tags": ["a"]}`)))
+
+	if err := root.FillDefaults(defaults); err != nil {
+		t.Fatalf("FillDefaults returns error: %v", err)
+	}
+
+	tags := root.MustKey("tags")
+	if tags.prev != root || tags.Key() != "tags" || tags.MustIndex(0).prev != tags {
+		t.Errorf("copied value has wrong references")
+	}
+
+	if err := tags.AppendArray(StringNode("", "b")); err != nil {
+		t.Fatalf("AppendArray returns error: %v", err)
+	}
+
+	if defaults.MustKey("tags").Size() != 1 {
+		t.Errorf("defaults must not be aliased")
+	}
+
+	if !root.Changed() {
+		t.Errorf("root must be marked as modified")
+	}
+
+	if err := NullNode("").FillDefaults(defaults); err == nil {
+		t.Errorf("expected error for non-object node")
+	}
+
+	if err := root.FillDefaults(ArrayNode("", nil)); err == nil {
+		t.Errorf("expected error for non-object defaults")
+	}
+
+	var nilNode *Node
+	if err := nilNode.FillDefaults(defaults); err != ErrNilNode {
+		t.Errorf("expected ErrNilNode for nil node, got %v", err)
+	}
+
+	if err := root.FillDefaults(nil); err != ErrNilNode {
+		t.Errorf("expected ErrNilNode for nil defaults, got %v", err)
+	}
+}
+
 func TestNode_ObjectEach(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -1437,6 +3120,72 @@ func TestNode_ObjectEach(t *testing.T) {
 	}
 }
 
+func TestNode_ObjectEachSorted(t *testing.T) {
+	tests := []struct {
+		name     string
+		json     string
+		expected string
+	}{
+		{name: "sorted", json: `{"c": 3, "a": 1, "b": {"z": 0}}`, expected: `a=1 b={"z": 0} c=3`},
+		{name: "unicode keys", json: `{"é": 1, "e": 2, "E": 3}`, expected: `E=3 e=2 é=1`},
+		{name: "empty", json: `{}`},
+		{name: "not an object", json: `[1, 2]`},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			root := Must(Unmarshal([]byte(tc.json)))
+
+			// repeat to make sure the order doesn't depend on the map iteration.
+			for i := 0; i < 5; i++ {
+				var pairs []string
+				root.ObjectEachSorted(func(key string, value *Node) {
+					pairs = append(pairs, key+"="+value.String())
+				})
+
+				if got := strings.Join(pairs, " "); got != tc.expected {
+					t.Fatalf("got %s, want %s", got, tc.expected)
+				}
+			}
+		})
+	}
+}
+
+func TestNode_ObjectEachErr(t *testing.T) {
+	root := Must(Unmarshal([]byte(`{"c": 3, "a": 1, "b": null, "d": 4}`)))
+
+	var visited []string
+	err := root.ObjectEachErr(func(key string, value *Node) error {
+		visited = append(visited, key)
+		if value.IsNull() {
+			return errStop
+		}
+
+		return nil
+	})
+
+	if err != errStop {
+		t.Errorf("expected callback error, got %v", err)
+	}
+
+	if got := strings.Join(visited, " "); got != "a b" {
+		t.Errorf("visited = %s, want a b", got)
+	}
+
+	count := 0
+	if err := root.ObjectEachErr(func(string, *Node) error { count++; return nil }); err != nil || count != 4 {
+		t.Errorf("expected all members to be visited, got count=%d, err=%v", count, err)
+	}
+
+	if err := Must(Unmarshal([]byte(`[]`))).ObjectEachErr(func(string, *Node) error { return nil }); !errors.Is(err, ErrTypeMismatch) {
+		t.Errorf("expected type error, got %v", err)
+	}
+
+	if err := (*Node)(nil).ObjectEachErr(nil); err != ErrNilNode {
+		t.Errorf("expected ErrNilNode, got %v", err)
+	}
+}
+
 func TestNode_ExampleMust(t *testing.T) {
 	data := []byte(`{
         "Image": {
@@ -1648,19 +3397,51 @@ func TestNode_Path2(t *testing.T) {
 	}
 }
 
-func TestNode_Root(t *testing.T) {
-	root := &Node{}
-	child := &Node{prev: root}
-	grandChild := &Node{prev: child}
+func TestNode_Path_EscapedKeys(t *testing.T) {
+	root := Must(Unmarshal([]byte(`{"o'brien": {"a\\b": [{"'\\'": 1}]}, "plain": 2}`)))
 
 	tests := []struct {
-		name string
 		node *Node
-		want *Node
+		want string
 	}{
-		{
-			name: "Root node",
-			node: root,
+		{root.MustKey("o'brien"), `$['o\'brien']`},
+		{root.MustKey("o'brien").MustKey(`a\b`), `$['o\'brien']['a\\b']`},
+		{root.MustKey("o'brien").MustKey(`a\b`).MustIndex(0).MustKey(`'\'`), `$['o\'brien']['a\\b'][0]['\'\\\'']`},
+		{root.MustKey("plain"), `$['plain']`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			path := tt.node.Path()
+			if path != tt.want {
+				t.Fatalf("Path() = %s, want %s", path, tt.want)
+			}
+
+			nodes, err := root.Collect(path)
+			if err != nil {
+				t.Fatalf("Collect(%s) returns error: %v", path, err)
+			}
+
+			if len(nodes) != 1 || nodes[0] != tt.node {
+				t.Errorf("Collect(%s) = %v, want the node itself", path, nodes)
+			}
+		})
+	}
+}
+
+func TestNode_Root(t *testing.T) {
+	root := &Node{}
+	child := &Node{prev: root}
+	grandChild := &Node{prev: child}
+
+	tests := []struct {
+		name string
+		node *Node
+		want *Node
+	}{
+		{
+			name: "Root node",
+			node: root,
 			want: root,
 		},
 		{
@@ -1945,3 +3726,2934 @@ func TestNode_Clone(t *testing.T) {
 		})
 	}
 }
+
+func TestNode_CloneAs(t *testing.T) {
+	root := Must(Unmarshal([]byte(`{"users": [{"name": "foo"}]}`)))
+	user := root.MustKey("users").MustIndex(0)
+
+	owner := user.CloneAs("owner")
+	if owner.prev != nil || owner.Key() != "owner" || owner.index != nil {
+		t.Errorf("CloneAs must return a detached node keyed owner, got key %q", owner.Key())
+	}
+
+	if user.Key() != "" || user.Index() != 0 || user.prev == nil {
+		t.Errorf("the original node must be left in place")
+	}
+
+	other := Must(Unmarshal([]byte(`{}`)))
+	if err := other.AppendObject(owner.Key(), owner); err != nil {
+		t.Fatalf("AppendObject returns error: %v", err)
+	}
+
+	if got := other.MustKey("owner").MustKey("name").MustString(); got != "foo" {
+		t.Errorf("name = %q, want %q", got, "foo")
+	}
+
+	elem := user.CloneAtIndex(3)
+	if elem.prev != nil || elem.key != nil || elem.Index() != 3 {
+		t.Errorf("CloneAtIndex must return a detached node at index 3, got %d", elem.Index())
+	}
+
+	if err := elem.MustKey("name").SetString("bar"); err != nil {
+		t.Fatalf("SetString returns error: %v", err)
+	}
+
+	if user.MustKey("name").MustString() != "foo" {
+		t.Errorf("the clone must not share the children of the original node")
+	}
+}
+
+func TestNode_SourceRange(t *testing.T) {
+	data := []byte(` {"a": [1, true], "b": {"c": "x"}} `)
+	root := Must(Unmarshal(data))
+
+	for _, node := range []*Node{root, root.MustKey("a"), root.MustKey("a").MustIndex(1), root.MustKey("b").MustKey("c")} {
+		start, end, ok := node.SourceRange()
+		if !ok {
+			t.Errorf("SourceRange of %s must be ok", node.Path())
+			continue
+		}
+
+		if got := string(data[start:end]); got != node.String() {
+			t.Errorf("SourceRange of %s = %q, want %q", node.Path(), got, node.String())
+		}
+	}
+
+	if err := root.MustKey("a").MustIndex(0).SetNumber(2); err != nil {
+		t.Fatalf("SetNumber returns error: %v", err)
+	}
+
+	if err := root.MustKey("a").AppendArray(IntNode("", 3)); err != nil {
+		t.Fatalf("AppendArray returns error: %v", err)
+	}
+
+	if err := root.MustKey("b").MustKey("c").SetNumberString("4"); err != nil {
+		t.Fatalf("SetNumberString returns error: %v", err)
+	}
+
+	tests := []struct {
+		node *Node
+		ok   bool
+	}{
+		{root, false},
+		{root.MustKey("a"), false},
+		{root.MustKey("a").MustIndex(0), false},
+		{root.MustKey("a").MustIndex(1), true},
+		{root.MustKey("a").MustIndex(2), false},
+		{root.MustKey("b").MustKey("c"), false},
+		{StringNode("", "foo"), false},
+		{nil, false},
+	}
+
+	for _, tc := range tests {
+		if _, _, ok := tc.node.SourceRange(); ok != tc.ok {
+			t.Errorf("SourceRange of %s: ok = %v, want %v", tc.node.Path(), ok, tc.ok)
+		}
+	}
+
+	if start, end, _ := root.MustKey("a").MustIndex(1).SourceRange(); string(data[start:end]) != "true" {
+		t.Errorf("SourceRange of an unmodified node must still point to the input, got %q", data[start:end])
+	}
+}
+
+func TestNode_Extract(t *testing.T) {
+	root := Must(Unmarshal([]byte(`{"users": [{"name": "foo\u00e9", "age": 1.50, "admin": true, "tags": ["a", null]}], "other": 1}`)))
+	user := root.MustKey("users").MustIndex(0).Extract()
+
+	var check func(node *Node)
+	check = func(node *Node) {
+		if node.data != nil || node.borders != [2]int{} || !node.modified {
+			t.Errorf("node %s still refers to the parsed data", node.Path())
+		}
+
+		for _, child := range node.next {
+			if child.prev != node {
+				t.Errorf("child %s has a wrong parent", child.Path())
+			}
+
+			check(child)
+		}
+	}
+	check(user)
+
+	if user.prev != nil || user.index != nil || user.key != nil {
+		t.Errorf("extracted node must be detached")
+	}
+
+	if got := user.MustKey("name").MustString(); got != "fooé" {
+		t.Errorf("name = %q, want %q", got, "fooé")
+	}
+
+	if got := user.MustKey("tags").MustIndex(1).Type(); got != Null {
+		t.Errorf("tags[1] type = %s, want null", got)
+	}
+
+	expected := Must(Unmarshal([]byte(`{"name": "fooé", "age": 1.5, "admin": true, "tags": ["a", null]}`)))
+	if !equals(user, expected) {
+		t.Errorf("extracted node = %s, want %s", user, expected)
+	}
+
+	if err := user.MustKey("age").SetNumber(2); err != nil {
+		t.Fatalf("SetNumber returns error: %v", err)
+	}
+
+	if root.MustKey("users").MustIndex(0).MustKey("age").MustNumeric() != 1.5 {
+		t.Errorf("the original node must not be changed")
+	}
+
+	if (*Node)(nil).Extract() != nil {
+		t.Errorf("nil node must be extracted as nil")
+	}
+}
+
+func TestNode_Clone_LoadedContainers(t *testing.T) {
+	root := Must(Unmarshal([]byte(`{"a": [1, 2], "b": {"c": 3}}`)))
+
+	// load the containers, so they hold their children.
+	_ = root.MustObject()
+	_ = root.MustKey("a").MustArray()
+	_ = root.MustKey("b").MustObject()
+
+	clone := root.Clone()
+	if clone.MustObject()["a"] == root.MustKey("a") {
+		t.Errorf("Clone().GetObject() returns a child of the original")
+	}
+
+	if clone.MustKey("a").MustArray()[0] == root.MustKey("a").MustIndex(0) {
+		t.Errorf("Clone().GetArray() returns an element of the original")
+	}
+
+	if err := clone.MustKey("a").MustArray()[0].SetNumber(9); err != nil {
+		t.Fatalf("SetNumber returns error: %v", err)
+	}
+
+	if got := root.String(); got != `{"a": [1, 2], "b": {"c": 3}}` {
+		t.Errorf("original = %s, must be unchanged", got)
+	}
+}
+
+func TestNode_Apply(t *testing.T) {
+	input := `{"name": "foo", "price": 1.50, "tags": ["a"], "meta": {"v": 1}}`
+
+	tests := []struct {
+		name     string
+		fn       func(clone *Node) error
+		expected string
+	}{
+		{
+			name:     "set",
+			fn:       func(clone *Node) error { return clone.MustKey("name").SetString("bar") },
+			expected: `{"name": "bar", "price": 1.5, "tags": ["a"], "meta": {"v": 1}}`,
+		},
+		{
+			name:     "append",
+			fn:       func(clone *Node) error { return clone.MustKey("tags").AppendArray(StringNode("", "b")) },
+			expected: `{"name": "foo", "price": 1.5, "tags": ["a", "b"], "meta": {"v": 1}}`,
+		},
+		{
+			name: "loaded containers",
+			fn: func(clone *Node) error {
+				return clone.MustObject()["meta"].MustObject()["v"].SetNumber(2)
+			},
+			expected: `{"name": "foo", "price": 1.5, "tags": ["a"], "meta": {"v": 2}}`,
+		},
+		{
+			name: "delete",
+			fn: func(clone *Node) error {
+				_, err := clone.RemoveKeys("tags", "meta")
+				return err
+			},
+			expected: `{"name": "foo", "price": 1.5}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := Must(Unmarshal([]byte(input)))
+			_ = root.MustObject()
+
+			updated, err := root.Apply(tt.fn)
+			if err != nil {
+				t.Fatalf("Apply returns error: %v", err)
+			}
+
+			if root.String() != input || root.Changed() {
+				t.Errorf("original = %s, must be unchanged", root)
+			}
+
+			value, err := Marshal(updated)
+			if err != nil {
+				t.Fatalf("Marshal returns error: %v", err)
+			}
+
+			if expected := Must(Unmarshal([]byte(tt.expected))); !equals(Must(Unmarshal(value)), expected) {
+				t.Errorf("Apply() = %s, want %s", value, tt.expected)
+			}
+
+			originals := make(map[*Node]bool)
+			root.WalkPath(func(_ string, node *Node) bool {
+				originals[node] = true
+				return true
+			})
+
+			updated.WalkPath(func(path string, node *Node) bool {
+				if originals[node] {
+					t.Errorf("node at %s is shared with the original", path)
+				}
+
+				return true
+			})
+		})
+	}
+}
+
+func TestNode_Apply_Fail(t *testing.T) {
+	root := Must(Unmarshal([]byte(`{"a": 1}`)))
+
+	updated, err := root.Apply(func(clone *Node) error {
+		if err := clone.MustKey("a").SetNumber(2); err != nil {
+			return err
+		}
+
+		return clone.AppendArray(NullNode(""))
+	})
+	if err == nil || updated != nil {
+		t.Errorf("Apply() = %v, %v, want the error of fn", updated, err)
+	}
+
+	if got := root.String(); got != `{"a": 1}` {
+		t.Errorf("original = %s, must be unchanged", got)
+	}
+
+	if _, err := (*Node)(nil).Apply(func(*Node) error { return nil }); err != ErrNilNode {
+		t.Errorf("expected ErrNilNode for nil receiver, got %v", err)
+	}
+}
+
+func TestNode_CopyFrom(t *testing.T) {
+	tests := []struct {
+		name     string
+		dst      string
+		src      string
+		expected string
+	}{
+		{"same shape", `{"id": 1, "tags": ["x"]}`, `{"id": 0, "tags": []}`, `{"id": 0, "tags": []}`},
+		{"stale keys", `{"a": 1, "x": {"y": 2}}`, `{"a": 3}`, `{"a": 3}`},
+		{"new keys", `{}`, `{"a": {"b": [1, 2.50]}}`, `{"a": {"b": [1, 2.50]}}`},
+		{"array", `[1, 2, 3]`, `["a"]`, `["a"]`},
+		{"container to scalar", `{"a": 1}`, `"foo"`, `"foo"`},
+		{"scalar to container", `null`, `{"a": [true]}`, `{"a": [true]}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dst := Must(Unmarshal([]byte(tt.dst)))
+			src := Must(Unmarshal([]byte(tt.src)))
+
+			if err := dst.CopyFrom(src); err != nil {
+				t.Fatalf("CopyFrom returns error: %v", err)
+			}
+
+			if !equals(dst, Must(Unmarshal([]byte(tt.expected)))) {
+				t.Errorf("CopyFrom() = %s, want %s", dst, tt.expected)
+			}
+
+			value, err := Marshal(dst)
+			if err != nil {
+				t.Fatalf("Marshal returns error: %v", err)
+			}
+
+			if !equals(Must(Unmarshal(value)), Must(Unmarshal([]byte(tt.expected)))) {
+				t.Errorf("Marshal() = %s, want %s", value, tt.expected)
+			}
+
+			sources := make(map[*Node]bool)
+			src.WalkPath(func(_ string, node *Node) bool {
+				sources[node] = true
+				return true
+			})
+
+			dst.WalkPath(func(path string, node *Node) bool {
+				if sources[node] {
+					t.Errorf("node at %s is shared with src", path)
+				}
+
+				return true
+			})
+		})
+	}
+}
+
+func TestNode_CopyFrom_Reuse(t *testing.T) {
+	template := Must(Unmarshal([]byte(`{"id": 0, "user": {"name": "foo"}}`)))
+	root := Must(Unmarshal([]byte(`{"doc": {"id": 7, "user": {"name": "bar", "age": 3}}}`)))
+	dst := root.MustKey("doc")
+	user := dst.MustKey("user")
+
+	if err := dst.CopyFrom(template); err != nil {
+		t.Fatalf("CopyFrom returns error: %v", err)
+	}
+
+	if dst.MustKey("user") != user {
+		t.Errorf("the child node with a matching key must be reused")
+	}
+
+	if dst.Key() != "doc" || dst.prev != root {
+		t.Errorf("CopyFrom must keep the position of the node, got key %q", dst.Key())
+	}
+
+	if !equals(root, Must(Unmarshal([]byte(`{"doc": {"id": 0, "user": {"name": "foo"}}}`)))) {
+		t.Errorf("root = %s, must contain the copied value", root)
+	}
+
+	if err := dst.MustKey("user").MustKey("name").SetString("baz"); err != nil {
+		t.Fatalf("SetString returns error: %v", err)
+	}
+
+	if got := template.MustKey("user").MustKey("name").MustString(); got != "foo" {
+		t.Errorf("template name = %q, must be unchanged", got)
+	}
+
+	if err := template.MustKey("id").SetNumber(9); err != nil {
+		t.Fatalf("SetNumber returns error: %v", err)
+	}
+
+	if got := dst.MustKey("id").MustNumeric(); got != 0 {
+		t.Errorf("dst id = %v, must be unchanged", got)
+	}
+}
+
+func TestNode_CopyFrom_Fail(t *testing.T) {
+	root := Must(Unmarshal([]byte(`{"a": {"b": 1}}`)))
+
+	tests := []struct {
+		name string
+		dst  *Node
+		src  *Node
+	}{
+		{"nil dst", nil, root},
+		{"nil src", root, nil},
+		{"src is descendant", root, root.MustKey("a").MustKey("b")},
+		{"dst is descendant", root.MustKey("a"), root},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.dst.CopyFrom(tt.src); err == nil {
+				t.Errorf("CopyFrom() must fail")
+			}
+		})
+	}
+
+	if got := root.String(); got != `{"a": {"b": 1}}` {
+		t.Errorf("root = %s, must be unchanged", got)
+	}
+}
+
+func BenchmarkNode_CopyFrom(b *testing.B) {
+	template := Must(Unmarshal([]byte(`{"id": 0, "user": {"name": "foo", "tags": ["a", "b", "c"]}, "items": [1, 2, 3, 4]}`)))
+
+	b.Run("Clone", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			_ = template.Clone()
+		}
+	})
+
+	b.Run("CopyFrom", func(b *testing.B) {
+		dst := ObjectNode("", nil)
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if err := dst.CopyFrom(template); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+func TestNode_Freeze(t *testing.T) {
+	input := `{"name": "foo", "tags": ["a", "b"], "meta": {"v": 1.50}}`
+
+	tests := []struct {
+		name   string
+		mutate func(root *Node) error
+	}{
+		{"Set", func(root *Node) error { return root.MustKey("name").Set(1) }},
+		{"SetString", func(root *Node) error { return root.MustKey("name").SetString("bar") }},
+		{"SetNull", func(root *Node) error { return root.SetNull() }},
+		{"SetNumberString", func(root *Node) error { return root.MustKey("meta").MustKey("v").SetNumberString("2") }},
+		{"SetNode", func(root *Node) error { return root.MustKey("meta").SetNode(NullNode("")) }},
+		{"Replace", func(root *Node) error { return root.MustKey("tags").Replace([]byte(`[]`)) }},
+		{"SetIndex", func(root *Node) error { return root.MustKey("tags").SetIndex(0, StringNode("", "x")) }},
+		{"SwapIndex", func(root *Node) error { return root.MustKey("tags").SwapIndex(0, 1) }},
+		{"AppendArray", func(root *Node) error { return root.MustKey("tags").AppendArray(StringNode("", "c")) }},
+		{"AppendObject", func(root *Node) error { return root.AppendObject("x", NullNode("")) }},
+		{"Prepend", func(root *Node) error { return root.MustKey("tags").Prepend(StringNode("", "c")) }},
+		{"Concat", func(root *Node) error { return root.MustKey("tags").Concat(Must(Unmarshal([]byte(`["c"]`)))) }},
+		{"Resize", func(root *Node) error { return root.MustKey("tags").Resize(1, nil) }},
+		{"Delete", func(root *Node) error { return root.MustKey("name").Delete() }},
+		{"DeleteIndex", func(root *Node) error { return root.MustKey("tags").DeleteIndex(0) }},
+		{"PopIndex", func(root *Node) error { _, err := root.MustKey("tags").PopIndex(0); return err }},
+		{"RemoveKeys", func(root *Node) error { _, err := root.RemoveKeys("name"); return err }},
+		{"RetainKeys", func(root *Node) error { return root.RetainKeys("name") }},
+		{"MoveTo", func(root *Node) error { return root.MustKey("name").MoveTo(ObjectNode("", nil), "name") }},
+		{"MoveToIndex", func(root *Node) error { return StringNode("", "c").MoveToIndex(root.MustKey("tags"), 0) }},
+		{"FillDefaults", func(root *Node) error { return root.FillDefaults(Must(Unmarshal([]byte(`{"meta": {"w": 2}}`)))) }},
+		{"DeepMerge", func(root *Node) error { return root.DeepMerge(Must(Unmarshal([]byte(`{"name": "bar"}`)))) }},
+		{"SortArrayByPath", func(root *Node) error { return root.MustKey("tags").SortArrayByPath("$", false) }},
+		{"CopyFrom", func(root *Node) error { return root.MustKey("meta").CopyFrom(Must(Unmarshal([]byte(`{}`)))) }},
+		{"Normalize", func(root *Node) error { return root.Normalize() }},
+		{"UnmarshalInto", func(root *Node) error { return root.UnmarshalInto([]byte(`[]`)) }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := Must(Unmarshal([]byte(input)))
+			root.Freeze()
+
+			if err := tt.mutate(root); !errors.Is(err, ErrImmutableNode) {
+				t.Errorf("%s on a frozen node = %v, want ErrImmutableNode", tt.name, err)
+			}
+
+			if root.Changed() || root.String() != input {
+				t.Errorf("root = %s, must be unchanged", root)
+			}
+		})
+	}
+}
+
+func TestNode_Freeze_Reads(t *testing.T) {
+	root := Must(Unmarshal([]byte(`{"name": "foo", "tags": ["a", "b"], "meta": {"v": 1.50}}`)))
+	root.Freeze()
+
+	if !root.IsFrozen() || !root.MustKey("meta").MustKey("v").IsFrozen() {
+		t.Errorf("Freeze must freeze the node and its descendants")
+	}
+
+	if got := root.MustKey("name").MustString(); got != "foo" {
+		t.Errorf("name = %q, want %q", got, "foo")
+	}
+
+	if got := root.MustKey("meta").MustKey("v").MustNumeric(); got != 1.5 {
+		t.Errorf("v = %v, want 1.5", got)
+	}
+
+	if tags, err := root.MustKey("tags").ArrayLength(); err != nil || tags != 2 {
+		t.Errorf("ArrayLength() = %d, %v, want 2", tags, err)
+	}
+
+	if nodes, err := root.Collect("$.tags[*]"); err != nil || len(nodes) != 2 {
+		t.Errorf("Collect() = %v, %v, want 2 nodes", nodes, err)
+	}
+
+	value, err := Marshal(root)
+	if err != nil {
+		t.Fatalf("Marshal returns error: %v", err)
+	}
+
+	if string(value) != `{"name": "foo", "tags": ["a", "b"], "meta": {"v": 1.50}}` {
+		t.Errorf("Marshal() = %s", value)
+	}
+
+	if root.Hash() != root.Clone().Hash() {
+		t.Errorf("Hash of a frozen node must match its clone")
+	}
+
+	clone := root.Clone()
+	if clone.IsFrozen() || clone.MustKey("tags").IsFrozen() {
+		t.Errorf("Clone of a frozen node must be mutable")
+	}
+
+	if err := clone.MustKey("tags").AppendArray(StringNode("", "c")); err != nil {
+		t.Errorf("AppendArray on a clone returns error: %v", err)
+	}
+
+	if got := root.MustKey("tags").Size(); got != 2 {
+		t.Errorf("frozen tags size = %d, must be unchanged", got)
+	}
+
+	if (*Node)(nil).IsFrozen() {
+		t.Errorf("nil node must not be frozen")
+	}
+
+	(*Node)(nil).Freeze()
+}
+
+func TestNode_Freeze_Subtree(t *testing.T) {
+	template := Must(Unmarshal([]byte(`{"v": 1}`)))
+	template.Freeze()
+
+	doc := Must(Unmarshal([]byte(`{"a": [], "b": 2}`)))
+	if err := doc.MustKey("a").AppendArray(template); !errors.Is(err, ErrImmutableNode) {
+		t.Errorf("AppendArray of a frozen node = %v, want ErrImmutableNode", err)
+	}
+
+	if err := doc.MustKey("a").AppendArray(template.Clone()); err != nil {
+		t.Fatalf("AppendArray of a clone returns error: %v", err)
+	}
+
+	if template.prev != nil {
+		t.Errorf("the frozen template must stay detached")
+	}
+
+	// a frozen subtree in a mutable tree.
+	frozen := doc.MustKey("a").MustIndex(0)
+	frozen.Freeze()
+
+	if err := frozen.MustKey("v").SetNumber(2); !errors.Is(err, ErrImmutableNode) {
+		t.Errorf("SetNumber in a frozen subtree = %v, want ErrImmutableNode", err)
+	}
+
+	if err := doc.MustKey("b").SetNumber(3); err != nil {
+		t.Errorf("SetNumber outside the frozen subtree returns error: %v", err)
+	}
+
+	if err := doc.CopyFrom(Must(Unmarshal([]byte(`{"a": [{"v": 9}]}`)))); err != nil {
+		t.Fatalf("CopyFrom returns error: %v", err)
+	}
+
+	if got := frozen.MustKey("v").MustNumeric(); got != 1 {
+		t.Errorf("frozen v = %v, must not be overwritten by CopyFrom", got)
+	}
+
+	if got := doc.MustKey("a").MustIndex(0).MustKey("v").MustNumeric(); got != 9 {
+		t.Errorf("copied v = %v, want 9", got)
+	}
+
+	if frozen.prev != nil {
+		t.Errorf("the frozen subtree must be detached by CopyFrom")
+	}
+
+	root := Must(Unmarshal([]byte(`{"keep": {"x": 1}, "y": 2}`)))
+	keep := root.MustKey("keep")
+	keep.Freeze()
+
+	if err := root.UnmarshalInto([]byte(`{"keep": {"x": 5}}`)); err != nil {
+		t.Fatalf("UnmarshalInto returns error: %v", err)
+	}
+
+	if got := keep.MustKey("x").MustNumeric(); got != 1 {
+		t.Errorf("frozen x = %v, must not be recycled by UnmarshalInto", got)
+	}
+
+	if got := root.MustKey("keep").MustKey("x").MustNumeric(); got != 5 {
+		t.Errorf("decoded x = %v, want 5", got)
+	}
+
+	parent := Must(Unmarshal([]byte(`{"keep": {"x": 1}, "y": 2}`)))
+	parent.MustKey("keep").Freeze()
+
+	if removed, err := parent.RemoveKeys("keep"); err != nil || removed != 1 {
+		t.Errorf("RemoveKeys of a frozen member = %d, %v, want it removed by its parent", removed, err)
+	}
+}
+
+func TestNode_SanitizeUTF8_Frozen(t *testing.T) {
+	root := ObjectNode("", map[string]*Node{"k\xff": StringNode("", "v")})
+	root.Freeze()
+
+	if _, err := root.SanitizeUTF8(); !errors.Is(err, ErrImmutableNode) {
+		t.Errorf("SanitizeUTF8 on a frozen node = %v, want ErrImmutableNode", err)
+	}
+
+	if !root.HasKey("k\xff") {
+		t.Errorf("the key must be unchanged")
+	}
+}
+
+func TestNode_Normalize(t *testing.T) {
+	users := `[{"name": "fooé", "age": 1.50, "id": 12345678901234567891, "admin": true, "tags": ["a", null]}]`
+	input := []byte(`{"users": ` + users + `, "meta": {"v": 1}}`)
+
+	root := Must(Unmarshal(input))
+	if err := root.MustKey("meta").MustKey("v").SetNumber(2); err != nil {
+		t.Fatalf("SetNumber returns error: %v", err)
+	}
+
+	if err := root.Normalize(); err != nil {
+		t.Fatalf("Normalize returns error: %v", err)
+	}
+
+	// the nodes must not refer to the parsed data anymore.
+	for i := range input {
+		input[i] = 'x'
+	}
+
+	for _, node := range []*Node{root, root.MustKey("meta"), root.MustKey("meta").MustKey("v")} {
+		if node.data != nil || !node.modified {
+			t.Errorf("modified node %s still refers to the parsed data", node.Path())
+		}
+	}
+
+	list := root.MustKey("users")
+	if list.Changed() || len(list.data) != len(users) {
+		t.Errorf("users must stay unmodified with a buffer holding only its source, got %d bytes", len(list.data))
+	}
+
+	user := list.MustIndex(0)
+	if got := user.MustKey("name").MustString(); got != "fooé" {
+		t.Errorf("name = %q, want %q", got, "fooé")
+	}
+
+	// the unmodified nodes keep their literals and key order.
+	value, err := Marshal(list)
+	if err != nil {
+		t.Fatalf("Marshal returns error: %v", err)
+	}
+
+	if string(value) != users {
+		t.Errorf("Marshal = %s, want %s", value, users)
+	}
+
+	if got := user.MustKey("tags").String(); got != `["a", null]` {
+		t.Errorf("tags = %s, want %s", got, `["a", null]`)
+	}
+
+	value, err = Marshal(root)
+	if err != nil {
+		t.Fatalf("Marshal returns error: %v", err)
+	}
+
+	expected := Must(Unmarshal([]byte(`{"users": ` + users + `, "meta": {"v": 2}}`)))
+	if !equals(Must(Unmarshal(value)), expected) || !bytes.Contains(value, []byte(users)) {
+		t.Errorf("Marshal = %s, want %s", value, expected)
+	}
+
+	ordered := Must(Unmarshal([]byte(`{"b": 1, "a": [2.0], "c": 3}`)))
+	if err := ordered.MustKey("c").SetNumber(4); err != nil {
+		t.Fatalf("SetNumber returns error: %v", err)
+	}
+
+	if err := ordered.Normalize(); err != nil {
+		t.Fatalf("Normalize returns error: %v", err)
+	}
+
+	var keys []string
+	for _, child := range ordered.children() {
+		keys = append(keys, child.Key())
+	}
+
+	if got := strings.Join(keys, ","); got != "b,a,c" || string(ordered.MustKey("a").data) != "1[2.0]" {
+		t.Errorf("keys = %s, data = %q, the document order must be kept", got, ordered.MustKey("a").data)
+	}
+
+	if err := (*Node)(nil).Normalize(); !errors.Is(err, ErrNilNode) {
+		t.Errorf("Normalize on nil node = %v, want %v", err, ErrNilNode)
+	}
+}
+
+func TestNode_Normalize_InvalidSource(t *testing.T) {
+	root := Must(Unmarshal([]byte(`{"a": 1, "b": true}`)))
+	b := root.MustKey("b")
+	b.data = []byte("tru")
+	b.borders = [2]int{0, 3}
+
+	if err := root.Normalize(); err == nil {
+		t.Fatalf("Normalize must fail on an invalid source")
+	}
+
+	if root.MustKey("a").data == nil || root.modified {
+		t.Errorf("nothing must be changed when Normalize fails")
+	}
+}
+
+func TestNode_ValidateUTF8(t *testing.T) {
+	tests := []struct {
+		name string
+		node *Node
+		err  string
+	}{
+		{
+			name: "valid",
+			node: Must(Unmarshal([]byte(`{"a": ["café", "x"], "b": {"c": 1}}`))),
+		},
+		{
+			name: "parsed value",
+			node: Must(Unmarshal([]byte("{\"a\": \"ok\", \"b\": [1, \"ab\xff\"]}"))),
+			err:  "$['b'][1]: invalid UTF-8 encoding in string value at byte 2",
+		},
+		{
+			name: "built value",
+			node: ObjectNode("", map[string]*Node{"a": StringNode("", "fine"), "b": StringNode("", "\xc0\xaf")}),
+			err:  "$['b']: invalid UTF-8 encoding in string value at byte 0",
+		},
+		{
+			name: "truncated sequence",
+			node: StringNode("", "caf\xc3"),
+			err:  "$: invalid UTF-8 encoding in string value at byte 3",
+		},
+		{
+			name: "parsed key",
+			node: Must(Unmarshal([]byte("{\"a\": 1, \"k\\n\xfe\": \"v\"}"))),
+			err:  "invalid UTF-8 encoding in object key at byte 3",
+		},
+		{
+			name: "built key",
+			node: ObjectNode("", map[string]*Node{"k\xfe": NullNode("")}),
+			err:  "invalid UTF-8 encoding in object key at byte 1",
+		},
+		{
+			name: "first in document order",
+			node: Must(Unmarshal([]byte("{\"z\": \"\xff\", \"a\": \"\xfe\"}"))),
+			err:  "$['z']: invalid UTF-8 encoding in string value at byte 0",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.node.ValidateUTF8()
+			if tt.err == "" {
+				if err != nil {
+					t.Errorf("ValidateUTF8() returns error: %v", err)
+				}
+
+				return
+			}
+
+			if !errors.Is(err, ErrInvalidUTF8) {
+				t.Fatalf("ValidateUTF8() = %v, want ErrInvalidUTF8", err)
+			}
+
+			if !strings.HasSuffix(err.Error(), tt.err) {
+				t.Errorf("ValidateUTF8() = %q, want %q", err, tt.err)
+			}
+		})
+	}
+
+	if err := (*Node)(nil).ValidateUTF8(); err != ErrNilNode {
+		t.Errorf("expected ErrNilNode for nil receiver, got %v", err)
+	}
+}
+
+func TestNode_SanitizeUTF8(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		count    int
+		expected string
+	}{
+		{"valid", `{"a": ["café"], "b": 1.50}`, 0, `{"a": ["café"], "b": 1.50}`},
+		{"values", "{\"a\": \"x\xffy\", \"b\": [\"\xc0\xaf\", \"ok\"]}", 2, "{\"a\": \"x�y\", \"b\": [\"��\", \"ok\"]}"},
+		{"keys", "{\"k\xfe\": {\"\xff\": \"v\xff\"}}", 3, "{\"k�\": {\"�\": \"v�\"}}"},
+		{"truncated sequence", "[\"caf\xc3\"]", 1, "[\"caf�\"]"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := Must(Unmarshal([]byte(tt.input)))
+
+			count, err := root.SanitizeUTF8()
+			if err != nil {
+				t.Fatalf("SanitizeUTF8 returns error: %v", err)
+			}
+
+			if count != tt.count {
+				t.Errorf("SanitizeUTF8() = %d, want %d", count, tt.count)
+			}
+
+			if err := root.ValidateUTF8(); err != nil {
+				t.Errorf("ValidateUTF8 after SanitizeUTF8 returns error: %v", err)
+			}
+
+			value, err := Marshal(root)
+			if err != nil {
+				t.Fatalf("Marshal returns error: %v", err)
+			}
+
+			if !utf8.Valid(value) {
+				t.Errorf("Marshal() = %q, must be valid UTF-8", value)
+			}
+
+			if !equals(Must(Unmarshal(value)), Must(Unmarshal([]byte(tt.expected)))) {
+				t.Errorf("SanitizeUTF8() = %s, want %s", value, tt.expected)
+			}
+		})
+	}
+}
+
+func TestNode_SanitizeUTF8_BuiltNodes(t *testing.T) {
+	root := ObjectNode("", map[string]*Node{
+		"name\xff": StringNode("", "caf\xe9"),
+		"tags":     ArrayNode("", []*Node{StringNode("", "ok"), StringNode("", "\xed\xa0\x80")}),
+	})
+
+	count, err := root.SanitizeUTF8()
+	if err != nil {
+		t.Fatalf("SanitizeUTF8 returns error: %v", err)
+	}
+
+	if count != 3 {
+		t.Errorf("SanitizeUTF8() = %d, want 3", count)
+	}
+
+	if got := root.MustKey("name\ufffd").MustString(); got != "caf\ufffd" {
+		t.Errorf("name = %q, want %q", got, "caf\ufffd")
+	}
+
+	if got := root.MustKey("tags").MustIndex(1).MustString(); got != "\ufffd\ufffd\ufffd" {
+		t.Errorf("tags[1] = %q, want three U+FFFD", got)
+	}
+
+	if err := root.ValidateUTF8(); err != nil {
+		t.Errorf("ValidateUTF8 after SanitizeUTF8 returns error: %v", err)
+	}
+}
+
+func TestNode_SanitizeUTF8_Fail(t *testing.T) {
+	tests := []struct {
+		name string
+		node *Node
+	}{
+		{"existing key", ObjectNode("", map[string]*Node{"a\xff": NumberNode("", 1), "a\ufffd": NumberNode("", 2), "b": StringNode("", "\xfe")})},
+		{"repaired keys", ObjectNode("", map[string]*Node{"a\xff": NumberNode("", 1), "a\xfe": NumberNode("", 2)})},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			before := tt.node.Clone()
+
+			if _, err := tt.node.SanitizeUTF8(); err == nil {
+				t.Errorf("SanitizeUTF8 must fail for colliding keys")
+			}
+
+			if !equals(tt.node, before) || tt.node.Size() != before.Size() {
+				t.Errorf("node = %s, must be unchanged", tt.node)
+			}
+		})
+	}
+
+	if _, err := (*Node)(nil).SanitizeUTF8(); err != ErrNilNode {
+		t.Errorf("expected ErrNilNode for nil receiver, got %v", err)
+	}
+}
+
+func TestNode_MoveTo(t *testing.T) {
+	root := Must(Unmarshal([]byte(`{"a":{"b":1},"c":{}}`)))
+	b := root.MustKey("a").MustKey("b")
+
+	if err := b.MoveTo(root.MustKey("c"), "d"); err != nil {
+		t.Fatalf("MoveTo returns error: %v", err)
+	}
+
+	if root.MustKey("a").HasKey("b") {
+		t.Errorf("moved node must be removed from the old parent")
+	}
+
+	if b.prev != root.MustKey("c") || b.Key() != "d" {
+		t.Errorf("moved node has wrong reference: prev=%v, key=%s", b.prev, b.Key())
+	}
+
+	if got := root.MustKey("c").String(); got != `{"d":1}` {
+		t.Errorf("unexpected result: %s", got)
+	}
+
+	if got := root.MustKey("a").String(); got != `{}` {
+		t.Errorf("unexpected result: %s", got)
+	}
+}
+
+func TestNode_MoveTo_Fail(t *testing.T) {
+	root := Must(Unmarshal([]byte(`{"a":{"b":{"c":1}},"arr":[1]}`)))
+	a := root.MustKey("a")
+
+	tests := []struct {
+		name   string
+		node   *Node
+		parent *Node
+	}{
+		{name: "nil node", node: nil, parent: root},
+		{name: "nil parent", node: a, parent: nil},
+		{name: "non-object parent", node: a, parent: root.MustKey("arr")},
+		{name: "same node", node: a, parent: a},
+		{name: "descendant", node: a, parent: a.MustKey("b")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.node.MoveTo(tt.parent, "x"); err == nil {
+				t.Errorf("expected error")
+			}
+		})
+	}
+
+	if !root.HasKey("a") || !a.MustKey("b").HasKey("c") {
+		t.Errorf("failed move must not change the tree")
+	}
+}
+
+func TestNode_MoveToIndex(t *testing.T) {
+	tests := []struct {
+		name     string
+		json     string
+		from     func(root *Node) *Node
+		to       func(root *Node) *Node
+		index    int
+		expected string
+		fail     bool
+	}{
+		{
+			name:     "object member into array front",
+			json:     `{"a":1,"arr":[2,3]}`,
+			from:     func(root *Node) *Node { return root.MustKey("a") },
+			to:       func(root *Node) *Node { return root.MustKey("arr") },
+			index:    0,
+			expected: `[1,2,3]`,
+		},
+		{
+			name:     "object member into array end",
+			json:     `{"a":1,"arr":[2,3]}`,
+			from:     func(root *Node) *Node { return root.MustKey("a") },
+			to:       func(root *Node) *Node { return root.MustKey("arr") },
+			index:    2,
+			expected: `[2,3,1]`,
+		},
+		{
+			name:     "within the same array",
+			json:     `{"arr":[1,2,3]}`,
+			from:     func(root *Node) *Node { return root.MustKey("arr").MustIndex(0) },
+			to:       func(root *Node) *Node { return root.MustKey("arr") },
+			index:    2,
+			expected: `[2,3,1]`,
+		},
+		{
+			name:  "index out of range",
+			json:  `{"a":1,"arr":[2,3]}`,
+			from:  func(root *Node) *Node { return root.MustKey("a") },
+			to:    func(root *Node) *Node { return root.MustKey("arr") },
+			index: 3,
+			fail:  true,
+		},
+		{
+			name:  "non-array parent",
+			json:  `{"a":1,"obj":{}}`,
+			from:  func(root *Node) *Node { return root.MustKey("a") },
+			to:    func(root *Node) *Node { return root.MustKey("obj") },
+			index: 0,
+			fail:  true,
+		},
+		{
+			name:  "into its own descendant",
+			json:  `{"arr":[[1]]}`,
+			from:  func(root *Node) *Node { return root.MustKey("arr") },
+			to:    func(root *Node) *Node { return root.MustKey("arr").MustIndex(0) },
+			index: 0,
+			fail:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := Must(Unmarshal([]byte(tt.json)))
+			node, parent := tt.from(root), tt.to(root)
+
+			err := node.MoveToIndex(parent, tt.index)
+			if tt.fail {
+				if err == nil {
+					t.Errorf("expected error")
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if node.Index() != tt.index || node.prev != parent {
+				t.Errorf("moved node has wrong reference: index=%d", node.Index())
+			}
+
+			if got := parent.String(); got != tt.expected {
+				t.Errorf("unexpected result: %s, expected %s", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestNode_SwapIndex(t *testing.T) {
+	tests := []struct {
+		json     string
+		i, j     int
+		expected string
+		fail     bool
+	}{
+		{`[1,2,3]`, 0, 2, `[3,2,1]`, false},
+		{`[1,2,3]`, 2, 0, `[3,2,1]`, false},
+		{`[1,2,3]`, 1, 1, `[1,2,3]`, false},
+		{`[{"a":1},[2],"3"]`, 0, 1, `[[2],{"a":1},"3"]`, false},
+		{`[1,2,3]`, 0, 3, ``, true},
+		{`[1,2,3]`, -1, 0, ``, true},
+		{`[]`, 0, 0, ``, true},
+		{`{"a":1}`, 0, 0, ``, true},
+		{`1`, 0, 0, ``, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.json, func(t *testing.T) {
+			root := Must(Unmarshal([]byte(test.json)))
+			err := root.SwapIndex(test.i, test.j)
+			if test.fail {
+				if err == nil {
+					t.Errorf("Expected error")
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			if result := root.String(); result != test.expected {
+				t.Errorf("Unexpected result: %s, expected %s", result, test.expected)
+			}
+
+			root.ArrayEach(func(i int, elem *Node) {
+				if elem.Index() != i {
+					t.Errorf("wrong index at %d: %d", i, elem.Index())
+				}
+			})
+		})
+	}
+}
+
+func TestNode_SetIndex(t *testing.T) {
+	tests := []struct {
+		json     string
+		idx      int
+		value    *Node
+		expected string
+		fail     bool
+	}{
+		{`[1,2,3]`, 1, StringNode("", "x"), `[1,"x",3]`, false},
+		{`[1,2,3]`, 3, NumberNode("", 4), `[1,2,3,4]`, false},
+		{`[1,2]`, 4, BoolNode("", true), `[1,2,null,null,true]`, false},
+		{`[]`, 1, NumberNode("", 1), `[null,1]`, false},
+		{`[1,2,3]`, -1, NullNode(""), `[1,2,null]`, false},
+		{`[1,2,3]`, -3, ArrayNode("", nil), `[[],2,3]`, false},
+		{`[1,2,3]`, -4, NullNode(""), ``, true},
+		{`[]`, -1, NullNode(""), ``, true},
+		{`[1]`, 0, nil, ``, true},
+		{`{"a":1}`, 0, NullNode(""), ``, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.json, func(t *testing.T) {
+			root := Must(Unmarshal([]byte(test.json)))
+			err := root.SetIndex(test.idx, test.value)
+			if test.fail {
+				if err == nil {
+					t.Errorf("Expected error")
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			if result := root.String(); result != test.expected {
+				t.Errorf("Unexpected result: %s, expected %s", result, test.expected)
+			}
+
+			root.ArrayEach(func(i int, elem *Node) {
+				if elem.Index() != i || elem.prev != root {
+					t.Errorf("wrong reference at %d: %d", i, elem.Index())
+				}
+			})
+		})
+	}
+}
+
+func TestNode_SetIndex_Owned(t *testing.T) {
+	root := Must(Unmarshal([]byte(`{"a": [1], "b": [2, 3]}`)))
+	b := root.MustKey("b")
+
+	if err := root.MustKey("a").SetIndex(0, b.MustIndex(1)); err != nil {
+		t.Fatalf("SetIndex returns error: %v", err)
+	}
+
+	if got := root.MustKey("a").String(); got != `[3]` {
+		t.Errorf("a = %s, want [3]", got)
+	}
+
+	if got := b.String(); got != `[2, 3]` {
+		t.Errorf("b = %s, want the original [2, 3]", got)
+	}
+}
+
+func TestNode_SetIndex_Cycle(t *testing.T) {
+	arr := Must(Unmarshal([]byte(`[1, 2]`)))
+	if err := arr.SetIndex(0, arr); err == nil {
+		t.Errorf("SetIndex of the array itself must fail")
+	}
+
+	root := Must(Unmarshal([]byte(`{"a": {"b": [1]}}`)))
+	b := root.MustKey("a").MustKey("b")
+
+	for _, ancestor := range []*Node{root, root.MustKey("a")} {
+		if err := b.SetIndex(0, ancestor); err == nil {
+			t.Errorf("SetIndex of the ancestor %s must fail", ancestor.Path())
+		}
+	}
+
+	for _, node := range []*Node{arr, root} {
+		if _, err := Marshal(node); err != nil {
+			t.Errorf("Marshal returns error: %v", err)
+		}
+	}
+
+	if got := root.String(); got != `{"a": {"b": [1]}}` || arr.String() != `[1, 2]` {
+		t.Errorf("root = %s, arr = %s, must be unchanged", got, arr)
+	}
+}
+
+func TestNode_PathSegments(t *testing.T) {
+	root := Must(Unmarshal([]byte(`{"key": {"sub": ["val1", "val2"], "a.b": {"c'd": null}}}`)))
+
+	tests := []struct {
+		name     string
+		node     *Node
+		expected []string
+	}{
+		{
+			name:     "root",
+			node:     root,
+			expected: []string{},
+		},
+		{
+			name:     "object member",
+			node:     root.MustKey("key"),
+			expected: []string{"key"},
+		},
+		{
+			name:     "array element",
+			node:     root.MustKey("key").MustKey("sub").MustIndex(1),
+			expected: []string{"key", "sub", "1"},
+		},
+		{
+			name:     "keys with special characters",
+			node:     root.MustKey("key").MustKey("a.b").MustKey("c'd"),
+			expected: []string{"key", "a.b", "c'd"},
+		},
+		{
+			name:     "built array element",
+			node:     ObjectNode("", map[string]*Node{"a": ArrayNode("a", []*Node{NumberNode("", 1), StringNode("", "x")})}).MustKey("a").MustIndex(1),
+			expected: []string{"a", "1"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.node.PathSegments()
+			if got == nil || len(got) != len(tt.expected) {
+				t.Fatalf("PathSegments() = %v, want %v", got, tt.expected)
+			}
+
+			for i := range got {
+				if got[i] != tt.expected[i] {
+					t.Errorf("PathSegments() = %v, want %v", got, tt.expected)
+				}
+			}
+		})
+	}
+
+	if (*Node)(nil).PathSegments() != nil {
+		t.Errorf("PathSegments() of nil node must be nil")
+	}
+}
+
+func TestPathsUnique(t *testing.T) {
+	root := Must(Unmarshal([]byte(`{"a": [1, 2], "b": {"c": true}}`)))
+	a0 := root.MustKey("a").MustIndex(0)
+	a1 := root.MustKey("a").MustIndex(1)
+	c := root.MustKey("b").MustKey("c")
+
+	tests := []struct {
+		name     string
+		nodes    []*Node
+		expected []string
+	}{
+		{
+			name:     "empty",
+			nodes:    nil,
+			expected: []string{},
+		},
+		{
+			name:     "no duplicates",
+			nodes:    []*Node{a0, a1, c},
+			expected: []string{"$['a'][0]", "$['a'][1]", "$['b']['c']"},
+		},
+		{
+			name:     "duplicates keep first-seen order",
+			nodes:    []*Node{c, a0, c, a1, a0, nil},
+			expected: []string{"$['b']['c']", "$['a'][0]", "$['a'][1]"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := PathsUnique(tt.nodes)
+			if len(got) != len(tt.expected) {
+				t.Fatalf("PathsUnique() = %v, want %v", got, tt.expected)
+			}
+
+			for i := range got {
+				if got[i] != tt.expected[i] {
+					t.Errorf("PathsUnique() = %v, want %v", got, tt.expected)
+				}
+			}
+		})
+	}
+}
+
+func TestSortNodes(t *testing.T) {
+	root := Must(Unmarshal([]byte(`[{"id": "a", "price": 3}, {"id": "b", "price": 1}, {"id": "c", "price": 3}, {"id": "d", "price": 2}]`)))
+	nodes := append([]*Node(nil), root.MustArray()...)
+
+	byPrice := func(a, b *Node) bool {
+		return a.MustKey("price").MustNumeric() < b.MustKey("price").MustNumeric()
+	}
+
+	ids := func(nodes []*Node) string {
+		var sb strings.Builder
+		for _, node := range nodes {
+			sb.WriteString(node.MustKey("id").MustString())
+		}
+
+		return sb.String()
+	}
+
+	SortNodes(nodes, byPrice)
+	if got := ids(nodes); got != "bdac" {
+		t.Errorf("SortNodes = %s, want %s", got, "bdac")
+	}
+
+	if got := ids(root.MustArray()); got != "abcd" {
+		t.Errorf("SortNodes must not change the array node, got %s", got)
+	}
+
+	nodes = root.MustArray()
+	if got := MinNode(nodes, byPrice); got.MustKey("id").MustString() != "b" {
+		t.Errorf("MinNode = %s, want b", got)
+	}
+
+	if got := MaxNode(nodes, byPrice); got.MustKey("id").MustString() != "a" {
+		t.Errorf("MaxNode = %s, want the first largest a", got)
+	}
+
+	if MinNode(nil, byPrice) != nil || MaxNode([]*Node{}, byPrice) != nil {
+		t.Errorf("MinNode and MaxNode must return nil for an empty slice")
+	}
+
+	SortNodes(nil, byPrice)
+}
+
+func TestNode_SortArrayByPath(t *testing.T) {
+	const books = `[
+		{"id": "a", "price": 12.99, "meta": {"created": "2024-03-01"}},
+		{"id": "b", "price": 8.95, "meta": {"created": "2023-12-24"}},
+		{"id": "c", "price": 22.99, "meta": {"created": "2024-01-15"}},
+		{"id": "d", "price": 8.95, "meta": {"created": "2024-02-29"}}
+	]`
+
+	tests := []struct {
+		name     string
+		input    string
+		path     string
+		opts     SortOptions
+		expected string
+	}{
+		{"numeric", books, "price", SortOptions{Numeric: true}, "b d a c"},
+		{"nested string", books, "meta.created", SortOptions{}, "b c d a"},
+		{"descending", books, "price", SortOptions{Numeric: true, Descending: true}, "c a b d"},
+		{"bracket path", books, "['meta']['created']", SortOptions{}, "b c d a"},
+		{"root path", books, "$.id", SortOptions{Descending: true}, "d c b a"},
+		{"lexical numbers", `[{"id": "a", "v": "10"}, {"id": "b", "v": "9"}]`, "v", SortOptions{}, "a b"},
+		{
+			"missing last",
+			`[{"id": "a"}, {"id": "b", "v": 2}, {"id": "c"}, {"id": "d", "v": 1}]`,
+			"v", SortOptions{Numeric: true, MissingLast: true, Descending: true},
+			"b d a c",
+		},
+		{"empty array", `[]`, "v", SortOptions{}, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := Must(Unmarshal([]byte(tt.input)))
+			_ = root.MustArray()
+
+			if err := root.SortArrayByPathWithOptions(tt.path, tt.opts); err != nil {
+				t.Fatalf("SortArrayByPathWithOptions returns error: %v", err)
+			}
+
+			var ids []string
+			for i, elem := range root.MustArray() {
+				if elem.Index() != i || elem.prev != root {
+					t.Errorf("element %d has index %d", i, elem.Index())
+				}
+
+				ids = append(ids, elem.MustKey("id").MustString())
+			}
+
+			if got := strings.Join(ids, " "); got != tt.expected {
+				t.Errorf("order = %q, want %q", got, tt.expected)
+			}
+
+			value, err := Marshal(root)
+			if err != nil {
+				t.Fatalf("Marshal returns error: %v", err)
+			}
+
+			if !equals(Must(Unmarshal(value)), root) {
+				t.Errorf("Marshal() = %s, want %s", value, root)
+			}
+		})
+	}
+}
+
+func TestNode_SortArrayByPath_Fail(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		path    string
+		numeric bool
+	}{
+		{"missing value", `[{"v": 1}, {}]`, "v", true},
+		{"not a number", `[{"v": 1}, {"v": "2"}]`, "v", true},
+		{"not a string", `[{"v": "a"}, {"v": 2}]`, "v", false},
+		{"multiple matches", `[{"v": [1, 2]}]`, "v[*]", true},
+		{"scalar element", `[{"v": 1}, 2]`, "v", true},
+		{"invalid path", `[{"v": 1}]`, "v[", true},
+		{"object", `{"v": 1}`, "v", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := Must(Unmarshal([]byte(tt.input)))
+
+			if err := root.SortArrayByPath(tt.path, tt.numeric); err == nil {
+				t.Errorf("SortArrayByPath() must fail")
+			}
+
+			if root.String() != tt.input || root.Changed() {
+				t.Errorf("node = %s, must be unchanged", root)
+			}
+		})
+	}
+
+	if err := (*Node)(nil).SortArrayByPath("v", true); err != ErrNilNode {
+		t.Errorf("expected ErrNilNode for nil receiver, got %v", err)
+	}
+}
+
+func TestNode_GetArray_AfterModification(t *testing.T) {
+	tests := []struct {
+		name     string
+		json     string
+		modify   func(arr *Node) error
+		expected string
+	}{
+		{name: "SwapIndex", json: `[1, 2, 3]`, modify: func(arr *Node) error { return arr.SwapIndex(0, 2) }, expected: "[3 2 1]"},
+		{name: "AppendArray", json: `[1, 2, 3]`, modify: func(arr *Node) error { return arr.AppendArray(NumberNode("", 4)) }, expected: "[1 2 3 4]"},
+		{name: "Prepend", json: `[1, 2, 3]`, modify: func(arr *Node) error { return arr.Prepend(NumberNode("", 0)) }, expected: "[0 1 2 3]"},
+		{name: "SetIndex", json: `[1, 2, 3]`, modify: func(arr *Node) error { return arr.SetIndex(1, NumberNode("", 5)) }, expected: "[1 5 3]"},
+		{name: "DeleteIndex", json: `[1, 2, 3]`, modify: func(arr *Node) error { return arr.DeleteIndex(0) }, expected: "[2 3]"},
+		{name: "SortArrayByPath", json: `[3, 1, 2]`, modify: func(arr *Node) error { return arr.SortArrayByPath("$", true) }, expected: "[1 2 3]"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			arr := Must(Unmarshal([]byte(tt.json)))
+
+			// load the array, so it holds its elements.
+			_ = arr.MustArray()
+
+			if err := tt.modify(arr); err != nil {
+				t.Fatalf("%s returns error: %v", tt.name, err)
+			}
+
+			var values []float64
+			for _, elem := range arr.MustArray() {
+				values = append(values, elem.MustNumeric())
+			}
+
+			if got := fmt.Sprint(values); got != tt.expected {
+				t.Errorf("GetArray() = %s, want %s", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestNode_GetObject_AfterModification(t *testing.T) {
+	obj := Must(Unmarshal([]byte(`{"a": 1, "c": 3}`)))
+
+	// load the object, so it holds its members.
+	_ = obj.MustObject()
+
+	if err := obj.AppendObject("b", NullNode("")); err != nil {
+		t.Fatalf("AppendObject returns error: %v", err)
+	}
+
+	if members := obj.MustObject(); len(members) != 3 {
+		t.Errorf("GetObject() = %v, want 3 members", members)
+	}
+
+	if err := obj.MustKey("a").Delete(); err != nil {
+		t.Fatalf("Delete returns error: %v", err)
+	}
+
+	if _, ok := obj.MustObject()["a"]; ok {
+		t.Errorf("GetObject() must not hold the deleted member")
+	}
+}
+
+func TestNode_EqualsExcept(t *testing.T) {
+	tests := []struct {
+		name     string
+		a, b     string
+		ignore   []string
+		expected bool
+	}{
+		{name: "equal", a: `{"a": 1, "b": [1, 2]}`, b: `{"b": [1, 2.0], "a": 1}`, expected: true},
+		{name: "different without ignore", a: `{"id": 1, "a": 1}`, b: `{"id": 2, "a": 1}`, expected: false},
+		{name: "ignored value", a: `{"id": 1, "a": 1}`, b: `{"id": "x", "a": 1}`, ignore: []string{"id"}, expected: true},
+		{name: "ignored missing", a: `{"id": 1, "a": 1}`, b: `{"a": 1}`, ignore: []string{"id"}, expected: true},
+		{name: "ignored missing other side", a: `{"a": 1}`, b: `{"a": 1, "id": 1, "ts": 2}`, ignore: []string{"id", "ts"}, expected: true},
+		{name: "ignored nested", a: `{"items": [{"id": 1, "v": true}]}`, b: `{"items": [{"id": 2, "v": true}]}`, ignore: []string{"id"}, expected: true},
+		{name: "not ignored nested", a: `{"items": [{"id": 1, "v": true}]}`, b: `{"items": [{"id": 1, "v": false}]}`, ignore: []string{"id"}, expected: false},
+		{name: "extra member", a: `{"a": 1}`, b: `{"a": 1, "b": 2}`, ignore: []string{"id"}, expected: false},
+		{name: "missing member", a: `{"a": 1, "b": 2}`, b: `{"a": 1}`, ignore: []string{"id"}, expected: false},
+		{name: "array length", a: `[1, 2]`, b: `[1]`, expected: false},
+		{name: "scalar", a: `"id"`, b: `"id"`, ignore: []string{"id"}, expected: true},
+		{name: "type", a: `{"a": "1"}`, b: `{"a": 1}`, expected: false},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			a, b := Must(Unmarshal([]byte(tc.a))), Must(Unmarshal([]byte(tc.b)))
+			if got := a.EqualsExcept(b, tc.ignore); got != tc.expected {
+				t.Errorf("EqualsExcept(%s, %s, %v) = %v, want %v", tc.a, tc.b, tc.ignore, got, tc.expected)
+			}
+
+			if got := b.EqualsExcept(a, tc.ignore); got != tc.expected {
+				t.Errorf("EqualsExcept(%s, %s, %v) = %v, want %v", tc.b, tc.a, tc.ignore, got, tc.expected)
+			}
+		})
+	}
+}
+
+func TestNode_EqualJSON(t *testing.T) {
+	root := Must(Unmarshal([]byte(`{"a": 1, "b": [true, null], "c": {"d": "x"}}`)))
+
+	tests := []struct {
+		name     string
+		data     string
+		expected bool
+	}{
+		{"same", `{"a": 1, "b": [true, null], "c": {"d": "x"}}`, true},
+		{"member order and spacing", "{\"c\":{\"d\":\"x\"},\n\"b\":[true,null],\"a\":1}", true},
+		{"number literal", `{"a": 1.0e0, "b": [true, null], "c": {"d": "x"}}`, true},
+		{"element order", `{"a": 1, "b": [null, true], "c": {"d": "x"}}`, false},
+		{"missing member", `{"a": 1, "b": [true, null]}`, false},
+		{"extra member", `{"a": 1, "b": [true, null], "c": {"d": "x"}, "e": 2}`, false},
+		{"type", `[1]`, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := root.EqualJSON([]byte(tt.data))
+			if err != nil {
+				t.Fatalf("EqualJSON returns error: %v", err)
+			}
+
+			if got != tt.expected {
+				t.Errorf("EqualJSON(%s) = %t, want %t", tt.data, got, tt.expected)
+			}
+		})
+	}
+
+	for _, data := range []string{``, `{"a": 1,}`, `[1] 2`} {
+		ok, err := root.EqualJSON([]byte(data))
+		if err == nil || ok {
+			t.Errorf("EqualJSON(%q) = %t, %v, want a parse error", data, ok, err)
+		}
+	}
+
+	var syntaxErr *SyntaxError
+	if _, err := root.EqualJSON([]byte(`{"a": }`)); !errors.As(err, &syntaxErr) {
+		t.Errorf("EqualJSON of an invalid document = %v, want a SyntaxError", err)
+	}
+
+	if _, err := (*Node)(nil).EqualJSON([]byte(`null`)); err != ErrNilNode {
+		t.Errorf("expected ErrNilNode for nil receiver, got %v", err)
+	}
+}
+
+func TestNode_EqualScalar(t *testing.T) {
+	root := Must(Unmarshal([]byte(`["a", "\u0061", "b", 1, 1.0, 1e0, 2, true, true, false, null, null, [], {}]`)))
+	at := root.MustIndex
+
+	tests := []struct {
+		name     string
+		a, b     *Node
+		expected bool
+	}{
+		{"same literal", at(0), at(0), true},
+		{"escaped string", at(0), at(1), true},
+		{"different string", at(0), at(2), false},
+		{"integer and float", at(3), at(4), true},
+		{"exponent", at(3), at(5), true},
+		{"different number", at(3), at(6), false},
+		{"bools", at(7), at(8), true},
+		{"different bool", at(7), at(9), false},
+		{"nulls", at(10), at(11), true},
+		{"different types", at(0), at(3), false},
+		{"null and false", at(10), at(9), false},
+		{"parsed and built", at(3), NumberNode("", 1), true},
+		{"built strings", StringNode("", "x"), StringNode("", "x"), true},
+		{"array", at(12), at(12), false},
+		{"object", at(13), at(13), false},
+		{"nil", at(0), nil, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.a.EqualScalar(tt.b); got != tt.expected {
+				t.Errorf("EqualScalar() = %v, want %v", got, tt.expected)
+			}
+
+			if got := tt.b.EqualScalar(tt.a); got != tt.expected {
+				t.Errorf("EqualScalar() reversed = %v, want %v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func BenchmarkNode_EqualScalar(b *testing.B) {
+	root := Must(Unmarshal([]byte(`["some string value", "some string value", 12345.678, 12345.678]`)))
+	pairs := [][2]*Node{{root.MustIndex(0), root.MustIndex(1)}, {root.MustIndex(2), root.MustIndex(3)}}
+
+	b.Run("equals", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			for _, pair := range pairs {
+				if !equals(pair[0], pair[1]) {
+					b.Fatal("not equal")
+				}
+			}
+		}
+	})
+
+	b.Run("EqualScalar", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			for _, pair := range pairs {
+				if !pair[0].EqualScalar(pair[1]) {
+					b.Fatal("not equal")
+				}
+			}
+		}
+	})
+}
+
+func TestNode_Hash(t *testing.T) {
+	tests := []struct {
+		name  string
+		a, b  string
+		equal bool
+	}{
+		{"same", `{"a": [1, "x", null, true]}`, `{"a": [1, "x", null, true]}`, true},
+		{"member order", `{"a": 1, "b": {"c": 2, "d": 3}}`, `{"b": {"d": 3, "c": 2}, "a": 1}`, true},
+		{"number literal", `[1.0, 1e2, -0]`, `[1, 100, 0]`, true},
+		{"escaped string", `"a"`, `"a"`, true},
+		{"element order", `[1, 2]`, `[2, 1]`, false},
+		{"key", `{"a": 1}`, `{"b": 1}`, false},
+		{"missing member", `{"a": 1, "b": 2}`, `{"a": 1}`, false},
+		{"swapped members", `{"a": 1, "b": 2}`, `{"a": 2, "b": 1}`, false},
+		{"type", `"1"`, `1`, false},
+		{"bool", `true`, `false`, false},
+		{"nested", `[[1], []]`, `[[], [1]]`, false},
+		{"empty containers", `{}`, `[]`, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := Must(Unmarshal([]byte(tt.a)))
+			b := Must(Unmarshal([]byte(tt.b)))
+
+			if got := a.Hash() == b.Hash(); got != tt.equal {
+				t.Errorf("Hash(%s) == Hash(%s) is %t, want %t", tt.a, tt.b, got, tt.equal)
+			}
+
+			if got := equals(a, b); got != tt.equal {
+				t.Errorf("equals(%s, %s) = %t, want %t", tt.a, tt.b, got, tt.equal)
+			}
+		})
+	}
+
+	if (*Node)(nil).Hash() != 0 {
+		t.Errorf("Hash of nil must be 0")
+	}
+}
+
+func TestNode_Hash_Invalidation(t *testing.T) {
+	const input = `{"a": {"b": [1, {"c": "x"}]}, "d": null}`
+
+	tests := []struct {
+		name   string
+		modify func(root *Node) error
+	}{
+		{"set leaf", func(root *Node) error { return root.MustKey("a").MustKey("b").MustIndex(1).MustKey("c").SetString("y") }},
+		{"set number literal", func(root *Node) error { return root.MustKey("a").MustKey("b").MustIndex(0).SetNumberString("2") }},
+		{"append", func(root *Node) error { return root.MustKey("a").MustKey("b").AppendArray(NullNode("")) }},
+		{"delete", func(root *Node) error { return root.MustKey("a").MustKey("b").MustIndex(0).Delete() }},
+		{"swap", func(root *Node) error { return root.MustKey("a").MustKey("b").SwapIndex(0, 1) }},
+		{"move", func(root *Node) error { return root.MustKey("a").MustKey("b").MustIndex(1).MoveTo(root, "e") }},
+	}
+
+	for _, tt := range tests {
+		for _, premodified := range []bool{false, true} {
+			t.Run(fmt.Sprintf("%s/premodified=%t", tt.name, premodified), func(t *testing.T) {
+				root := Must(Unmarshal([]byte(input)))
+				original := Must(Unmarshal([]byte(input)))
+
+				// a modified node that is hashed again must still be invalidated.
+				if premodified {
+					for _, node := range []*Node{root, original} {
+						if err := node.MustKey("a").MustKey("b").AppendArray(StringNode("", "z")); err != nil {
+							t.Fatalf("AppendArray returns error: %v", err)
+						}
+					}
+				}
+
+				hash := root.Hash()
+				original.Hash()
+
+				if err := tt.modify(root); err != nil {
+					t.Fatalf("modify returns error: %v", err)
+				}
+
+				if root.Hash() == hash {
+					t.Errorf("Hash() is unchanged after the modification")
+				}
+
+				if equals(root, original) {
+					t.Errorf("equals() = true after the modification")
+				}
+
+				fresh := Must(Unmarshal([]byte(root.String())))
+				if fresh.Hash() != root.Hash() || !equals(fresh, root) {
+					t.Errorf("Hash() = %d, want the hash of %s: %d", root.Hash(), fresh, fresh.Hash())
+				}
+			})
+		}
+	}
+}
+
+func BenchmarkNode_Hash_Equals(b *testing.B) {
+	data := recordsJSON(1000)
+	x := Must(Unmarshal(data))
+	y := Must(Unmarshal(data))
+	if err := y.MustIndex(999).MustKey("identifier").SetNumber(-1); err != nil {
+		b.Fatal(err)
+	}
+
+	b.Run("uncached", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			equals(x, y)
+		}
+	})
+
+	b.Run("cached", func(b *testing.B) {
+		x.Hash()
+		y.Hash()
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			equals(x, y)
+		}
+	})
+}
+
+func TestNode_Compare(t *testing.T) {
+	tests := []struct {
+		name     string
+		a, b     *Node
+		expected int
+		fail     bool
+	}{
+		{name: "number less", a: NumberNode("", 1), b: NumberNode("", 2), expected: -1},
+		{name: "number equal", a: NumberNode("", 2), b: Must(Unmarshal([]byte("2"))), expected: 0},
+		{name: "number greater", a: Must(Unmarshal([]byte("1e2"))), b: NumberNode("", 99.9), expected: 1},
+		{name: "string less", a: StringNode("", "abc"), b: StringNode("", "abd"), expected: -1},
+		{name: "string equal", a: StringNode("", "foo"), b: Must(Unmarshal([]byte(`"foo"`))), expected: 0},
+		{name: "string greater", a: StringNode("", "b"), b: StringNode("", "a"), expected: 1},
+		{name: "bool less", a: BoolNode("", false), b: BoolNode("", true), expected: -1},
+		{name: "bool equal", a: BoolNode("", true), b: BoolNode("", true), expected: 0},
+		{name: "bool greater", a: BoolNode("", true), b: BoolNode("", false), expected: 1},
+		{name: "null", a: NullNode(""), b: NullNode(""), expected: 0},
+		{name: "type mismatch", a: NumberNode("", 1), b: StringNode("", "1"), fail: true},
+		{name: "array", a: ArrayNode("", nil), b: ArrayNode("", nil), fail: true},
+		{name: "object", a: ObjectNode("", nil), b: ObjectNode("", nil), fail: true},
+		{name: "nil", a: nil, b: NullNode(""), fail: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.a.Compare(tt.b)
+			if tt.fail {
+				if err == nil {
+					t.Errorf("expected error")
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if got != tt.expected {
+				t.Errorf("Compare() = %d, want %d", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestNode_Compare_Sort(t *testing.T) {
+	root := Must(Unmarshal([]byte(`[3, 1.1, -2, 10, 0]`)))
+	nodes := root.MustArray()
+
+	sort.Slice(nodes, func(i, j int) bool {
+		c, err := nodes[i].Compare(nodes[j])
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return c < 0
+	})
+
+	expected := []float64{-2, 0, 1.1, 3, 10}
+	for i, node := range nodes {
+		if node.MustNumeric() != expected[i] {
+			t.Errorf("unexpected order at %d: %v", i, node.MustNumeric())
+		}
+	}
+}
+
+func TestNode_Compare_MixedNumeric(t *testing.T) {
+	tests := []struct {
+		name     string
+		a, b     string
+		expected int
+		fail     bool
+	}{
+		{name: "integer and float", a: `2`, b: `2.0`, expected: 0},
+		{name: "float and integer", a: `2.5`, b: `2`, expected: 1},
+		{name: "integer and exponent", a: `100`, b: `1e2`, expected: 0},
+		{name: "negative float and integer", a: `-3.5`, b: `-3`, expected: -1},
+		{name: "fraction and exponent", a: `0.25`, b: `25e-2`, expected: 0},
+		{name: "number and string", a: `1`, b: `"1"`, fail: true},
+		{name: "number and bool", a: `1`, b: `true`, fail: true},
+		{name: "number and null", a: `0`, b: `null`, fail: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := Must(Unmarshal([]byte(tt.a)))
+			b := Must(Unmarshal([]byte(tt.b)))
+
+			got, err := a.Compare(b)
+			if tt.fail {
+				if err == nil {
+					t.Errorf("expected error")
+				} else if !strings.Contains(err.Error(), "can't compare") {
+					t.Errorf("unexpected error message: %v", err)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if got != tt.expected {
+				t.Errorf("Compare() = %d, want %d", got, tt.expected)
+			}
+
+			if reverse, _ := b.Compare(a); reverse != -tt.expected {
+				t.Errorf("reversed Compare() = %d, want %d", reverse, -tt.expected)
+			}
+		})
+	}
+}
+
+func TestNode_FloatNode(t *testing.T) {
+	float := FloatNode("f", 2)
+
+	if float.Type() != Float || !float.IsNumber() || !float.IsScalar() {
+		t.Errorf("FloatNode must be a number: type %s", float.Type())
+	}
+
+	if value, err := float.GetNumeric(); err != nil || value != 2 {
+		t.Errorf("GetNumeric() = %v, %v, want 2", value, err)
+	}
+
+	if value, err := Marshal(float); err != nil || string(value) != `2.0` {
+		t.Errorf("Marshal() = %s, %v, want 2.0", value, err)
+	}
+
+	tests := []struct {
+		name     string
+		other    *Node
+		expected int
+	}{
+		{name: "equal integer", other: Must(Unmarshal([]byte(`2`))), expected: 0},
+		{name: "equal number node", other: NumberNode("", 2), expected: 0},
+		{name: "smaller", other: Must(Unmarshal([]byte(`1.5`))), expected: 1},
+		{name: "greater", other: FloatNode("", 3), expected: -1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := float.Compare(tt.other)
+			if err != nil || got != tt.expected {
+				t.Errorf("Compare() = %d, %v, want %d", got, err, tt.expected)
+			}
+
+			if equal := equals(float, tt.other); equal != (tt.expected == 0) {
+				t.Errorf("equals() = %t, want %t", equal, tt.expected == 0)
+			}
+
+			if tt.expected == 0 && float.Hash() != tt.other.Hash() {
+				t.Errorf("equal numbers must have the same hash")
+			}
+		})
+	}
+
+	if _, err := float.Compare(StringNode("", "2")); err == nil {
+		t.Errorf("expected error comparing a float with a string")
+	}
+}
+
+func TestNode_Entries(t *testing.T) {
+	tests := []struct {
+		name     string
+		json     string
+		expected []string
+	}{
+		{name: "sorted keys", json: `{"c": 3, "a": 1, "b": 2}`, expected: []string{"a", "b", "c"}},
+		{name: "empty object", json: `{}`, expected: []string{}},
+		{name: "not object", json: `[1, 2]`, expected: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := Must(Unmarshal([]byte(tt.json)))
+
+			entries := root.Entries()
+			if (entries == nil) != (tt.expected == nil) || len(entries) != len(tt.expected) {
+				t.Fatalf("Entries() = %v, want keys %v", entries, tt.expected)
+			}
+
+			for i, entry := range entries {
+				if entry.Key != tt.expected[i] {
+					t.Errorf("Entries()[%d].Key = %s, want %s", i, entry.Key, tt.expected[i])
+				}
+
+				if entry.Value != root.MustKey(entry.Key) {
+					t.Errorf("Entries()[%d].Value is not the member node", i)
+				}
+			}
+		})
+	}
+}
+
+func TestNode_AppendUnique(t *testing.T) {
+	tests := []struct {
+		name     string
+		json     string
+		value    string
+		added    bool
+		expected int
+	}{
+		{name: "new string", json: `["foo", "bar"]`, value: `"baz"`, added: true, expected: 3},
+		{name: "existing string", json: `["foo", "bar"]`, value: `"foo"`, added: false, expected: 2},
+		{name: "equal number", json: `[1, 2.5]`, value: `25e-1`, added: false, expected: 2},
+		{name: "equal object", json: `[{"a": 1, "b": [true]}]`, value: `{"b": [true], "a": 1}`, added: false, expected: 1},
+		{name: "different object", json: `[{"a": 1}]`, value: `{"a": 2}`, added: true, expected: 2},
+		{name: "array order matters", json: `[[1, 2]]`, value: `[2, 1]`, added: true, expected: 2},
+		{name: "null", json: `[null]`, value: `null`, added: false, expected: 1},
+		{name: "empty array", json: `[]`, value: `null`, added: true, expected: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := Must(Unmarshal([]byte(tt.json)))
+			value := Must(Unmarshal([]byte(tt.value)))
+
+			added, err := root.AppendUnique(value)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if added != tt.added {
+				t.Errorf("AppendUnique() = %t, want %t", added, tt.added)
+			}
+
+			if root.Size() != tt.expected {
+				t.Errorf("wrong size: %d, want %d", root.Size(), tt.expected)
+			}
+
+			if root.Changed() != tt.added {
+				t.Errorf("Changed() = %t, want %t", root.Changed(), tt.added)
+			}
+		})
+	}
+
+	var nilNode *Node
+	if _, err := nilNode.AppendUnique(NullNode("")); err != ErrNilNode {
+		t.Errorf("expected ErrNilNode for nil node, got %v", err)
+	}
+}
+
+func TestNode_AppendObjectIfAbsent(t *testing.T) {
+	tests := []struct {
+		name     string
+		json     string
+		key      string
+		added    bool
+		expected string
+	}{
+		{name: "new key", json: `{"a": 1}`, key: "b", added: true, expected: `"new"`},
+		{name: "existing key", json: `{"a": 1}`, key: "a", added: false, expected: `1`},
+		{name: "existing null", json: `{"a": null}`, key: "a", added: false, expected: `null`},
+		{name: "empty object", json: `{}`, key: "a", added: true, expected: `"new"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := Must(Unmarshal([]byte(tt.json)))
+
+			added, err := root.AppendObjectIfAbsent(tt.key, StringNode("", "new"))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if added != tt.added {
+				t.Errorf("AppendObjectIfAbsent() = %t, want %t", added, tt.added)
+			}
+
+			if got := root.MustKey(tt.key).String(); got != tt.expected {
+				t.Errorf("value of %s = %s, want %s", tt.key, got, tt.expected)
+			}
+
+			if root.Changed() != tt.added {
+				t.Errorf("Changed() = %t, want %t", root.Changed(), tt.added)
+			}
+		})
+	}
+
+	if _, err := Must(Unmarshal([]byte(`[]`))).AppendObjectIfAbsent("a", NullNode("")); err == nil {
+		t.Errorf("AppendObjectIfAbsent on an array must fail")
+	}
+
+	var nilNode *Node
+	if _, err := nilNode.AppendObjectIfAbsent("a", NullNode("")); err != ErrNilNode {
+		t.Errorf("expected ErrNilNode for nil node, got %v", err)
+	}
+}
+
+func TestNode_RemoveKeys(t *testing.T) {
+	tests := []struct {
+		name     string
+		json     string
+		keys     []string
+		removed  int
+		expected string
+	}{
+		{name: "redact", json: `{"user": "foo", "password": "bar", "token": "baz"}`, keys: []string{"password", "token", "secret"}, removed: 2, expected: `{"user": "foo"}`},
+		{name: "no keys", json: `{"a": 1}`, keys: nil, removed: 0, expected: `{"a": 1}`},
+		{name: "absent keys", json: `{"a": 1}`, keys: []string{"b", "c"}, removed: 0, expected: `{"a": 1}`},
+		{name: "duplicated keys", json: `{"a": 1, "b": 2}`, keys: []string{"a", "a"}, removed: 1, expected: `{"b": 2}`},
+		{name: "all keys", json: `{"a": {"b": 1}, "c": [2]}`, keys: []string{"a", "c"}, removed: 2, expected: `{}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := Must(Unmarshal([]byte(tt.json)))
+
+			removed, err := root.RemoveKeys(tt.keys...)
+			if err != nil {
+				t.Fatalf("RemoveKeys returns error: %v", err)
+			}
+
+			if removed != tt.removed {
+				t.Errorf("RemoveKeys() = %d, want %d", removed, tt.removed)
+			}
+
+			if expected := Must(Unmarshal([]byte(tt.expected))); !equals(root, expected) {
+				t.Errorf("RemoveKeys() result = %s, want %s", root, expected)
+			}
+
+			if root.Changed() != (tt.removed > 0) {
+				t.Errorf("Changed() = %t, want %t", root.Changed(), tt.removed > 0)
+			}
+		})
+	}
+
+	var te *TypeError
+	if _, err := Must(Unmarshal([]byte(`[]`))).RemoveKeys("a"); !errors.As(err, &te) {
+		t.Errorf("RemoveKeys on an array = %v, want TypeError", err)
+	}
+
+	if _, err := (*Node)(nil).RemoveKeys("a"); !errors.Is(err, ErrNilNode) {
+		t.Errorf("RemoveKeys on nil = %v, want ErrNilNode", err)
+	}
+}
+
+func TestNode_RetainKeys(t *testing.T) {
+	tests := []struct {
+		name     string
+		json     string
+		keys     []string
+		expected string
+		changed  bool
+	}{
+		{name: "whitelist", json: `{"id": 1, "name": "foo", "password": "bar"}`, keys: []string{"id", "name"}, expected: `{"id": 1, "name": "foo"}`, changed: true},
+		{name: "absent keys", json: `{"a": 1, "b": 2}`, keys: []string{"a", "c"}, expected: `{"a": 1}`, changed: true},
+		{name: "all keys", json: `{"a": 1, "b": 2}`, keys: []string{"b", "a"}, expected: `{"a": 1, "b": 2}`, changed: false},
+		{name: "no keys", json: `{"a": {"b": 1}, "c": [2]}`, keys: nil, expected: `{}`, changed: true},
+		{name: "empty object", json: `{}`, keys: []string{"a"}, expected: `{}`, changed: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := Must(Unmarshal([]byte(tt.json)))
+
+			if err := root.RetainKeys(tt.keys...); err != nil {
+				t.Fatalf("RetainKeys returns error: %v", err)
+			}
+
+			if expected := Must(Unmarshal([]byte(tt.expected))); !equals(root, expected) {
+				t.Errorf("RetainKeys() result = %s, want %s", root, expected)
+			}
+
+			if root.Changed() != tt.changed {
+				t.Errorf("Changed() = %t, want %t", root.Changed(), tt.changed)
+			}
+		})
+	}
+
+	var te *TypeError
+	if err := Must(Unmarshal([]byte(`"a"`))).RetainKeys("a"); !errors.As(err, &te) {
+		t.Errorf("RetainKeys on a string = %v, want TypeError", err)
+	}
+
+	if err := (*Node)(nil).RetainKeys("a"); !errors.Is(err, ErrNilNode) {
+		t.Errorf("RetainKeys on nil = %v, want ErrNilNode", err)
+	}
+}
+func TestNode_AppendUnique_Reparent(t *testing.T) {
+	src := Must(Unmarshal([]byte(`{"item": {"id": 1}}`)))
+	dst := Must(Unmarshal([]byte(`[]`)))
+
+	added, err := dst.AppendUnique(src.MustKey("item"))
+	if err != nil || !added {
+		t.Fatalf("AppendUnique() = %t, %v", added, err)
+	}
+
+	if !src.HasKey("item") {
+		t.Errorf("the original node must be left in place")
+	}
+
+	if dst.MustIndex(0).MustKey("id").Path() != "$[0]['id']" {
+		t.Errorf("wrong path of appended node: %s", dst.MustIndex(0).MustKey("id").Path())
+	}
+
+	if _, err := NumberNode("", 1).AppendUnique(NullNode("")); err == nil {
+		t.Errorf("expected error for non-array node")
+	}
+
+	if _, err := dst.AppendUnique(nil); err == nil {
+		t.Errorf("expected error for nil value")
+	}
+}
+
+func TestNode_Prepend(t *testing.T) {
+	tests := []struct {
+		name     string
+		json     string
+		values   []*Node
+		expected string
+	}{
+		{name: "single", json: `[2, 3]`, values: []*Node{NumberNode("", 1)}, expected: `[1,2,3]`},
+		{name: "multiple", json: `[3]`, values: []*Node{NumberNode("", 1), NumberNode("", 2)}, expected: `[1,2,3]`},
+		{name: "empty array", json: `[]`, values: []*Node{StringNode("", "a")}, expected: `["a"]`},
+		{name: "no values", json: `[1]`, values: nil, expected: `[1]`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := Must(Unmarshal([]byte(tt.json)))
+
+			if err := root.Prepend(tt.values...); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if got := root.String(); got != tt.expected {
+				t.Errorf("Prepend() = %s, want %s", got, tt.expected)
+			}
+
+			for i, elem := range root.MustArray() {
+				if elem.Index() != i {
+					t.Errorf("wrong index of element %d: %d", i, elem.Index())
+				}
+			}
+		})
+	}
+
+	root := Must(Unmarshal([]byte(`[1, 2]`)))
+	if err := root.Prepend(root.MustIndex(1)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got := root.String(); got != `[2,1]` {
+		t.Errorf("Prepend() of own element = %s, want [2,1]", got)
+	}
+
+	if err := NullNode("").Prepend(NumberNode("", 1)); err == nil {
+		t.Errorf("expected error for non-array node")
+	}
+
+	if err := root.Prepend(root); err == nil {
+		t.Errorf("expected error for prepending itself")
+	}
+
+	var nilNode *Node
+	if err := nilNode.Prepend(NullNode("")); err != ErrNilNode {
+		t.Errorf("expected ErrNilNode for nil node, got %v", err)
+	}
+}
+
+func TestNode_PopIndex(t *testing.T) {
+	tests := []struct {
+		name     string
+		idx      int
+		popped   string
+		expected string
+		fail     bool
+	}{
+		{name: "first", idx: 0, popped: `1`, expected: `[2,3]`},
+		{name: "middle", idx: 1, popped: `2`, expected: `[1,3]`},
+		{name: "last by negative index", idx: -1, popped: `3`, expected: `[1,2]`},
+		{name: "out of range", idx: 5, fail: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := Must(Unmarshal([]byte(`[1, 2, 3]`)))
+
+			node, err := root.PopIndex(tt.idx)
+			if tt.fail {
+				if err == nil {
+					t.Errorf("expected error")
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if node.String() != tt.popped {
+				t.Errorf("PopIndex() = %s, want %s", node, tt.popped)
+			}
+
+			if node.prev != nil {
+				t.Errorf("popped node must be detached")
+			}
+
+			if got := root.String(); got != tt.expected {
+				t.Errorf("array = %s, want %s", got, tt.expected)
+			}
+
+			for i, elem := range root.MustArray() {
+				if elem.Index() != i {
+					t.Errorf("wrong index of element %d: %d", i, elem.Index())
+				}
+			}
+		})
+	}
+}
+
+func TestNode_Concat(t *testing.T) {
+	tests := []struct {
+		name     string
+		json     string
+		others   []string
+		expected string
+	}{
+		{name: "single", json: `[1, 2]`, others: []string{`[3, 4]`}, expected: `[1,2,3,4]`},
+		{name: "multiple", json: `[1]`, others: []string{`[2]`, `[]`, `["a", null]`}, expected: `[1,2,"a",null]`},
+		{name: "nested", json: `[]`, others: []string{`[[1], {"a": true}]`}, expected: `[[1],{"a":true}]`},
+		{name: "no arguments", json: `[1]`, expected: `[1]`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := Must(Unmarshal([]byte(tt.json)))
+
+			var others []*Node
+			for _, other := range tt.others {
+				others = append(others, Must(Unmarshal([]byte(other))))
+			}
+
+			if err := root.Concat(others...); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			got := Must(Unmarshal([]byte(root.String())))
+			if !equals(got, Must(Unmarshal([]byte(tt.expected)))) {
+				t.Errorf("Concat() = %s, want %s", root, tt.expected)
+			}
+
+			for i, other := range others {
+				if other.Changed() || other.String() != Must(Unmarshal([]byte(tt.others[i]))).String() {
+					t.Errorf("argument %d must not be changed: %s", i, other)
+				}
+			}
+		})
+	}
+
+	var nilNode *Node
+	if err := nilNode.Concat(ArrayNode("", nil)); err != ErrNilNode {
+		t.Errorf("expected ErrNilNode for nil node, got %v", err)
+	}
+}
+
+func TestNode_Concat_Fail(t *testing.T) {
+	root := Must(Unmarshal([]byte(`[1]`)))
+
+	if err := root.Concat(Must(Unmarshal([]byte(`[2]`))), NumberNode("", 3)); err == nil {
+		t.Errorf("expected error for non-array argument")
+	}
+
+	if root.Size() != 1 || root.Changed() {
+		t.Errorf("array must not be changed on error: %s", root)
+	}
+
+	if err := NullNode("").Concat(root); err == nil {
+		t.Errorf("expected error for non-array node")
+	}
+
+	if err := root.Concat(root); err != nil || root.String() != `[1,1]` {
+		t.Errorf("Concat() with itself = %s, %v", root, err)
+	}
+}
+
+func TestNode_Resize(t *testing.T) {
+	shared := Must(Unmarshal([]byte(`{"x": "shared"}`))).MustKey("x")
+
+	tests := []struct {
+		name     string
+		input    string
+		length   int
+		fill     func(i int) *Node
+		expected string
+	}{
+		{name: "grow with fill", input: `[1, 2]`, length: 4, fill: func(i int) *Node { return NumberNode("", float64(i)) }, expected: `[1,2,2,3]`},
+		{name: "grow with null", input: `["a"]`, length: 3, expected: `["a",null,null]`},
+		{name: "grow from empty", input: `[]`, length: 2, fill: func(int) *Node { return shared }, expected: `["shared","shared"]`},
+		{name: "shrink", input: `[1, [2], {"a": 3}, 4]`, length: 2, expected: `[1,[2]]`},
+		{name: "shrink to empty", input: `[1, 2]`, length: 0, expected: `[]`},
+		{name: "same length", input: `[1, 2]`, length: 2, expected: `[1, 2]`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := Must(Unmarshal([]byte(tt.input)))
+			if err := root.Resize(tt.length, tt.fill); err != nil {
+				t.Fatalf("Resize returns error: %v", err)
+			}
+
+			if root.Size() != tt.length {
+				t.Errorf("size = %d, want %d", root.Size(), tt.length)
+			}
+
+			for i := 0; i < tt.length; i++ {
+				if elem := root.MustIndex(i); elem.Index() != i || elem.prev != root {
+					t.Errorf("element %d has a wrong reference", i)
+				}
+			}
+
+			if value, err := Marshal(root); err != nil {
+				t.Errorf("Marshal returns error: %v", err)
+			} else if string(value) != tt.expected {
+				t.Errorf("got %s, want %s", value, tt.expected)
+			}
+		})
+	}
+
+	if shared.prev == nil || shared.Key() != "x" {
+		t.Errorf("fill value with a parent must be cloned")
+	}
+
+	var nilNode *Node
+	if err := nilNode.Resize(1, nil); err != ErrNilNode {
+		t.Errorf("expected ErrNilNode for nil node, got %v", err)
+	}
+}
+
+func TestNode_Resize_Fail(t *testing.T) {
+	root := Must(Unmarshal([]byte(`[1]`)))
+
+	if err := root.Resize(-1, nil); !errors.Is(err, ErrIndexOutOfRange) {
+		t.Errorf("expected ErrIndexOutOfRange, got %v", err)
+	}
+
+	if err := root.Resize(3, func(i int) *Node { return nil }); !errors.Is(err, ErrNilNode) {
+		t.Errorf("expected ErrNilNode, got %v", err)
+	}
+
+	if root.Size() != 1 || root.Changed() {
+		t.Errorf("array must not be changed on error: %s", root)
+	}
+
+	if err := NullNode("").Resize(1, nil); err == nil {
+		t.Errorf("expected error for non-array node")
+	}
+}
+
+func TestNode_MapLeaves(t *testing.T) {
+	root := Must(Unmarshal([]byte(`{"b": [" x ", 1, {"c": "2"}], "a": " y ", "d": {}, "e": null}`)))
+	if err := root.AppendObject("f", StringNode("", " z ")); err != nil {
+		t.Fatalf("AppendObject returns error: %v", err)
+	}
+
+	var visited []string
+	err := root.MapLeaves(func(leaf *Node) error {
+		visited = append(visited, leaf.String())
+		if !leaf.IsString() {
+			return nil
+		}
+
+		return leaf.SetString(strings.TrimSpace(leaf.MustString()))
+	})
+	if err != nil {
+		t.Fatalf("MapLeaves returns error: %v", err)
+	}
+
+	if got := strings.Join(visited, " "); got != `" x " 1 "2" " y " null " z "` {
+		t.Errorf("leaves are not visited in document order: %s", got)
+	}
+
+	for key, expected := range map[string]string{"a": "y", "f": "z"} {
+		if got := root.MustKey(key).MustString(); got != expected {
+			t.Errorf("%s = %q, want %q", key, got, expected)
+		}
+	}
+
+	if got := root.MustKey("b").MustIndex(0).MustString(); got != "x" {
+		t.Errorf("b[0] = %q, want %q", got, "x")
+	}
+
+	if !root.Changed() {
+		t.Errorf("root must be marked as modified")
+	}
+}
+
+func TestNode_MapLeaves_Error(t *testing.T) {
+	root := Must(Unmarshal([]byte(`[1, "a", 2]`)))
+
+	count := 0
+	err := root.MapLeaves(func(leaf *Node) error {
+		count++
+		if leaf.IsString() {
+			return errStop
+		}
+
+		return nil
+	})
+
+	if err != errStop || count != 2 {
+		t.Errorf("expected to stop at the second leaf, got err=%v, count=%d", err, count)
+	}
+
+	if err := (*Node)(nil).MapLeaves(func(*Node) error { return nil }); err != ErrNilNode {
+		t.Errorf("expected ErrNilNode, got %v", err)
+	}
+
+	scalar := NumberNode("", 1)
+	if err := scalar.MapLeaves(func(leaf *Node) error { return leaf.SetNumber(2) }); err != nil || scalar.MustNumeric() != 2 {
+		t.Errorf("scalar receiver must be mapped itself, got err=%v, value=%v", err, scalar.MustNumeric())
+	}
+}
+
+func TestNode_WalkPath(t *testing.T) {
+	root := Must(Unmarshal([]byte(`{"a": [1, {"b": null}], "c": {"d": true}, "e": "x"}`)))
+
+	var paths []string
+	root.WalkPath(func(path string, node *Node) bool {
+		if path != node.Path() {
+			t.Errorf("path = %s, want %s", path, node.Path())
+		}
+
+		paths = append(paths, path)
+		return true
+	})
+
+	expected := []string{"$", "$['a']", "$['a'][0]", "$['a'][1]", "$['a'][1]['b']", "$['c']", "$['c']['d']", "$['e']"}
+	if strings.Join(paths, " ") != strings.Join(expected, " ") {
+		t.Errorf("WalkPath visited %v, want %v", paths, expected)
+	}
+
+	paths = nil
+	root.MustKey("a").WalkPath(func(path string, node *Node) bool {
+		paths = append(paths, path)
+		return !node.IsObject()
+	})
+
+	expected = []string{"$['a']", "$['a'][0]", "$['a'][1]"}
+	if strings.Join(paths, " ") != strings.Join(expected, " ") {
+		t.Errorf("WalkPath visited %v, want %v", paths, expected)
+	}
+
+	(*Node)(nil).WalkPath(func(string, *Node) bool {
+		t.Errorf("fn must not be called for a nil node")
+		return true
+	})
+}
+
+func TestNode_WalkPath_BuiltNodes(t *testing.T) {
+	root := ObjectNode("", map[string]*Node{
+		"a": ArrayNode("a", []*Node{NumberNode("", 1), ObjectNode("", map[string]*Node{"b": NullNode("b")})}),
+	})
+
+	var paths []string
+	root.WalkPath(func(path string, node *Node) bool {
+		if found, err := root.Collect(path); err != nil || len(found) != 1 || found[0] != node {
+			t.Errorf("path %s does not resolve to the node: %v", path, err)
+		}
+
+		paths = append(paths, path)
+		return true
+	})
+
+	expected := []string{"$", "$['a']", "$['a'][0]", "$['a'][1]", "$['a'][1]['b']"}
+	if strings.Join(paths, " ") != strings.Join(expected, " ") {
+		t.Errorf("WalkPath visited %v, want %v", paths, expected)
+	}
+}
+
+func TestNode_ForEachLeaf(t *testing.T) {
+	root := Must(Unmarshal([]byte(`{"a": [1.5, "x", [], {"t": true}], "b/c": null, "d~e": {}, "f": false}`)))
+
+	var got []string
+	err := root.ForEachLeaf(func(pointer string, value interface{}) error {
+		got = append(got, fmt.Sprintf("%s=%v", pointer, value))
+
+		if resolved, err := root.resolvePointer(pointer); err != nil || !resolved.IsScalar() {
+			t.Errorf("pointer %s does not resolve to a leaf: %v", pointer, err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("ForEachLeaf returns error: %v", err)
+	}
+
+	expected := []string{"/a/0=1.5", "/a/1=x", "/a/3/t=true", "/b~1c=<nil>", "/f=false"}
+	if strings.Join(got, " ") != strings.Join(expected, " ") {
+		t.Errorf("ForEachLeaf visited %v, want %v", got, expected)
+	}
+
+	got = nil
+	if err := Must(Unmarshal([]byte(`"x"`))).ForEachLeaf(func(pointer string, value interface{}) error {
+		got = append(got, fmt.Sprintf("%q=%v", pointer, value))
+		return nil
+	}); err != nil || len(got) != 1 || got[0] != `""=x` {
+		t.Errorf("ForEachLeaf on a scalar = %v, %v", got, err)
+	}
+
+	count := 0
+	err = root.ForEachLeaf(func(pointer string, value interface{}) error {
+		count++
+		if pointer == "/a/1" {
+			return errStop
+		}
+
+		return nil
+	})
+	if !errors.Is(err, errStop) || count != 2 {
+		t.Errorf("ForEachLeaf must stop at the first error, got %v after %d leaves", err, count)
+	}
+}
+
+func TestNode_ForEachLeaf_BuiltNodes(t *testing.T) {
+	root := ObjectNode("", map[string]*Node{
+		"a": ArrayNode("a", []*Node{NumberNode("", 1), StringNode("", "x"), ArrayNode("", []*Node{BoolNode("", true)})}),
+	})
+
+	var got []string
+	err := root.ForEachLeaf(func(pointer string, value interface{}) error {
+		got = append(got, fmt.Sprintf("%s=%v", pointer, value))
+
+		if !root.HasPointer(pointer) {
+			t.Errorf("pointer %s does not resolve", pointer)
+		}
+
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("ForEachLeaf returns error: %v", err)
+	}
+
+	expected := []string{"/a/0=1", "/a/1=x", "/a/2/0=true"}
+	if strings.Join(got, " ") != strings.Join(expected, " ") {
+		t.Errorf("ForEachLeaf visited %v, want %v", got, expected)
+	}
+}
+
+func TestNode_FindN(t *testing.T) {
+	root := Must(Unmarshal([]byte(`[{"id": 1, "tags": [{"id": 2}]}, 3, {"id": 4}, {"id": 5}]`)))
+	isObject := func(node *Node) bool { return node.IsObject() }
+
+	tests := []struct {
+		name     string
+		pred     func(*Node) bool
+		limit    int
+		expected []string
+	}{
+		{"first", isObject, 1, []string{`{"id": 1, "tags": [{"id": 2}]}`}},
+		{"document order", isObject, 2, []string{`{"id": 1, "tags": [{"id": 2}]}`, `{"id": 2}`}},
+		{"unlimited", isObject, 0, []string{`{"id": 1, "tags": [{"id": 2}]}`, `{"id": 2}`, `{"id": 4}`, `{"id": 5}`}},
+		{"negative limit", func(node *Node) bool { return node.IsNumber() && node.MustNumeric() > 3 }, -1, []string{`4`, `5`}},
+		{"limit above count", func(node *Node) bool { return node.IsArray() }, 10, []string{root.String(), `[{"id": 2}]`}},
+		{"no match", func(node *Node) bool { return node.IsNull() }, 3, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, node := range root.FindN(tt.pred, tt.limit) {
+				got = append(got, node.String())
+			}
+
+			if strings.Join(got, " | ") != strings.Join(tt.expected, " | ") {
+				t.Errorf("FindN() = %v, want %v", got, tt.expected)
+			}
+		})
+	}
+
+	visited := 0
+	root.FindN(func(node *Node) bool {
+		visited++
+		return node.IsObject()
+	}, 1)
+
+	if visited != 2 {
+		t.Errorf("FindN visited %d nodes after the first match, want 2", visited)
+	}
+}
+
+func TestNode_CountMatches(t *testing.T) {
+	root := Must(Unmarshal([]byte(`[{"id": 1, "tags": [{"id": 2}]}, 3, {"name": "foo"}, {"id": null}]`)))
+
+	tests := []struct {
+		name     string
+		pred     func(*Node) bool
+		expected int
+	}{
+		{"objects", func(node *Node) bool { return node.IsObject() }, 4},
+		{"has key", func(node *Node) bool { return node.HasKey("id") }, 3},
+		{"numbers", func(node *Node) bool { return node.IsNumber() }, 3},
+		{"root included", func(node *Node) bool { return node.IsArray() }, 2},
+		{"all", func(node *Node) bool { return true }, 11},
+		{"no match", func(node *Node) bool { return node.IsBool() }, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := root.CountMatches(tt.pred); got != tt.expected {
+				t.Errorf("CountMatches() = %d, want %d", got, tt.expected)
+			}
+
+			if got := len(root.FindN(tt.pred, 0)); got != tt.expected {
+				t.Errorf("len(FindN()) = %d, want %d", got, tt.expected)
+			}
+		})
+	}
+
+	if got := (*Node)(nil).CountMatches(func(*Node) bool { return true }); got != 0 {
+		t.Errorf("CountMatches on nil = %d, want 0", got)
+	}
+}
+
+func TestNode_FindObjectsWithKeys(t *testing.T) {
+	root := Must(Unmarshal([]byte(`{
+		"store": {
+			"book": [
+				{"title": "a", "author": "x", "price": 1},
+				{"title": "b", "price": 2},
+				{"title": "c", "author": "y", "price": 3, "related": {"title": "d", "author": "z", "price": 4}}
+			],
+			"bicycle": {"color": "red", "price": 5}
+		},
+		"title": "store",
+		"author": null
+	}`)))
+
+	tests := []struct {
+		name     string
+		keys     []string
+		expected []string
+	}{
+		{"books", []string{"title", "author", "price"}, []string{"$['store']['book'][0]", "$['store']['book'][2]", "$['store']['book'][2]['related']"}},
+		{"single key", []string{"price"}, []string{"$['store']['book'][0]", "$['store']['book'][1]", "$['store']['book'][2]", "$['store']['book'][2]['related']", "$['store']['bicycle']"}},
+		{"root included", []string{"title", "author"}, []string{"$", "$['store']['book'][0]", "$['store']['book'][2]", "$['store']['book'][2]['related']"}},
+		{"no keys", nil, []string{"$", "$['store']", "$['store']['book'][0]", "$['store']['book'][1]", "$['store']['book'][2]", "$['store']['book'][2]['related']", "$['store']['bicycle']"}},
+		{"no match", []string{"title", "color"}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, node := range root.FindObjectsWithKeys(tt.keys...) {
+				got = append(got, node.Path())
+			}
+
+			if strings.Join(got, " | ") != strings.Join(tt.expected, " | ") {
+				t.Errorf("FindObjectsWithKeys() = %v, want %v", got, tt.expected)
+			}
+		})
+	}
+
+	if got := (*Node)(nil).FindObjectsWithKeys("a"); got != nil {
+		t.Errorf("FindObjectsWithKeys on nil = %v, want nil", got)
+	}
+}
+
+func TestNode_EnforceLimits(t *testing.T) {
+	tests := []struct {
+		json     string
+		maxDepth int
+		maxNodes int
+		err      string
+	}{
+		{`1`, 1, 1, ``},
+		{`[]`, 1, 1, ``},
+		{`{"a": [[1]]}`, 3, 4, ``},
+		{`{"a": [[1]]}`, 0, 0, ``},
+		{`{"a": [[1]]}`, 2, 0, `depth exceeds the limit of 2 at $['a'][0]`},
+		{`{"a": [[1]]}`, 0, 3, `node count exceeds the limit of 3 at $['a'][0][0]`},
+		{`[1, {"b": null}]`, 0, 3, `node count exceeds the limit of 3 at $[1]['b']`},
+		{`[[], [[]]]`, 2, 0, `depth exceeds the limit of 2 at $[1][0]`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.json, func(t *testing.T) {
+			root := Must(Unmarshal([]byte(tt.json)))
+			err := root.EnforceLimits(tt.maxDepth, tt.maxNodes)
+
+			if tt.err == "" {
+				if err != nil {
+					t.Errorf("EnforceLimits returns error: %v", err)
+				}
+				return
+			}
+
+			if err == nil || err.Error() != tt.err {
+				t.Errorf("EnforceLimits() error = %v, want %s", err, tt.err)
+			}
+		})
+	}
+
+	// a tree built with the node APIs is not limited by the decoder.
+	root := ArrayNode("", nil)
+	for curr, i := root, 0; i < 5; i++ {
+		next := ArrayNode("", nil)
+		if err := curr.AppendArray(next); err != nil {
+			t.Fatalf("AppendArray returns error: %v", err)
+		}
+
+		curr = next
+	}
+
+	if err := root.EnforceLimits(5, 0); err == nil {
+		t.Errorf("EnforceLimits must fail for a depth of 6")
+	}
+}
+
+func TestNode_ToMap(t *testing.T) {
+	root := Must(Unmarshal([]byte(`{"name": "foo", "age": 20, "ok": true, "none": null, "tags": ["a", 1], "nested": {"x": [{"y": false}]}}`)))
+
+	m, err := root.ToMap()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(m) != 6 {
+		t.Errorf("wrong size: %d", len(m))
+	}
+
+	if m["name"] != "foo" || m["age"] != float64(20) || m["ok"] != true || m["none"] != nil {
+		t.Errorf("wrong scalar values: %v", m)
+	}
+
+	tags, ok := m["tags"].([]interface{})
+	if !ok || len(tags) != 2 || tags[0] != "a" || tags[1] != float64(1) {
+		t.Errorf("wrong tags: %v", m["tags"])
+	}
+
+	nested, ok := m["nested"].(map[string]interface{})
+	if !ok {
+		t.Fatalf("wrong nested value: %v", m["nested"])
+	}
+
+	x, ok := nested["x"].([]interface{})
+	if !ok || len(x) != 1 {
+		t.Fatalf("wrong nested array: %v", nested["x"])
+	}
+
+	if y, ok := x[0].(map[string]interface{}); !ok || y["y"] != false {
+		t.Errorf("wrong nested object: %v", x[0])
+	}
+
+	if _, err := Must(Unmarshal([]byte(`[1]`))).ToMap(); err == nil {
+		t.Errorf("expected error for array node")
+	}
+}
+
+func TestNode_ToSlice(t *testing.T) {
+	root := Must(Unmarshal([]byte(`[1.5, "foo", null, true, [2], {"a": "b"}]`)))
+
+	s, err := root.ToSlice()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(s) != 6 || s[0] != 1.5 || s[1] != "foo" || s[2] != nil || s[3] != true {
+		t.Errorf("wrong scalar values: %v", s)
+	}
+
+	if arr, ok := s[4].([]interface{}); !ok || len(arr) != 1 || arr[0] != float64(2) {
+		t.Errorf("wrong nested array: %v", s[4])
+	}
+
+	if obj, ok := s[5].(map[string]interface{}); !ok || obj["a"] != "b" {
+		t.Errorf("wrong nested object: %v", s[5])
+	}
+
+	if _, err := Must(Unmarshal([]byte(`{}`))).ToSlice(); err == nil {
+		t.Errorf("expected error for object node")
+	}
+}
+
+func TestNode_OrderedPairs(t *testing.T) {
+	tests := []struct {
+		name     string
+		json     string
+		modify   func(root *Node) error
+		expected string
+	}{
+		{
+			name:     "document order",
+			json:     `{"name": "foo", "meta": {"z": 1, "a": 2.5}, "tags": ["b", "a"], "ok": true, "none": null}`,
+			expected: `[{name foo} {meta [{z 1} {a 2.5}]} {tags [b a]} {ok true} {none <nil>}]`,
+		},
+		{
+			name:     "nested arrays",
+			json:     `{"rows": [[{"y": 1, "x": 2}], []], "empty": {}}`,
+			expected: `[{rows [[[{y 1} {x 2}]] []]} {empty []}]`,
+		},
+		{
+			name: "added members",
+			json: `{"b": 1, "a": 2}`,
+			modify: func(root *Node) error {
+				if err := root.AppendObject("d", NumberNode("", 3)); err != nil {
+					return err
+				}
+
+				return root.AppendObject("c", StringNode("", "x"))
+			},
+			expected: `[{b 1} {a 2} {c x} {d 3}]`,
+		},
+		{
+			name: "built object",
+			json: `{}`,
+			modify: func(root *Node) error {
+				return root.SetObject(map[string]*Node{"y": NullNode(""), "x": BoolNode("", false)})
+			},
+			expected: `[{x false} {y <nil>}]`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := Must(Unmarshal([]byte(tt.json)))
+			if tt.modify != nil {
+				if err := tt.modify(root); err != nil {
+					t.Fatalf("modify returns error: %v", err)
+				}
+			}
+
+			pairs, err := root.OrderedPairs()
+			if err != nil {
+				t.Fatalf("OrderedPairs returns error: %v", err)
+			}
+
+			if got := fmt.Sprint(pairs); got != tt.expected {
+				t.Errorf("OrderedPairs() = %s, want %s", got, tt.expected)
+			}
+		})
+	}
+
+	pairs, _ := Must(Unmarshal([]byte(`{"a": {"b": [1]}}`))).OrderedPairs()
+	if nested, ok := pairs[0].Value.([]Pair); !ok || nested[0].Key != "b" {
+		t.Errorf("nested object = %#v, want []Pair", pairs[0].Value)
+	} else if elems, ok := nested[0].Value.([]interface{}); !ok || elems[0] != 1.0 {
+		t.Errorf("nested array = %#v, want []interface{}{1.0}", nested[0].Value)
+	}
+
+	for _, input := range []string{`[1]`, `"a"`, `{"a": "\ud800x"}`} {
+		if pairs, err := Must(Unmarshal([]byte(input))).OrderedPairs(); err == nil {
+			t.Errorf("OrderedPairs(%s) = %v, must fail", input, pairs)
+		}
+	}
+
+	if _, err := (*Node)(nil).OrderedPairs(); err != ErrNilNode {
+		t.Errorf("expected ErrNilNode for nil receiver, got %v", err)
+	}
+}
+
+func TestNode_GoString(t *testing.T) {
+	root := Must(Unmarshal([]byte(`{"key": ["value"]}`)))
+
+	tests := []struct {
+		name     string
+		node     *Node
+		expected string
+	}{
+		{
+			name:     "root",
+			node:     root,
+			expected: `Node{key: nil, nodeType: object, index: nil, borders: [0 18], modified: false}`,
+		},
+		{
+			name:     "object member",
+			node:     root.MustKey("key"),
+			expected: `Node{key: "key", nodeType: array, index: nil, borders: [8 17], modified: false}`,
+		},
+		{
+			name:     "array element",
+			node:     root.MustKey("key").MustIndex(0),
+			expected: `Node{key: nil, nodeType: string, index: 0, borders: [9 16], modified: false}`,
+		},
+		{
+			name:     "nil",
+			node:     nil,
+			expected: `Node(nil)`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := fmt.Sprintf("%#v", tt.node); got != tt.expected {
+				t.Errorf("GoString() = %s, want %s", got, tt.expected)
+			}
+		})
+	}
+
+	if root.String() != `{"key": ["value"]}` {
+		t.Errorf("String() must stay the JSON representation: %s", root.String())
+	}
+}
+
+func TestNode_Format(t *testing.T) {
+	root := Must(Unmarshal([]byte(`{"key": [1, 2], "s": "a b"}`)))
+	arr := root.MustKey("key")
+
+	tests := []struct {
+		name     string
+		format   string
+		node     *Node
+		expected string
+	}{
+		{name: "v", format: "%v", node: arr, expected: `[1,2]`},
+		{name: "s", format: "%s", node: Must(Unmarshal([]byte(`{ "s" : "a b" }`))), expected: `{"s":"a b"}`},
+		{name: "q", format: "%q", node: arr, expected: `"[1,2]"`},
+		{name: "plus v", format: "%+v", node: arr, expected: arr.GoString()},
+		{name: "sharp v", format: "%#v", node: arr, expected: arr.GoString()},
+		{name: "width", format: "%1v", node: Must(Unmarshal([]byte(`{"a": 1}`))), expected: "{\n \"a\": 1\n}"},
+		{name: "width nested", format: "%2v", node: Must(Unmarshal([]byte(`{"a": [1, {"b": 2}]}`))), expected: "{\n  \"a\": [\n    1,\n    {\n      \"b\": 2\n    }\n  ]\n}"},
+		{name: "precision", format: "%.1v", node: Must(Unmarshal([]byte(`{"a": 1}`))), expected: "{\n\t\"a\": 1\n}"},
+		{name: "precision nested", format: "%.2v", node: Must(Unmarshal([]byte(`{"a": [1]}`))), expected: "{\n\t\t\"a\": [\n\t\t\t\t1\n\t\t]\n}"},
+		{name: "width over precision", format: "%3.1v", node: Must(Unmarshal([]byte(`[1]`))), expected: "[\n   1\n]"},
+		{name: "nil", format: "%v", node: nil, expected: ``},
+		{name: "bad verb", format: "%d", node: arr, expected: `%!d(*json.Node=[1, 2])`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := fmt.Sprintf(tt.format, tt.node); got != tt.expected {
+				t.Errorf("Sprintf(%s) = %q, want %q", tt.format, got, tt.expected)
+			}
+		})
+	}
+}
@@ -3,9 +3,16 @@ package json
 import (
 	"bytes"
 	"errors"
+	"math"
 	"strconv"
 )
 
+var (
+	errRangeLimit       = errors.New("JSON Error: numeric value exceeds the range limit")
+	errMantissaOverflow = errors.New("JSON Error: mantissa exceeds the range limit")
+	errLeadingPlus      = errors.New("JSON Error: invalid number: leading plus")
+)
+
 const (
 	absMinInt64 = 1 << 63
 	maxInt64    = absMinInt64 - 1
@@ -42,12 +49,24 @@ func ParseBoolLiteral(data []byte) (bool, error) {
 //
 // It utilizes double-precision (64-bit) floating-point format as defined
 // by the IEEE 754 standard, providing a decimal precision of approximately 15 digits.
+//
+// This is the function used to decode the value of number nodes (see Node.Value),
+// the decoder itself only validates the number grammar. The result is the same as
+// strconv.ParseFloat: integers up to 2^53 are exact, longer literals are rounded to
+// the nearest float64, and a value outside the float64 range returns an error.
+//
+// A leading '+' is rejected as JSON does not allow it.
 // TODO: support for 32-bit floating-point format
 func ParseFloatLiteral(bytes []byte) (value float64, err error) {
 	if len(bytes) == 0 {
 		return -1, errors.New("JSON Error: empty byte slice found while parsing float value")
 	}
 
+	if bytes[0] == plus {
+		return -1, errLeadingPlus
+	}
+
+	literal := bytes
 	neg, bytes := trimNegativeSign(bytes)
 
 	var exponentPart []byte
@@ -60,6 +79,11 @@ func ParseFloatLiteral(bytes []byte) (value float64, err error) {
 	}
 
 	man, exp10, err := extractMantissaAndExp10(bytes)
+	if err == errMantissaOverflow {
+		// too many digits for the fast path, but not necessarily out of range.
+		return parseFloatFallback(literal)
+	}
+
 	if err != nil {
 		return -1, err
 	}
@@ -74,13 +98,63 @@ func ParseFloatLiteral(bytes []byte) (value float64, err error) {
 
 	// for fast float64 conversion
 	f, success := eiselLemire64(man, exp10, neg)
-	if !success {
-		return 0, nil
+	if success {
+		return f, nil
+	}
+
+	// Eisel-Lemire gives up on half-way ambiguous values (e.g. 1.5, 0.25),
+	// so fall back to the slower but exact conversion for those.
+	return parseFloatFallback(literal)
+}
+
+// parseFloatFallback parses the number literal with the slower but exact strconv.ParseFloat.
+func parseFloatFallback(literal []byte) (float64, error) {
+	f, err := strconv.ParseFloat(string(literal), 64)
+	if errors.Is(err, strconv.ErrRange) {
+		return -1, errRangeLimit
+	}
+
+	if err != nil {
+		return -1, errors.New("JSON Error: invalid numeric value found while parsing float value")
 	}
 
 	return f, nil
 }
 
+// parseNonFiniteLiteral parses the non-standard NaN and Infinity literals.
+// It returns false if the given byte slice is not one of them.
+func parseNonFiniteLiteral(data []byte) (float64, bool) {
+	switch {
+	case bytes.Equal(data, nanLiteral):
+		return math.NaN(), true
+	case bytes.Equal(data, infinityLiteral):
+		return math.Inf(1), true
+	case bytes.Equal(data, negInfinityLiteral):
+		return math.Inf(-1), true
+	default:
+		return 0, false
+	}
+}
+
+// containsNonFinite checks whether the given JSON source contains
+// NaN or Infinity literals outside of string literals.
+func containsNonFinite(data []byte) bool {
+	inString := false
+
+	for i := 0; i < len(data); i++ {
+		switch c := data[i]; {
+		case inString && c == backSlash:
+			i++ // skip the escaped character
+		case c == doubleQuote:
+			inString = !inString
+		case !inString && (c == 'N' || c == 'I'):
+			return true
+		}
+	}
+
+	return false
+}
+
 func ParseIntLiteral(bytes []byte) (v int64, err error) {
 	if len(bytes) == 0 {
 		return 0, errors.New("JSON Error: empty byte slice found while parsing integer value")
@@ -95,14 +169,14 @@ func ParseIntLiteral(bytes []byte) (v int64, err error) {
 		}
 
 		if n > maxUint64/10 {
-			return 0, errors.New("JSON Error: numeric value exceeds the range limit")
+			return 0, errRangeLimit
 		}
 
 		n *= 10
 
 		n1 := n + uint64(c-'0')
 		if n1 < n {
-			return 0, errors.New("JSON Error: numeric value exceeds the range limit")
+			return 0, errRangeLimit
 		}
 
 		n = n1
@@ -113,7 +187,7 @@ func ParseIntLiteral(bytes []byte) (v int64, err error) {
 			return -absMinInt64, nil
 		}
 
-		return 0, errors.New("JSON Error: numeric value exceeds the range limit")
+		return 0, errRangeLimit
 	}
 
 	if neg {
@@ -153,7 +227,7 @@ func extractMantissaAndExp10(bytes []byte) (uint64, int, error) {
 		digit := uint64(c - '0')
 
 		if man > (maxUint64-digit)/10 {
-			return 0, 0, errors.New("JSON Error: numeric value exceeds the range limit")
+			return 0, 0, errMantissaOverflow
 		}
 
 		man = man*10 + digit
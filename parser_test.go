@@ -1,7 +1,9 @@
 package json
 
 import (
+	"math"
 	"strconv"
+	"strings"
 	"testing"
 )
 
@@ -81,10 +83,17 @@ func TestParseFloatLiteral(t *testing.T) {
 		{"-12345678.09123456789", -12345678.09123456789},
 		{"0.123", 0.123},
 		{"-0.123", -0.123},
+		{"1.5", 1.5},
+		{"2.0", 2},
+		{"0.25", 0.25},
+		{"-3.5", -3.5},
+		{"100.5", 100.5},
 		{"", -1},
 		{"abc", -1},
 		{"123.45.6", -1},
-		{"999999999999999999999", -1},
+		{"999999999999999999999", 1e21},
+		{"12345678901234567890123.5", 1.2345678901234568e22},
+		{"1e400", -1},
 	}
 
 	for _, tt := range tests {
@@ -141,8 +150,9 @@ func TestParseFloat_May_Interoperability_Problem(t *testing.T) {
 		input     string
 		shouldErr bool
 	}{
-		{"3.141592653589793238462643383279", true},
-		{"1E400", false}, // TODO: should error
+		{"3.141592653589793238462643383279", false},
+		{"1E400", true},
+		{"-1.8e308", true},
 	}
 
 	for _, tt := range tests {
@@ -155,6 +165,82 @@ func TestParseFloat_May_Interoperability_Problem(t *testing.T) {
 	}
 }
 
+// TestParseFloatLiteral_Strconv checks that ParseFloatLiteral, used to decode number
+// nodes, agrees with strconv.ParseFloat on both values and out-of-range errors.
+func TestParseFloatLiteral_Strconv(t *testing.T) {
+	tests := []string{
+		"0",
+		"-0",
+		"9007199254740991",  // 2^53 - 1
+		"9007199254740992",  // 2^53
+		"-9007199254740992", // -2^53
+		"9007199254740993",  // 2^53 + 1, rounded
+		"18446744073709551615",
+		"18446744073709551616",
+		"123456789012345678901234567890",
+		"0.000000000000000000000000000001234567890123456789",
+		"3.141592653589793238462643383279",
+		"1.7976931348623157e308",
+		"4.9e-324",
+		"2e-324",
+		"1e-400",
+		"1e308",
+		"1.8e308",
+		"1e400",
+		"-1e400",
+		"123456789012345678901234567890e300",
+	}
+
+	for _, input := range tests {
+		t.Run(input, func(t *testing.T) {
+			expected, strconvErr := strconv.ParseFloat(input, 64)
+			got, err := ParseFloatLiteral([]byte(input))
+
+			if (err != nil) != (strconvErr != nil) {
+				t.Fatalf("errors disagree: ParseFloatLiteral = %v, strconv = %v", err, strconvErr)
+			}
+
+			if err != nil {
+				if err != errRangeLimit {
+					t.Errorf("expected range error, got %v", err)
+				}
+				return
+			}
+
+			if math.Float64bits(got) != math.Float64bits(expected) {
+				t.Errorf("got %v, want %v", got, expected)
+			}
+
+			node := Must(Unmarshal([]byte(input)))
+			if value := node.MustNumeric(); math.Float64bits(value) != math.Float64bits(expected) {
+				t.Errorf("decoded node value = %v, want %v", value, expected)
+			}
+		})
+	}
+}
+
+func TestParseFloatLiteral_LeadingPlus(t *testing.T) {
+	tests := []string{"+1", "+0.5", "+1e10", "+123456789012345678901234567890", "+"}
+
+	for _, input := range tests {
+		t.Run(input, func(t *testing.T) {
+			_, err := ParseFloatLiteral([]byte(input))
+			if err == nil {
+				t.Fatalf("ParseFloatLiteral(%s) must fail", input)
+			}
+
+			if !strings.Contains(err.Error(), "invalid number: leading plus") {
+				t.Errorf("ParseFloatLiteral(%s) error = %v, want leading plus error", input, err)
+			}
+		})
+	}
+
+	// the exponent sign is still allowed.
+	if got, err := ParseFloatLiteral([]byte("1e+2")); err != nil || got != 100 {
+		t.Errorf("ParseFloatLiteral(1e+2) = %v, %v, want 100", got, err)
+	}
+}
+
 func TestParseIntLiteral(t *testing.T) {
 	tests := []struct {
 		input    string
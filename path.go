@@ -3,6 +3,8 @@ package json
 import (
 	"fmt"
 	"regexp"
+	"sort"
+	"strconv"
 	"strings"
 	"unicode"
 )
@@ -127,3 +129,813 @@ func classify(tokens []PathToken) ([]ClassifiedToken, error) {
 
 	return classified, nil
 }
+
+// SegmentKind is the kind of a segment of a parsed JSON path.
+type SegmentKind int
+
+const (
+	SegmentRoot             SegmentKind = iota // `$`, the root node.
+	SegmentCurrent                             // `@`, the current node of a filter expression.
+	SegmentKey                                 // `.key`, `['key']` or `["key"]`.
+	SegmentIndex                               // `[0]`, a negative index counts from the end.
+	SegmentWildcard                            // `.*` or `[*]`.
+	SegmentRecursiveDescent                    // `..`, applying the segment following it at all depths.
+	SegmentSlice                               // `[start:end:step]`, each bound is optional.
+	SegmentFilter                              // `[?(expression)]`.
+	SegmentUnion                               // `['a','b']` or `[0,1]`.
+)
+
+func (k SegmentKind) String() string {
+	switch k {
+	case SegmentRoot:
+		return "root"
+	case SegmentCurrent:
+		return "current"
+	case SegmentKey:
+		return "key"
+	case SegmentIndex:
+		return "index"
+	case SegmentWildcard:
+		return "wildcard"
+	case SegmentRecursiveDescent:
+		return "recursive-descent"
+	case SegmentSlice:
+		return "slice"
+	case SegmentFilter:
+		return "filter"
+	case SegmentUnion:
+		return "union"
+	default:
+		return "unknown"
+	}
+}
+
+// Segment is a segment of a parsed JSON path. Only the fields of its kind are set.
+type Segment struct {
+	Kind SegmentKind
+
+	Key   string // Key is the unquoted object key of a SegmentKey.
+	Index int    // Index is the array index of a SegmentIndex.
+
+	// Start, End and Step are the bounds of a SegmentSlice, nil if omitted.
+	Start, End, Step *int
+
+	Filter string    // Filter is the expression of a SegmentFilter, without the enclosing `?(` and `)`.
+	Union  []Segment // Union holds the members of a SegmentUnion, each a key, index, slice or wildcard.
+}
+
+// ParsePathTyped parses the JSON path into typed segments, so that a consumer can tell
+// a key from an index, a wildcard or a filter without inspecting the path again.
+//
+// The path starts with `$` (or `@` for a path relative to the current node of a filter),
+// followed by dot-notation (`.key`, `.*`), bracket-notation (`['key']`, `[0]`, `[*]`,
+// `[1:3]`, `[?(...)]`, `['a','b']`) and recursive descent (`..key`, `..[0]`) segments.
+// A recursive descent is returned as its own segment, followed by the segment it applies to.
+// Filter expressions are returned as-is and are not parsed.
+//
+// Usage:
+//
+//	segments, err := ParsePathTyped("$.store..book[0:2]")
+//	if err != nil {
+//		t.Errorf("ParsePathTyped returns error: %v", err)
+//	}
+//
+//	result: [root, key "store", recursive-descent, key "book", slice 0:2]
+func ParsePathTyped(path string) ([]Segment, error) {
+	p := &pathParser{path: path}
+	return p.parse()
+}
+
+// pathParser parses a JSON path into segments.
+type pathParser struct {
+	path string
+	pos  int
+}
+
+func (p *pathParser) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("invalid path %q: %s", p.path, fmt.Sprintf(format, args...))
+}
+
+// peek returns the current byte, or 0 at the end of the path.
+func (p *pathParser) peek() byte {
+	if p.pos >= len(p.path) {
+		return 0
+	}
+
+	return p.path[p.pos]
+}
+
+func (p *pathParser) skipSpaces() {
+	for p.peek() == ' ' {
+		p.pos++
+	}
+}
+
+func (p *pathParser) parse() ([]Segment, error) {
+	var segments []Segment
+
+	switch p.peek() {
+	case '$':
+		segments = append(segments, Segment{Kind: SegmentRoot})
+	case '@':
+		segments = append(segments, Segment{Kind: SegmentCurrent})
+	default:
+		return nil, p.errorf("must start with '$' or '@'")
+	}
+
+	p.pos++
+	for p.pos < len(p.path) {
+		var (
+			seg Segment
+			err error
+		)
+
+		switch p.peek() {
+		case '.':
+			p.pos++
+			if p.peek() == '.' {
+				p.pos++
+				segments = append(segments, Segment{Kind: SegmentRecursiveDescent})
+
+				if p.peek() == '[' {
+					continue
+				}
+			}
+
+			seg, err = p.member()
+
+		case '[':
+			seg, err = p.bracket()
+
+		default:
+			err = p.errorf("unexpected character %q at %d", p.peek(), p.pos)
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		segments = append(segments, seg)
+	}
+
+	return segments, nil
+}
+
+// member parses the key or the wildcard following a dot.
+func (p *pathParser) member() (Segment, error) {
+	if p.peek() == '*' {
+		p.pos++
+		return Segment{Kind: SegmentWildcard}, nil
+	}
+
+	start := p.pos
+	for p.pos < len(p.path) && p.path[p.pos] != '.' && p.path[p.pos] != '[' {
+		p.pos++
+	}
+
+	if p.pos == start {
+		return Segment{}, p.errorf("empty key at %d", start)
+	}
+
+	return Segment{Kind: SegmentKey, Key: p.path[start:p.pos]}, nil
+}
+
+// bracket parses a bracket-notation segment, which is a filter or a list of selectors.
+func (p *pathParser) bracket() (Segment, error) {
+	open := p.pos
+	p.pos++
+	p.skipSpaces()
+
+	if p.peek() == '?' {
+		return p.filter(open)
+	}
+
+	var members []Segment
+	for {
+		p.skipSpaces()
+
+		seg, err := p.selector()
+		if err != nil {
+			return Segment{}, err
+		}
+
+		members = append(members, seg)
+		p.skipSpaces()
+
+		switch p.peek() {
+		case ',':
+			p.pos++
+			continue
+		case ']':
+			p.pos++
+		default:
+			return Segment{}, p.errorf("mismatched brackets at %d", open)
+		}
+
+		break
+	}
+
+	if len(members) == 1 {
+		return members[0], nil
+	}
+
+	return Segment{Kind: SegmentUnion, Union: members}, nil
+}
+
+// selector parses a single selector of a bracket: a quoted key, a wildcard, an index or a slice.
+func (p *pathParser) selector() (Segment, error) {
+	switch c := p.peek(); c {
+	case '*':
+		p.pos++
+		return Segment{Kind: SegmentWildcard}, nil
+
+	case '\'', '"':
+		key, end, err := quotedPathKey(p.path, p.pos)
+		if err != nil {
+			return Segment{}, err
+		}
+
+		p.pos = end
+		return Segment{Kind: SegmentKey, Key: key}, nil
+	}
+
+	start := p.pos
+
+	var bounds [3]*int
+	colons := 0
+	for {
+		p.skipSpaces()
+		if c := p.peek(); c == '-' || isDecimalDigit(c) {
+			n, err := p.integer()
+			if err != nil {
+				return Segment{}, err
+			}
+
+			bounds[colons] = &n
+		}
+
+		p.skipSpaces()
+		if p.peek() != ':' || colons == 2 {
+			break
+		}
+
+		colons++
+		p.pos++
+	}
+
+	if colons == 0 {
+		if bounds[0] == nil {
+			return Segment{}, p.errorf("invalid selector at %d", start)
+		}
+
+		return Segment{Kind: SegmentIndex, Index: *bounds[0]}, nil
+	}
+
+	if bounds[2] != nil && *bounds[2] == 0 {
+		return Segment{}, p.errorf("slice step must not be zero at %d", start)
+	}
+
+	return Segment{Kind: SegmentSlice, Start: bounds[0], End: bounds[1], Step: bounds[2]}, nil
+}
+
+// integer parses an optionally negative decimal integer.
+func (p *pathParser) integer() (int, error) {
+	start := p.pos
+	if p.peek() == '-' {
+		p.pos++
+	}
+
+	for isDecimalDigit(p.peek()) {
+		p.pos++
+	}
+
+	n, err := strconv.Atoi(p.path[start:p.pos])
+	if err != nil {
+		return 0, p.errorf("invalid integer %q at %d", p.path[start:p.pos], start)
+	}
+
+	return n, nil
+}
+
+// filter parses the `?(expression)]` part of a filter segment opened at the given position.
+func (p *pathParser) filter(open int) (Segment, error) {
+	p.pos++
+	p.skipSpaces()
+
+	if p.peek() != '(' {
+		return Segment{}, p.errorf("filter must be enclosed in parentheses at %d", open)
+	}
+
+	start := p.pos + 1
+	depth := 0
+	for ; p.pos < len(p.path); p.pos++ {
+		switch c := p.path[p.pos]; c {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case '\'', '"':
+			_, end, err := quotedPathKey(p.path, p.pos)
+			if err != nil {
+				return Segment{}, err
+			}
+
+			p.pos = end - 1
+		}
+
+		if depth == 0 {
+			break
+		}
+	}
+
+	if depth != 0 {
+		return Segment{}, p.errorf("unclosed parenthesis at %d", start-1)
+	}
+
+	expr := p.path[start:p.pos]
+	p.pos++
+	p.skipSpaces()
+
+	if p.peek() != ']' {
+		return Segment{}, p.errorf("mismatched brackets at %d", open)
+	}
+
+	p.pos++
+	return Segment{Kind: SegmentFilter, Filter: strings.TrimSpace(expr)}, nil
+}
+
+func isDecimalDigit(c byte) bool {
+	return '0' <= c && c <= '9'
+}
+
+// HasPath reports whether the given JSON path matches at least one node from the current node.
+//
+// The path is evaluated like Collect, so it may be any path supported by Collect. It reports
+// false for a malformed or unsupported path instead of panicking.
+//
+// Usage:
+//
+//	root := Must(Unmarshal([]byte(`{"key": {"sub": ["val1", "val2"]}}`)))
+//	root.HasPath("$.key.sub[1]")      // true
+//	root.HasPath("$..sub[*]")         // true
+//	root.HasPath("$['key']['other']") // false
+func (n *Node) HasPath(path string) bool {
+	nodes, err := n.Collect(path)
+	return err == nil && len(nodes) > 0
+}
+
+// Collect returns the nodes matched by the JSON path from the current node, in document order.
+//
+// The path is parsed by ParsePathTyped, and `$` (or `@`) refers to the current node.
+// Keys, indices, wildcards, slices, unions and recursive descent are supported, while
+// filter expressions return an error as they are not evaluated. A segment that doesn't
+// apply to a node (e.g. a key on an array, or an index out of range) matches nothing.
+// So a union may mix keys and indices, like `$['name',0]`: each member is applied to each
+// node, and the results are combined in the listed order.
+//
+// The returned nodes are the nodes of the tree, not copies, so modifying them modifies the tree.
+//
+// Usage:
+//
+//	root := Must(Unmarshal([]byte(`{"store": {"book": [{"price": 8.95}, {"price": 12.99}]}}`)))
+//	prices, err := root.Collect("$..price")
+//	if err != nil {
+//		t.Errorf("Collect returns error: %v", err)
+//	}
+//
+//	result: [8.95, 12.99]
+func (n *Node) Collect(path string) ([]*Node, error) {
+	if n == nil {
+		return nil, ErrNilNode
+	}
+
+	segments, err := ParsePathTyped(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return n.collect(segments)
+}
+
+// collect returns the nodes matched by the parsed path from the current node. See Collect.
+func (n *Node) collect(segments []Segment) ([]*Node, error) {
+	nodes := []*Node{n}
+	for _, seg := range segments[1:] {
+		if seg.Kind == SegmentRecursiveDescent {
+			nodes = descendantsOrSelf(nodes)
+			continue
+		}
+
+		var next []*Node
+		for _, node := range nodes {
+			matched, err := selectSegment(node, seg)
+			if err != nil {
+				return nil, err
+			}
+
+			next = append(next, matched...)
+		}
+
+		nodes = next
+	}
+
+	return nodes, nil
+}
+
+// Result is a list of nodes matched by a JSON path, with helpers for the common reductions.
+type Result []*Node
+
+// Query returns the nodes matched by the JSON path from the current node as a Result.
+// See Collect for the supported paths.
+//
+// Usage:
+//
+//	root := Must(Unmarshal([]byte(`{"book": [{"price": 8.95}, {"price": 12.99}]}`)))
+//	result, err := root.Query("$..price")
+//	if err != nil {
+//		t.Errorf("Query returns error: %v", err)
+//	}
+//
+//	result: result.Numbers() == [8.95, 12.99]
+func (n *Node) Query(path string) (Result, error) {
+	return n.Collect(path)
+}
+
+// Query parses the JSON-encoded data and returns the nodes matched by the JSON path as a Result.
+//
+// Usage:
+//
+//	result, err := json.Query(data, "$..price")
+//	if err != nil {
+//		return err
+//	}
+//
+//	prices := result.Numbers()
+func Query(data []byte, path string) (Result, error) {
+	root, err := Unmarshal(data)
+	if err != nil {
+		return nil, err
+	}
+
+	return root.Query(path)
+}
+
+// FirstPath returns the node matched by the first of the given paths that matches exactly
+// one node, trying them in order. It suits documents whose fields were renamed across
+// versions, e.g. FirstPath("$.fullName", "$.name", "$.displayName").
+//
+// A candidate matching no node, or several nodes like a wildcard over many elements,
+// is skipped. If no candidate matches exactly one node, it returns an error wrapping
+// ErrKeyNotFound. An invalid path is returned as an error immediately.
+//
+// Usage:
+//
+//	root := Must(Unmarshal([]byte(`{"user": {"display_name": "foo"}}`)))
+//	name, err := root.FirstPath("$.user.fullName", "$.user.name", "$.user.display_name")
+//	if err != nil {
+//		t.Errorf("FirstPath returns error: %v", err)
+//	}
+//
+//	result: "foo"
+func (n *Node) FirstPath(paths ...string) (*Node, error) {
+	if n == nil {
+		return nil, ErrNilNode
+	}
+
+	for _, path := range paths {
+		nodes, err := n.Collect(path)
+		if err != nil {
+			return nil, fmt.Errorf("path %s: %w", path, err)
+		}
+
+		if len(nodes) == 1 {
+			return nodes[0], nil
+		}
+	}
+
+	return nil, fmt.Errorf("no path matches exactly one node in %s: %w", strings.Join(paths, ", "), ErrKeyNotFound)
+}
+
+// RedactedValue is the string that Redact writes in place of the redacted values.
+const RedactedValue = "[REDACTED]"
+
+// Redact replaces the values matched by the given JSON paths with the string RedactedValue,
+// and returns the number of redacted values. It is equivalent to RedactWith(RedactedValue, paths...).
+//
+// Usage:
+//
+//	root := Must(Unmarshal([]byte(`{"user": "foo", "password": "bar", "db": {"password": "baz"}}`)))
+//	count, err := root.Redact("$..password")
+//
+//	result: count == 2, {"user": "foo", "password": "[REDACTED]", "db": {"password": "[REDACTED]"}}
+func (n *Node) Redact(paths ...string) (int, error) {
+	return n.RedactWith(RedactedValue, paths...)
+}
+
+// RedactWith replaces the values matched by the given JSON paths with the given string,
+// and returns the number of redacted values. See Collect for the supported paths, e.g.
+// `$..password` redacts every password member at any depth.
+//
+// A value matched by several paths is redacted once, and the values inside an already
+// redacted value are not counted. The paths are checked before any value is replaced,
+// so the node is left unchanged if one of them is invalid.
+func (n *Node) RedactWith(sentinel string, paths ...string) (int, error) {
+	if n == nil {
+		return 0, ErrNilNode
+	}
+
+	var matched []*Node
+	for _, path := range paths {
+		nodes, err := n.Collect(path)
+		if err != nil {
+			return 0, fmt.Errorf("path %s: %w", path, err)
+		}
+
+		matched = append(matched, nodes...)
+	}
+
+	root := n.root()
+	redacted := make(map[*Node]bool, len(matched))
+	for _, node := range matched {
+		// a node inside a redacted value is detached from the tree.
+		if redacted[node] || node.root() != root {
+			continue
+		}
+
+		if err := node.SetString(sentinel); err != nil {
+			return len(redacted), err
+		}
+
+		redacted[node] = true
+	}
+
+	return len(redacted), nil
+}
+
+// Len returns the number of nodes.
+func (r Result) Len() int {
+	return len(r)
+}
+
+// First returns the first node, or nil if the result is empty.
+func (r Result) First() *Node {
+	if len(r) == 0 {
+		return nil
+	}
+
+	return r[0]
+}
+
+// Strings returns the values of the string nodes. The other nodes are skipped.
+func (r Result) Strings() []string {
+	values := make([]string, 0, len(r))
+	for _, node := range r {
+		if value, err := node.GetString(); err == nil {
+			values = append(values, value)
+		}
+	}
+
+	return values
+}
+
+// Numbers returns the values of the number nodes. The other nodes are skipped.
+func (r Result) Numbers() []float64 {
+	values := make([]float64, 0, len(r))
+	for _, node := range r {
+		if value, err := node.GetNumeric(); err == nil {
+			values = append(values, value)
+		}
+	}
+
+	return values
+}
+
+// Array returns a new array node holding the clones of the nodes,
+// so the nodes themselves are left in their tree.
+func (r Result) Array() *Node {
+	elems := make([]*Node, len(r))
+	for i, node := range r {
+		elems[i] = node.Clone()
+	}
+
+	return ArrayNode("", elems)
+}
+
+// selectSegment returns the children of the node matched by the segment.
+func selectSegment(node *Node, seg Segment) ([]*Node, error) {
+	switch seg.Kind {
+	case SegmentKey:
+		if child, ok := node.next[seg.Key]; ok && node.IsObject() {
+			return []*Node{child}, nil
+		}
+
+	case SegmentIndex:
+		if child, err := node.GetIndex(seg.Index); err == nil {
+			return []*Node{child}, nil
+		}
+
+	case SegmentWildcard:
+		if node.isContainer() {
+			return node.children(), nil
+		}
+
+	case SegmentSlice:
+		if node.IsArray() {
+			return sliceArray(node, seg), nil
+		}
+
+	case SegmentUnion:
+		var result []*Node
+		for _, member := range seg.Union {
+			matched, err := selectSegment(node, member)
+			if err != nil {
+				return nil, err
+			}
+
+			result = append(result, matched...)
+		}
+
+		return result, nil
+
+	case SegmentFilter:
+		return nil, fmt.Errorf("filter expression %q is not supported", seg.Filter)
+
+	default:
+		return nil, fmt.Errorf("unexpected %s segment", seg.Kind)
+	}
+
+	return nil, nil
+}
+
+// sliceArray returns the elements of the array node selected by the slice segment.
+func sliceArray(node *Node, seg Segment) []*Node {
+	var result []*Node
+	for _, i := range sliceIndices(node.Size(), seg.Start, seg.End, seg.Step) {
+		result = append(result, node.next[strconv.Itoa(i)])
+	}
+
+	return result
+}
+
+// sliceIndices returns the indices selected by the slice [start:end:step] of an array of
+// the given size, with the semantics of RFC 9535 (and Python): a nil bound is open, a negative
+// bound counts from the end, and a negative step selects the elements in reverse order.
+// The bounds are clamped to the array, and a zero step selects nothing.
+func sliceIndices(size int, start, end, step *int) []int {
+	s := 1
+	if step != nil {
+		s = *step
+	}
+
+	bound := func(i int) int {
+		if i < 0 {
+			i += size
+		}
+
+		if s > 0 {
+			return min(max(i, 0), size)
+		}
+
+		return min(max(i, -1), size-1)
+	}
+
+	var from, to int
+	if s > 0 {
+		from, to = 0, size
+	} else {
+		from, to = size-1, -1
+	}
+
+	if start != nil {
+		from = bound(*start)
+	}
+
+	if end != nil {
+		to = bound(*end)
+	}
+
+	var indices []int
+	for i := from; (s > 0 && i < to) || (s < 0 && i > to); i += s {
+		indices = append(indices, i)
+	}
+
+	return indices
+}
+
+// descendantsOrSelf returns the given nodes each followed by all of its descendants, in document order.
+func descendantsOrSelf(nodes []*Node) []*Node {
+	var result []*Node
+
+	var walk func(node *Node)
+	walk = func(node *Node) {
+		result = append(result, node)
+		for _, child := range node.children() {
+			walk(child)
+		}
+	}
+
+	for _, node := range nodes {
+		walk(node)
+	}
+
+	return result
+}
+
+// Project builds a new object whose members are copies of the nodes matched by the JSON
+// paths of spec in the source, keyed by the keys of spec. A path that matches nothing is
+// omitted from the result.
+//
+// The paths are evaluated like Collect. A definite path, made of keys and indices only,
+// yields the matched node itself. Any other path (e.g. with a wildcard, a slice, a union or
+// a recursive descent) yields an array of the matched nodes in document order, even if it
+// matches a single node, so the shape of the result doesn't depend on the source.
+//
+// Usage:
+//
+//	source := Must(Unmarshal([]byte(`{"user": {"name": "foo", "tags": ["a", "b"]}, "id": 1}`)))
+//	dto, err := Project(source, map[string]string{"name": "$.user.name", "tags": "$.user.tags[*]"})
+//
+//	result: {"name": "foo", "tags": ["a", "b"]}
+func Project(source *Node, spec map[string]string) (*Node, error) {
+	return ProjectWith(source, spec, false)
+}
+
+// ProjectWith is like Project, but sets the keys whose path matches nothing to null
+// instead of omitting them if nullMissing is true.
+func ProjectWith(source *Node, spec map[string]string, nullMissing bool) (*Node, error) {
+	if source == nil {
+		return nil, ErrNilNode
+	}
+
+	keys := make([]string, 0, len(spec))
+	for key := range spec {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	fields := make(map[string]*Node, len(spec))
+	for _, key := range keys {
+		segments, err := ParsePathTyped(spec[key])
+		if err != nil {
+			return nil, fmt.Errorf("project %q: %w", key, err)
+		}
+
+		nodes, err := source.collect(segments)
+		if err != nil {
+			return nil, fmt.Errorf("project %q: %w", key, err)
+		}
+
+		switch {
+		case len(nodes) == 0:
+			if nullMissing {
+				fields[key] = NullNode(key)
+			}
+		case isDefinitePath(segments):
+			fields[key] = nodes[0].Clone()
+		default:
+			elems := make([]*Node, len(nodes))
+			for i, node := range nodes {
+				elems[i] = node.Clone()
+			}
+
+			fields[key] = ArrayNode(key, elems)
+		}
+	}
+
+	return ObjectNode("", fields), nil
+}
+
+// isDefinitePath reports whether the parsed path addresses a single location,
+// i.e. it is made of keys and indices only.
+func isDefinitePath(segments []Segment) bool {
+	for _, seg := range segments[1:] {
+		if seg.Kind != SegmentKey && seg.Kind != SegmentIndex {
+			return false
+		}
+	}
+
+	return true
+}
+
+// quotedPathKey reads the quoted key starting at path[start] and returns it with
+// the index of the byte following the closing quote. `\` escapes the next byte.
+func quotedPathKey(path string, start int) (string, int, error) {
+	quote := path[start]
+
+	var sb strings.Builder
+	for i := start + 1; i < len(path); i++ {
+		switch path[i] {
+		case quote:
+			return sb.String(), i + 1, nil
+		case '\\':
+			if i+1 >= len(path) {
+				return "", 0, fmt.Errorf("invalid path %q: unterminated string", path)
+			}
+			i++
+		}
+
+		sb.WriteByte(path[i])
+	}
+
+	return "", 0, fmt.Errorf("invalid path %q: unterminated string", path)
+}
@@ -1,6 +1,8 @@
 package json
 
 import (
+	"errors"
+	"reflect"
 	"testing"
 )
 
@@ -102,4 +104,685 @@ func TestClassify(t *testing.T) {
             }
         }
     }
-}
\ No newline at end of file
+}
+
+func TestNode_HasPath(t *testing.T) {
+	root := Must(Unmarshal([]byte(`{"key": {"sub": ["val1", "val2"], "a.b": {"c'd": null}}, "arr": [[1]]}`)))
+
+	tests := []struct {
+		path     string
+		expected bool
+	}{
+		{"$", true},
+		{"$.key", true},
+		{"$.key.sub[1]", true},
+		{"$['key']['sub'][0]", true},
+		{`$["key"]["a.b"]`, true},
+		{`$.key['a.b']['c\'d']`, true},
+		{"$.arr[0][0]", true},
+		{"$.key.sub[2]", false},
+		{"$.key.sub[-1]", true},
+		{"$.key.other", false},
+		{"$.key.sub.val1", false},
+		{"$.arr[0][0][0]", false},
+		{"", false},
+		{"key", false},
+		{"$.", false},
+		{"$..key", true},
+		{"$[", false},
+		{"$[]", false},
+		{"$['key'", false},
+		{"$['key]", false},
+		{"$[0", false},
+		{"$.key.sub[*]", true},
+		{"$.key.sub[0:1]", true},
+		{"$.key.sub[?(@ == 'val1')]", false},
+		{"$key", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			if got := root.HasPath(tt.path); got != tt.expected {
+				t.Errorf("HasPath(%q) = %v, want %v", tt.path, got, tt.expected)
+			}
+		})
+	}
+
+	// the paths built by Node.Path must resolve to the same node.
+	for _, node := range []*Node{root.MustKey("key").MustKey("sub").MustIndex(1), root.MustKey("arr").MustIndex(0)} {
+		if resolved, err := root.Collect(node.Path()); err != nil || len(resolved) != 1 || resolved[0] != node {
+			t.Errorf("Collect(%q) = %v, %v", node.Path(), resolved, err)
+		}
+	}
+}
+
+func TestProject(t *testing.T) {
+	source := Must(Unmarshal([]byte(`{"user": {"name": "foo", "tags": ["a", "b"], "address": {"city": "bar"}}, "id": 1}`)))
+
+	tests := []struct {
+		name        string
+		spec        map[string]string
+		nullMissing bool
+		expected    string
+		err         bool
+	}{
+		{
+			name:     "fields",
+			spec:     map[string]string{"name": "$.user.name", "tag": "$.user.tags[1]", "id": "$['id']"},
+			expected: `{"name": "foo", "tag": "b", "id": 1}`,
+		},
+		{
+			name:     "container",
+			spec:     map[string]string{"address": "$.user.address", "root": "$"},
+			expected: `{"address": {"city": "bar"}, "root": {"user": {"name": "foo", "tags": ["a", "b"], "address": {"city": "bar"}}, "id": 1}}`,
+		},
+		{
+			name:     "missing omitted",
+			spec:     map[string]string{"name": "$.user.name", "age": "$.user.age", "tag": "$.user.tags[5]", "sub": "$.id.sub"},
+			expected: `{"name": "foo"}`,
+		},
+		{
+			name:        "missing as null",
+			spec:        map[string]string{"name": "$.user.name", "age": "$.user.age"},
+			nullMissing: true,
+			expected:    `{"name": "foo", "age": null}`,
+		},
+		{
+			name:     "empty spec",
+			spec:     map[string]string{},
+			expected: `{}`,
+		},
+		{
+			name: "invalid path",
+			spec: map[string]string{"name": "user.name"},
+			err:  true,
+		},
+		{
+			name:     "multi-match as array",
+			spec:     map[string]string{"names": "$..name", "tags": "$.user.tags[*]", "first": "$.user.tags[0:1]", "none": "$.user.tags[5:]"},
+			expected: `{"names": ["foo"], "tags": ["a", "b"], "first": ["a"]}`,
+		},
+		{
+			name: "filter path",
+			spec: map[string]string{"name": "$.user[?(@.name == 'foo')]"},
+			err:  true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := ProjectWith(source, tc.spec, tc.nullMissing)
+			if tc.err {
+				if err == nil {
+					t.Errorf("expected error, got %s", got)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			bs, err := Marshal(got)
+			if err != nil {
+				t.Fatalf("Marshal returns error: %v", err)
+			}
+
+			if !equals(Must(Unmarshal(bs)), Must(Unmarshal([]byte(tc.expected)))) {
+				t.Errorf("got %s, want %s", bs, tc.expected)
+			}
+		})
+	}
+
+	dto, err := Project(source, map[string]string{"address": "$.user.address"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := dto.MustKey("address").MustKey("city").SetString("baz"); err != nil {
+		t.Fatalf("SetString returns error: %v", err)
+	}
+
+	if source.MustKey("user").MustKey("address").MustKey("city").MustString() != "bar" {
+		t.Errorf("the source must not be changed")
+	}
+
+	if _, err := Project(nil, nil); err != ErrNilNode {
+		t.Errorf("Project on nil source = %v, want %v", err, ErrNilNode)
+	}
+}
+
+func TestParsePathTyped(t *testing.T) {
+	ptr := func(i int) *int { return &i }
+
+	root := Segment{Kind: SegmentRoot}
+	key := func(k string) Segment { return Segment{Kind: SegmentKey, Key: k} }
+	index := func(i int) Segment { return Segment{Kind: SegmentIndex, Index: i} }
+	wildcard := Segment{Kind: SegmentWildcard}
+	descent := Segment{Kind: SegmentRecursiveDescent}
+
+	tests := []struct {
+		path     string
+		expected []Segment
+	}{
+		{"$", []Segment{root}},
+		{"@.price", []Segment{{Kind: SegmentCurrent}, key("price")}},
+		{"$.store.book[0].title", []Segment{root, key("store"), key("book"), index(0), key("title")}},
+		{`$['store']["book"][-1]`, []Segment{root, key("store"), key("book"), index(-1)}},
+		{`$['a.b']['c\'d'][ 'e]' ]`, []Segment{root, key("a.b"), key("c'd"), key("e]")}},
+		{"$.store.*", []Segment{root, key("store"), wildcard}},
+		{"$.book[*].author", []Segment{root, key("book"), wildcard, key("author")}},
+		{"$..author", []Segment{root, descent, key("author")}},
+		{"$..*", []Segment{root, descent, wildcard}},
+		{"$..book[2]", []Segment{root, descent, key("book"), index(2)}},
+		{"$..[0]", []Segment{root, descent, index(0)}},
+		{"$[1:3]", []Segment{root, {Kind: SegmentSlice, Start: ptr(1), End: ptr(3)}}},
+		{"$[:2]", []Segment{root, {Kind: SegmentSlice, End: ptr(2)}}},
+		{"$[-2:]", []Segment{root, {Kind: SegmentSlice, Start: ptr(-2)}}},
+		{"$[::-1]", []Segment{root, {Kind: SegmentSlice, Step: ptr(-1)}}},
+		{"$[0:10:2]", []Segment{root, {Kind: SegmentSlice, Start: ptr(0), End: ptr(10), Step: ptr(2)}}},
+		{"$[:]", []Segment{root, {Kind: SegmentSlice}}},
+		{"$.book[?(@.price < 10)]", []Segment{root, key("book"), {Kind: SegmentFilter, Filter: "@.price < 10"}}},
+		{"$[?((@.a == ')') && (@.b[0] > 1))]", []Segment{root, {Kind: SegmentFilter, Filter: "(@.a == ')') && (@.b[0] > 1)"}}},
+		{`$[?(@['first-name'] == 'o\'brien')]`, []Segment{root, {Kind: SegmentFilter, Filter: `@['first-name'] == 'o\'brien'`}}},
+		{`$[?(@["a.b"] && @['c)]'])]`, []Segment{root, {Kind: SegmentFilter, Filter: `@["a.b"] && @['c)]']`}}},
+		{"$['a', 'b']", []Segment{root, {Kind: SegmentUnion, Union: []Segment{key("a"), key("b")}}}},
+		{"$[0,-1, 1:2]", []Segment{root, {Kind: SegmentUnion, Union: []Segment{index(0), index(-1), {Kind: SegmentSlice, Start: ptr(1), End: ptr(2)}}}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			got, err := ParsePathTyped(tt.path)
+			if err != nil {
+				t.Fatalf("ParsePathTyped(%q) returns error: %v", tt.path, err)
+			}
+
+			if !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("ParsePathTyped(%q) = %+v, want %+v", tt.path, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestParsePathTyped_Invalid(t *testing.T) {
+	tests := []string{
+		"",
+		"store.book",
+		"$.",
+		"$..",
+		"$...a",
+		"$.*a",
+		"$[",
+		"$[]",
+		"$[0",
+		"$['a'",
+		"$['a]",
+		"$[a]",
+		"$[0 1]",
+		"$[1:2:3:4]",
+		"$[::0]",
+		"$[-]",
+		"$[?@.a]",
+		"$[?(@.a]",
+		"$[?(@.a) ]x",
+		"$[?(@.a)",
+		"$key",
+	}
+
+	for _, path := range tests {
+		t.Run(path, func(t *testing.T) {
+			if got, err := ParsePathTyped(path); err == nil {
+				t.Errorf("ParsePathTyped(%q) = %+v, want error", path, got)
+			}
+		})
+	}
+}
+
+func TestSegmentKind_String(t *testing.T) {
+	kinds := map[SegmentKind]string{
+		SegmentRoot:             "root",
+		SegmentRecursiveDescent: "recursive-descent",
+		SegmentUnion:            "union",
+		SegmentKind(-1):         "unknown",
+	}
+
+	for kind, expected := range kinds {
+		if got := kind.String(); got != expected {
+			t.Errorf("SegmentKind(%d).String() = %q, want %q", int(kind), got, expected)
+		}
+	}
+}
+
+func TestNode_Collect(t *testing.T) {
+	root := Must(Unmarshal([]byte(`{
+		"store": {
+			"book": [
+				{"title": "a", "price": 8.95, "tags": ["x"]},
+				{"title": "b", "price": 12.99},
+				{"title": "c", "price": 8.99, "isbn": "0-553"},
+				{"title": "d", "price": 22.99}
+			],
+			"bicycle": {"color": "red", "price": 19.95}
+		}
+	}`)))
+
+	tests := []struct {
+		path     string
+		expected []string
+	}{
+		{"$", []string{root.String()}},
+		{"$.store.bicycle.color", []string{`"red"`}},
+		{"$['store']['book'][1].title", []string{`"b"`}},
+		{"$.store.book[-1].title", []string{`"d"`}},
+		{"$.store.book[*].title", []string{`"a"`, `"b"`, `"c"`, `"d"`}},
+		{"$.store.bicycle.*", []string{`"red"`, `19.95`}},
+		{"$..price", []string{`8.95`, `12.99`, `8.99`, `22.99`, `19.95`}},
+		{"$..book[2].title", []string{`"c"`}},
+		{"$..book[0,-1].title", []string{`"a"`, `"d"`}},
+		{"$..book[1:3].title", []string{`"b"`, `"c"`}},
+		{"$..book[:2].title", []string{`"a"`, `"b"`}},
+		{"$..book[-2:].title", []string{`"c"`, `"d"`}},
+		{"$..book[::2].title", []string{`"a"`, `"c"`}},
+		{"$..book[::-1].title", []string{`"d"`, `"c"`, `"b"`, `"a"`}},
+		{"$..book[2:0:-1].title", []string{`"c"`, `"b"`}},
+		{"$..book[10:].title", nil},
+		{"$.store['bicycle','missing'].color", []string{`"red"`}},
+		{"$..isbn", []string{`"0-553"`}},
+		{"$..tags.*", []string{`"x"`}},
+		{"$.store.book.title", nil},
+		{"$.store.book[9]", nil},
+		{"$.store.bicycle[0]", nil},
+		{"$..nothing", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			nodes, err := root.Collect(tt.path)
+			if err != nil {
+				t.Fatalf("Collect(%q) returns error: %v", tt.path, err)
+			}
+
+			var got []string
+			for _, node := range nodes {
+				got = append(got, node.String())
+			}
+
+			if !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("Collect(%q) = %v, want %v", tt.path, got, tt.expected)
+			}
+		})
+	}
+
+	nodes, err := root.Collect("$..bicycle")
+	if err != nil || len(nodes) != 1 || nodes[0] != root.MustKey("store").MustKey("bicycle") {
+		t.Errorf("Collect must return the nodes of the tree, got %v, %v", nodes, err)
+	}
+
+	if _, err := root.Collect("$..book[?(@.price < 10)]"); err == nil {
+		t.Errorf("Collect with a filter expression must fail")
+	}
+
+	if _, err := root.Collect("store"); err == nil {
+		t.Errorf("Collect with an invalid path must fail")
+	}
+
+	if _, err := (*Node)(nil).Collect("$"); err != ErrNilNode {
+		t.Errorf("Collect on nil node = %v, want %v", err, ErrNilNode)
+	}
+}
+
+func TestNode_Collect_MixedUnion(t *testing.T) {
+	root := Must(Unmarshal([]byte(`{"items": [{"name": "obj", "0": "zero"}, ["first", "second"], "scalar"]}`)))
+
+	tests := []struct {
+		path     string
+		expected []string
+	}{
+		{"$.items[*]['name',0]", []string{`"obj"`, `"first"`}},
+		{"$.items[*][0,'name']", []string{`"obj"`, `"first"`}},
+		{"$.items[*][1,'name',-2]", []string{`"obj"`, `"second"`, `"first"`}},
+		{"$.items[*]['0',1]", []string{`"zero"`, `"second"`}},
+		{"$.items[0,'name']", []string{`{"name": "obj", "0": "zero"}`}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			nodes, err := root.Collect(tt.path)
+			if err != nil {
+				t.Fatalf("Collect(%q) returns error: %v", tt.path, err)
+			}
+
+			var got []string
+			for _, node := range nodes {
+				got = append(got, node.String())
+			}
+
+			if !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("Collect(%q) = %v, want %v", tt.path, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestNode_Collect_RecursiveSubscript(t *testing.T) {
+	root := Must(Unmarshal([]byte(`{"a": [[1, 2], {"key": 3, "b": [4]}], "key": [5]}`)))
+
+	tests := []struct {
+		path     string
+		expected []string
+	}{
+		{"$..[0]", []string{`$['a'][0]`, `$['a'][0][0]`, `$['a'][1]['b'][0]`, `$['key'][0]`}},
+		{"$..[-1]", []string{`$['a'][1]`, `$['a'][0][1]`, `$['a'][1]['b'][0]`, `$['key'][0]`}},
+		{"$..['key']", []string{`$['key']`, `$['a'][1]['key']`}},
+		{"$..[0:1]", []string{`$['a'][0]`, `$['a'][0][0]`, `$['a'][1]['b'][0]`, `$['key'][0]`}},
+		{"$.a..[1]", []string{`$['a'][1]`, `$['a'][0][1]`}},
+		{
+			"$..[*]",
+			[]string{
+				`$['a']`, `$['key']`,
+				`$['a'][0]`, `$['a'][1]`, `$['a'][0][0]`, `$['a'][0][1]`,
+				`$['a'][1]['key']`, `$['a'][1]['b']`, `$['a'][1]['b'][0]`, `$['key'][0]`,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			nodes, err := root.Collect(tt.path)
+			if err != nil {
+				t.Fatalf("Collect(%q) returns error: %v", tt.path, err)
+			}
+
+			var got []string
+			for _, node := range nodes {
+				got = append(got, node.Path())
+			}
+
+			if !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("Collect(%q) = %v, want %v", tt.path, got, tt.expected)
+			}
+		})
+	}
+
+	wildcard, err := root.Collect("$..*")
+	if err != nil {
+		t.Fatalf("Collect returns error: %v", err)
+	}
+
+	subscript, _ := root.Collect("$..[*]")
+	if !reflect.DeepEqual(wildcard, subscript) {
+		t.Errorf("$..* and $..[*] must select the same nodes")
+	}
+}
+
+func TestQuery(t *testing.T) {
+	data := []byte(`{"store": {"book": [
+		{"title": "a", "price": 8.95},
+		{"title": "b", "price": 12.99, "isbn": 1}
+	], "bicycle": {"price": 19.95}}}`)
+
+	prices, err := Query(data, "$..price")
+	if err != nil {
+		t.Fatalf("Query returns error: %v", err)
+	}
+
+	if prices.Len() != 3 || !reflect.DeepEqual(prices.Numbers(), []float64{8.95, 12.99, 19.95}) {
+		t.Errorf("Numbers() = %v", prices.Numbers())
+	}
+
+	if prices.First().MustNumeric() != 8.95 {
+		t.Errorf("First() = %v, want 8.95", prices.First())
+	}
+
+	mixed, err := Query(data, "$.store.book[*]['title','isbn']")
+	if err != nil {
+		t.Fatalf("Query returns error: %v", err)
+	}
+
+	if !reflect.DeepEqual(mixed.Strings(), []string{"a", "b"}) {
+		t.Errorf("Strings() = %v, want [a b]", mixed.Strings())
+	}
+
+	if !reflect.DeepEqual(mixed.Numbers(), []float64{1}) {
+		t.Errorf("Numbers() = %v, want [1]", mixed.Numbers())
+	}
+
+	array := mixed.Array()
+	if value, err := Marshal(array); err != nil || string(value) != `["a","b",1]` {
+		t.Errorf("Array() = %s, %v", value, err)
+	}
+
+	if mixed[0].prev == array {
+		t.Errorf("Array must not move the nodes out of their tree")
+	}
+
+	empty, err := Query(data, "$.nothing")
+	if err != nil || empty.Len() != 0 || empty.First() != nil || len(empty.Strings()) != 0 || empty.Array().Size() != 0 {
+		t.Errorf("empty result = %v, %v", empty, err)
+	}
+
+	if _, err := Query([]byte(`{`), "$"); err == nil {
+		t.Errorf("Query of invalid data must fail")
+	}
+
+	if _, err := Query(data, "store"); err == nil {
+		t.Errorf("Query with an invalid path must fail")
+	}
+}
+
+func TestNode_FirstPath(t *testing.T) {
+	root := Must(Unmarshal([]byte(`{
+		"v1": {"fullName": "foo", "tags": ["a", "b"]},
+		"v2": {"name": "bar", "tags": ["c"]},
+		"v3": {"displayName": null}
+	}`)))
+
+	tests := []struct {
+		name     string
+		paths    []string
+		expected string
+	}{
+		{"first candidate", []string{"$.v1.fullName", "$.v1.name"}, `"foo"`},
+		{"fallback", []string{"$.v2.fullName", "$.v2.name", "$.v2.displayName"}, `"bar"`},
+		{"null value", []string{"$.v3.fullName", "$.v3.name", "$.v3.displayName"}, `null`},
+		{"several matches skipped", []string{"$.v1.tags[*]", "$.v2.tags[*]"}, `"c"`},
+		{"recursive descent", []string{"$..fullName"}, `"foo"`},
+		{"index", []string{"$.v1.tags[5]", "$.v1.tags[-1]"}, `"b"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			node, err := root.FirstPath(tt.paths...)
+			if err != nil {
+				t.Fatalf("FirstPath returns error: %v", err)
+			}
+
+			if got := node.String(); got != tt.expected {
+				t.Errorf("FirstPath() = %s, want %s", got, tt.expected)
+			}
+		})
+	}
+
+	for _, paths := range [][]string{nil, {"$.v1.nothing", "$..tags"}} {
+		if _, err := root.FirstPath(paths...); !errors.Is(err, ErrKeyNotFound) {
+			t.Errorf("FirstPath(%v) = %v, want ErrKeyNotFound", paths, err)
+		}
+	}
+
+	if _, err := root.FirstPath("$.v1[", "$.v1.fullName"); err == nil || errors.Is(err, ErrKeyNotFound) {
+		t.Errorf("FirstPath with an invalid path = %v, want a syntax error", err)
+	}
+
+	if _, err := (*Node)(nil).FirstPath("$"); err != ErrNilNode {
+		t.Errorf("expected ErrNilNode for nil receiver, got %v", err)
+	}
+}
+
+func TestNode_Redact(t *testing.T) {
+	const input = `{
+		"user": "foo",
+		"password": "p1",
+		"db": {"password": {"hash": "x", "salt": "y"}, "hosts": ["a", "b"]},
+		"tokens": [{"token": "t1"}, {"token": "t2"}]
+	}`
+
+	tests := []struct {
+		name     string
+		paths    []string
+		count    int
+		expected string
+	}{
+		{
+			name:     "recursive",
+			paths:    []string{"$..password"},
+			count:    2,
+			expected: `{"user": "foo", "password": "[REDACTED]", "db": {"password": "[REDACTED]", "hosts": ["a", "b"]}, "tokens": [{"token": "t1"}, {"token": "t2"}]}`,
+		},
+		{
+			name:     "wildcard",
+			paths:    []string{"$.tokens[*].token", "$.db.hosts[0]"},
+			count:    3,
+			expected: `{"user": "foo", "password": "p1", "db": {"password": {"hash": "x", "salt": "y"}, "hosts": ["[REDACTED]", "b"]}, "tokens": [{"token": "[REDACTED]"}, {"token": "[REDACTED]"}]}`,
+		},
+		{
+			name:     "overlapping paths",
+			paths:    []string{"$.password", "$..password", "$.db.password.salt"},
+			count:    2,
+			expected: `{"user": "foo", "password": "[REDACTED]", "db": {"password": "[REDACTED]", "hosts": ["a", "b"]}, "tokens": [{"token": "t1"}, {"token": "t2"}]}`,
+		},
+		{
+			name:     "nested matches",
+			paths:    []string{"$..*"},
+			count:    4,
+			expected: `{"user": "[REDACTED]", "password": "[REDACTED]", "db": "[REDACTED]", "tokens": "[REDACTED]"}`,
+		},
+		{
+			name:     "no match",
+			paths:    []string{"$..secret", "$.db.hosts[5]"},
+			expected: input,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := Must(Unmarshal([]byte(input)))
+
+			count, err := root.Redact(tt.paths...)
+			if err != nil {
+				t.Fatalf("Redact returns error: %v", err)
+			}
+
+			if count != tt.count {
+				t.Errorf("count = %d, want %d", count, tt.count)
+			}
+
+			if expected := Must(Unmarshal([]byte(tt.expected))); !equals(root, expected) {
+				t.Errorf("Redact() = %s, want %s", root, tt.expected)
+			}
+
+			value, err := Marshal(root)
+			if err != nil {
+				t.Fatalf("Marshal returns error: %v", err)
+			}
+
+			if !equals(Must(Unmarshal(value)), root) {
+				t.Errorf("Marshal() = %s, want %s", value, root)
+			}
+		})
+	}
+}
+
+func TestNode_RedactWith(t *testing.T) {
+	root := Must(Unmarshal([]byte(`{"a": {"token": 1}, "b": [{"token": null}]}`)))
+
+	count, err := root.MustKey("b").RedactWith("***", "$..token")
+	if err != nil {
+		t.Fatalf("RedactWith returns error: %v", err)
+	}
+
+	if expected := `{"a":{"token":1},"b":[{"token":"***"}]}`; count != 1 || !equals(root, Must(Unmarshal([]byte(expected)))) {
+		t.Errorf("RedactWith() = %d, %s, want 1, %s", count, root, expected)
+	}
+
+	if _, err := root.Redact("$..token", "$.a["); err == nil {
+		t.Errorf("Redact with an invalid path must fail")
+	}
+
+	if got := root.MustKey("a").MustKey("token").String(); got != "1" {
+		t.Errorf("token = %s, must be unchanged after a failed Redact", got)
+	}
+
+	if _, err := (*Node)(nil).Redact("$"); err != ErrNilNode {
+		t.Errorf("expected ErrNilNode for nil receiver, got %v", err)
+	}
+}
+
+// sliceCases are shared by the tests of Node.Slice and the slice segments of Collect.
+var sliceCases = []struct {
+	expr             string
+	start, end, step *int
+	expected         []int
+}{
+	{":", nil, nil, nil, []int{0, 1, 2, 3, 4}},
+	{"1:3", intPtr(1), intPtr(3), nil, []int{1, 2}},
+	{"-2:", intPtr(-2), nil, nil, []int{3, 4}},
+	{":-2", nil, intPtr(-2), nil, []int{0, 1, 2}},
+	{"::2", nil, nil, intPtr(2), []int{0, 2, 4}},
+	{"::-1", nil, nil, intPtr(-1), []int{4, 3, 2, 1, 0}},
+	{"3:0:-1", intPtr(3), intPtr(0), intPtr(-1), []int{3, 2, 1}},
+	{"-1:-4:-2", intPtr(-1), intPtr(-4), intPtr(-2), []int{4, 2}},
+	{"-10:10", intPtr(-10), intPtr(10), nil, []int{0, 1, 2, 3, 4}},
+	{"10:-10:-1", intPtr(10), intPtr(-10), intPtr(-1), []int{4, 3, 2, 1, 0}},
+	{"3:1", intPtr(3), intPtr(1), nil, nil},
+	{"1:3:-1", intPtr(1), intPtr(3), intPtr(-1), nil},
+	{"5:", intPtr(5), nil, nil, nil},
+	{"::0", nil, nil, intPtr(0), nil},
+}
+
+func intPtr(i int) *int {
+	return &i
+}
+
+func TestSlice_Shared(t *testing.T) {
+	root := Must(Unmarshal([]byte(`[0, 1, 2, 3, 4]`)))
+
+	indices := func(nodes []*Node) []int {
+		var result []int
+		for _, node := range nodes {
+			result = append(result, int(node.MustNumeric()))
+		}
+
+		return result
+	}
+
+	for _, tc := range sliceCases {
+		t.Run(tc.expr, func(t *testing.T) {
+			elems, err := root.Slice(tc.start, tc.end, tc.step)
+			if err != nil {
+				t.Fatalf("Slice returns error: %v", err)
+			}
+
+			if got := indices(elems); !reflect.DeepEqual(got, tc.expected) {
+				t.Errorf("Slice(%s) = %v, want %v", tc.expr, got, tc.expected)
+			}
+
+			if tc.step != nil && *tc.step == 0 {
+				return // a zero step is rejected by the path parser.
+			}
+
+			nodes, err := root.Collect("$[" + tc.expr + "]")
+			if err != nil {
+				t.Fatalf("Collect returns error: %v", err)
+			}
+
+			if got := indices(nodes); !reflect.DeepEqual(got, tc.expected) {
+				t.Errorf("Collect($[%s]) = %v, want %v", tc.expr, got, tc.expected)
+			}
+		})
+	}
+
+	if _, err := Must(Unmarshal([]byte(`{"a": 1}`))).Slice(nil, nil, nil); err == nil {
+		t.Errorf("Slice of an object must fail")
+	}
+}
@@ -0,0 +1,132 @@
+package json
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// HasPointer reports whether the given JSON pointer (RFC 6901) resolves to a node
+// from the current node. A malformed pointer reports false.
+//
+// Usage:
+//
+//	root := Must(Unmarshal([]byte(`{"a": {"b/c": [1, 2]}}`)))
+//	root.HasPointer("/a/b~1c/1") // true
+//	root.HasPointer("/a/x")      // false
+func (n *Node) HasPointer(pointer string) bool {
+	_, err := n.resolvePointer(pointer)
+	return err == nil
+}
+
+// resolvePointer returns the node referenced by the JSON pointer, relative to the current node.
+func (n *Node) resolvePointer(pointer string) (*Node, error) {
+	if n == nil {
+		return nil, ErrNilNode
+	}
+
+	tokens, err := splitPointer(pointer)
+	if err != nil {
+		return nil, err
+	}
+
+	curr := n
+	for _, token := range tokens {
+		switch curr.Type() {
+		case Object:
+			child, ok := curr.next[token]
+			if !ok {
+				return nil, &KeyError{Key: token}
+			}
+
+			curr = child
+
+		case Array:
+			idx, err := pointerIndex(token)
+			if err != nil {
+				return nil, err
+			}
+
+			if curr, err = curr.GetIndex(idx); err != nil {
+				return nil, err
+			}
+
+		default:
+			return nil, fmt.Errorf("can't resolve %q in %s node", token, curr.Type())
+		}
+	}
+
+	return curr, nil
+}
+
+// splitPointer splits the JSON pointer into its unescaped reference tokens.
+// The empty pointer refers to the whole document and has no tokens.
+func splitPointer(pointer string) ([]string, error) {
+	if pointer == "" {
+		return nil, nil
+	}
+
+	if pointer[0] != '/' {
+		return nil, fmt.Errorf("invalid JSON pointer %q: must start with '/'", pointer)
+	}
+
+	tokens := strings.Split(pointer[1:], "/")
+	for i, token := range tokens {
+		unescaped, err := unescapePointerToken(token)
+		if err != nil {
+			return nil, fmt.Errorf("invalid JSON pointer %q: %w", pointer, err)
+		}
+
+		tokens[i] = unescaped
+	}
+
+	return tokens, nil
+}
+
+// unescapePointerToken reverses escapePointerToken. `~` must be followed by `0` or `1`.
+func unescapePointerToken(token string) (string, error) {
+	if !strings.Contains(token, "~") {
+		return token, nil
+	}
+
+	var sb strings.Builder
+	for i := 0; i < len(token); i++ {
+		if token[i] != '~' {
+			sb.WriteByte(token[i])
+			continue
+		}
+
+		if i+1 >= len(token) || (token[i+1] != '0' && token[i+1] != '1') {
+			return "", fmt.Errorf("invalid escape in %q", token)
+		}
+
+		if token[i+1] == '0' {
+			sb.WriteByte('~')
+		} else {
+			sb.WriteByte('/')
+		}
+		i++
+	}
+
+	return sb.String(), nil
+}
+
+// pointerIndex parses an array index token, which is a decimal number without leading zeros.
+func pointerIndex(token string) (int, error) {
+	if token == "" || (len(token) > 1 && token[0] == '0') {
+		return 0, fmt.Errorf("invalid array index %q", token)
+	}
+
+	for i := 0; i < len(token); i++ {
+		if notDigit(token[i]) {
+			return 0, fmt.Errorf("invalid array index %q", token)
+		}
+	}
+
+	idx, err := strconv.Atoi(token)
+	if err != nil {
+		return 0, fmt.Errorf("invalid array index %q: %w", token, ErrIndexOutOfRange)
+	}
+
+	return idx, nil
+}
@@ -0,0 +1,80 @@
+package json
+
+import "testing"
+
+func TestNode_HasPointer(t *testing.T) {
+	// the example document of RFC 6901.
+	root := Must(Unmarshal([]byte(`{
+		"foo": ["bar", "baz"],
+		"": 0,
+		"a/b": 1,
+		"c%d": 2,
+		"e^f": 3,
+		"g|h": 4,
+		"i\\j": 5,
+		"k\"l": 6,
+		" ": 7,
+		"m~n": 8,
+		"nested": {"arr": [{"x": null}]}
+	}`)))
+
+	tests := []struct {
+		pointer  string
+		expected bool
+	}{
+		{"", true},
+		{"/foo", true},
+		{"/foo/0", true},
+		{"/foo/1", true},
+		{"/", true},
+		{"/a~1b", true},
+		{"/c%d", true},
+		{"/e^f", true},
+		{"/g|h", true},
+		{`/i\j`, true},
+		{`/k"l`, true},
+		{"/ ", true},
+		{"/m~0n", true},
+		{"/nested/arr/0/x", true},
+		{"/foo/2", false},
+		{"/foo/-", false},
+		{"/foo/01", false},
+		{"/foo/-1", false},
+		{"/foo/bar", false},
+		{"/a/b", false},
+		{"/m~n", false},
+		{"/m~2n", false},
+		{"/nested/arr/0/x/y", false},
+		{"foo", false},
+		{"/missing", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.pointer, func(t *testing.T) {
+			if got := root.HasPointer(tt.pointer); got != tt.expected {
+				t.Errorf("HasPointer(%q) = %v, want %v", tt.pointer, got, tt.expected)
+			}
+		})
+	}
+
+	if (*Node)(nil).HasPointer("") {
+		t.Errorf("nil node must not resolve any pointer")
+	}
+}
+
+func TestNode_resolvePointer(t *testing.T) {
+	root := Must(Unmarshal([]byte(`{"a": [{"b~/c": "found"}]}`)))
+
+	node, err := root.resolvePointer("/a/0/b~0~1c")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if node.MustString() != "found" {
+		t.Errorf("resolved wrong node: %s", node)
+	}
+
+	if node, err := root.resolvePointer(""); err != nil || node != root {
+		t.Errorf("empty pointer must resolve to the node itself")
+	}
+}
@@ -0,0 +1,293 @@
+package json
+
+import (
+	"bytes"
+	"io"
+)
+
+// TokenType is the type of a token read by Scanner.
+type TokenType int
+
+const (
+	TokenObjectStart TokenType = iota // {
+	TokenObjectEnd                    // }
+	TokenArrayStart                   // [
+	TokenArrayEnd                     // ]
+	TokenKey                          // object member key
+	TokenString                       // string value
+	TokenNumber                       // number value
+	TokenBool                         // true or false
+	TokenNull                         // null
+)
+
+func (t TokenType) String() string {
+	switch t {
+	case TokenObjectStart:
+		return "object-start"
+	case TokenObjectEnd:
+		return "object-end"
+	case TokenArrayStart:
+		return "array-start"
+	case TokenArrayEnd:
+		return "array-end"
+	case TokenKey:
+		return "key"
+	case TokenString:
+		return "string"
+	case TokenNumber:
+		return "number"
+	case TokenBool:
+		return "bool"
+	case TokenNull:
+		return "null"
+	default:
+		return "unknown"
+	}
+}
+
+// Token is a single token read by Scanner.
+//
+// Value holds the unquoted content for TokenKey and TokenString, and the raw literal
+// for the other types (e.g. `1.5e3`, `true`, `{`). It may refer to the scanned data,
+// so it must be copied if it's modified.
+type Token struct {
+	Type  TokenType
+	Value []byte
+}
+
+// Scanner reads the tokens of a JSON value one by one.
+//
+// The tokens follow the grammar below, where a key is always followed by its value:
+//
+//	value  = object | array | TokenString | TokenNumber | TokenBool | TokenNull
+//	object = TokenObjectStart { TokenKey value } TokenObjectEnd
+//	array  = TokenArrayStart { value } TokenArrayEnd
+//
+// The input must hold exactly one JSON value. Commas and colons are validated
+// but not reported as tokens. As in Unmarshal, a leading UTF-8 byte order mark is ignored.
+type Scanner struct {
+	buf        *buffer
+	stack      []ValueType // stack holds the types of the open containers.
+	keyPending bool        // keyPending indicates a key is read but its value is not yet.
+	offset     int         // offset is the index of the first byte of the last token.
+	started    bool
+	err        error
+}
+
+// NewScanner returns a new scanner that reads the tokens of the given data.
+func NewScanner(data []byte) *Scanner {
+	buf := newBuffer(data)
+	if bytes.HasPrefix(data, byteOrderMark) {
+		buf.index = len(byteOrderMark)
+	}
+
+	return &Scanner{buf: buf}
+}
+
+// Next returns the next token.
+//
+// After the last token of the value, it returns io.EOF. If the data holds no value at all,
+// it returns ErrEmptyDocument, and if it ends before the value is complete, io.ErrUnexpectedEOF.
+// For malformed data, it returns a *SyntaxError at the first invalid byte, with the same
+// message as Unmarshal (e.g. "invalid surrogate pair" for a lone surrogate escape).
+// Once an error is returned, all subsequent calls return the same error.
+//
+// Usage:
+//
+//	s := json.NewScanner([]byte(`{"key": [1, true]}`))
+//	for {
+//		tok, err := s.Next()
+//		if err == io.EOF {
+//			break
+//		} else if err != nil {
+//			return err
+//		}
+//
+//		println(tok.Type.String(), string(tok.Value))
+//	}
+//
+//	result: object-start {, key key, array-start [, number 1, bool true, array-end ], object-end }
+func (s *Scanner) Next() (Token, error) {
+	if s.err != nil {
+		return Token{}, s.err
+	}
+
+	tok, err := s.next()
+	if err != nil {
+		s.err = err
+	}
+
+	return tok, err
+}
+
+func (s *Scanner) next() (Token, error) {
+	buf := s.buf
+
+	for {
+		if !s.advance() {
+			if len(s.stack) == 0 && buf.state == OK {
+				return Token{}, io.EOF
+			}
+
+			if len(s.stack) == 0 && buf.state == GO {
+				return Token{}, ErrEmptyDocument
+			}
+
+			return Token{}, io.ErrUnexpectedEOF
+		}
+
+		s.offset = buf.index
+		state := buf.getState()
+		if state == __ {
+			return Token{}, unexpectedTokenError(buf.data, buf.index)
+		}
+
+		if state >= GO {
+			return s.value()
+		}
+
+		switch state {
+		case ec, cc: // <empty> }
+			if s.keyPending || s.top() != Object {
+				return Token{}, unexpectedTokenError(buf.data, buf.index)
+			}
+
+			return s.pop(TokenObjectEnd), nil
+
+		case bc: // ]
+			if s.top() != Array {
+				return Token{}, unexpectedTokenError(buf.data, buf.index)
+			}
+
+			return s.pop(TokenArrayEnd), nil
+
+		case co, bo: // { [
+			if len(s.stack) >= maxNestingDepth {
+				return Token{}, ErrMaxNesting
+			}
+
+			s.keyPending = false
+			if state == co {
+				s.stack = append(s.stack, Object)
+				buf.state = OB
+				return Token{Type: TokenObjectStart, Value: buf.data[buf.index : buf.index+1]}, nil
+			}
+
+			s.stack = append(s.stack, Array)
+			buf.state = AR
+			return Token{Type: TokenArrayStart, Value: buf.data[buf.index : buf.index+1]}, nil
+
+		case cm: // ,
+			switch s.top() {
+			case Object:
+				buf.state = KE
+			case Array:
+				buf.state = VA
+			default:
+				return Token{}, unexpectedTokenError(buf.data, buf.index)
+			}
+
+		case cl: // :
+			if s.top() != Object || !s.keyPending {
+				return Token{}, unexpectedTokenError(buf.data, buf.index)
+			}
+
+			buf.state = VA
+
+		default:
+			return Token{}, unexpectedTokenError(buf.data, buf.index)
+		}
+	}
+}
+
+// advance moves the buffer to the next significant byte after the previous token.
+// It returns false if the end of data is reached.
+func (s *Scanner) advance() bool {
+	if !s.started {
+		s.started = true
+	} else if s.buf.step() != nil {
+		return false
+	}
+
+	_, err := s.buf.first()
+	return err == nil
+}
+
+// value reads the scalar value (or key) starting at the current byte.
+func (s *Scanner) value() (Token, error) {
+	buf := s.buf
+	start := buf.index
+
+	switch buf.state {
+	case ST: // string
+		if err := buf.string(doubleQuote, false); err != nil {
+			return Token{}, stringSyntaxError(buf)
+		}
+
+		value, err := unquoteBytesAt(buf.data[start:buf.index+1], start, false)
+		if err != nil {
+			return Token{}, err
+		}
+
+		if s.top() == Object && !s.keyPending {
+			s.keyPending = true
+			buf.state = CO
+			return Token{Type: TokenKey, Value: value}, nil
+		}
+
+		s.keyPending = false
+		buf.state = OK
+		return Token{Type: TokenString, Value: value}, nil
+
+	case MI, ZE, IN: // number
+		if err := buf.numeric(false); err != nil {
+			return Token{}, newSyntaxError(buf.index, "invalid number literal")
+		}
+
+		literal := buf.data[start:buf.index]
+		buf.index -= 1
+		return s.scalar(TokenNumber, literal), nil
+
+	case T1, F1: // boolean
+		literal := falseLiteral
+		if buf.state == T1 {
+			literal = trueLiteral
+		}
+
+		if err := buf.word(literal); err != nil {
+			return Token{}, newSyntaxError(buf.index, "invalid literal")
+		}
+
+		return s.scalar(TokenBool, buf.data[start:buf.index+1]), nil
+
+	case N1: // null
+		if err := buf.word(nullLiteral); err != nil {
+			return Token{}, newSyntaxError(buf.index, "invalid literal")
+		}
+
+		return s.scalar(TokenNull, buf.data[start:buf.index+1]), nil
+
+	default:
+		return Token{}, unexpectedTokenError(buf.data, buf.index)
+	}
+}
+
+func (s *Scanner) scalar(typ TokenType, literal []byte) Token {
+	s.keyPending = false
+	s.buf.state = OK
+	return Token{Type: typ, Value: literal}
+}
+
+func (s *Scanner) pop(typ TokenType) Token {
+	s.stack = s.stack[:len(s.stack)-1]
+	s.buf.state = OK
+	return Token{Type: typ, Value: s.buf.data[s.buf.index : s.buf.index+1]}
+}
+
+func (s *Scanner) top() ValueType {
+	if len(s.stack) == 0 {
+		return NotExist
+	}
+
+	return s.stack[len(s.stack)-1]
+}
@@ -0,0 +1,123 @@
+package json
+
+import (
+	"io"
+	"strings"
+	"testing"
+)
+
+func scanAll(data string) ([]string, error) {
+	s := NewScanner([]byte(data))
+
+	var tokens []string
+	for {
+		tok, err := s.Next()
+		if err != nil {
+			return tokens, err
+		}
+
+		tokens = append(tokens, tok.Type.String()+":"+string(tok.Value))
+	}
+}
+
+func TestScanner_Next(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{name: "string", input: `"foo"`, expected: `string:foo`},
+		{name: "number", input: ` 12.5e-1 `, expected: `number:12.5e-1`},
+		{name: "literals", input: `[true,false,null]`, expected: `array-start:[ bool:true bool:false null:null array-end:]`},
+		{name: "object", input: `{"a": 1, "b\n": "é"}`, expected: "object-start:{ key:a number:1 key:b\n string:é object-end:}"},
+		{name: "byte order mark", input: "\xef\xbb\xbf {\"a\": 1}", expected: `object-start:{ key:a number:1 object-end:}`},
+		{name: "nested", input: `[{"a": []}, {}]`, expected: `array-start:[ object-start:{ key:a array-start:[ array-end:] object-end:} object-start:{ object-end:} array-end:]`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tokens, err := scanAll(tt.input)
+			if err != io.EOF {
+				t.Fatalf("expected io.EOF, got %v", err)
+			}
+
+			if got := strings.Join(tokens, " "); got != tt.expected {
+				t.Errorf("tokens = %q, want %q", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestScanner_Errors(t *testing.T) {
+	tests := []struct {
+		name   string
+		input  string
+		tokens int
+		err    error
+	}{
+		{name: "empty", input: ``, err: ErrEmptyDocument},
+		{name: "whitespace only", input: `   `, err: ErrEmptyDocument},
+		{name: "byte order mark only", input: "\xef\xbb\xbf\n", err: ErrEmptyDocument},
+		{name: "unclosed array", input: `[1, 2`, tokens: 3, err: io.ErrUnexpectedEOF},
+		{name: "missing value", input: `{"a":`, tokens: 2, err: io.ErrUnexpectedEOF},
+		{name: "trailing comma", input: `[1,]`, tokens: 2, err: ErrSyntax},
+		{name: "mismatched bracket", input: `[1}`, tokens: 2, err: ErrSyntax},
+		{name: "trailing value", input: `1 2`, tokens: 1, err: ErrSyntax},
+		{name: "invalid literal", input: `[nul]`, tokens: 1, err: ErrSyntax},
+		{name: "unterminated string", input: `["abc`, tokens: 1, err: ErrSyntax},
+		{name: "byte order mark inside", input: "[\xef\xbb\xbf1]", tokens: 1, err: ErrSyntax},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewScanner([]byte(tt.input))
+
+			for i := 0; i < tt.tokens; i++ {
+				if _, err := s.Next(); err != nil {
+					t.Fatalf("unexpected error at token %d: %v", i, err)
+				}
+			}
+
+			_, err := s.Next()
+			if tt.err == ErrSyntax {
+				if _, ok := err.(*SyntaxError); !ok {
+					t.Fatalf("expected *SyntaxError, got %v", err)
+				}
+			} else if err != tt.err {
+				t.Fatalf("expected %v, got %v", tt.err, err)
+			}
+
+			if _, again := s.Next(); again != err {
+				t.Errorf("error must be sticky: %v, then %v", err, again)
+			}
+		})
+	}
+}
+
+func TestScanner_ErrorsLikeUnmarshal(t *testing.T) {
+	tests := []struct {
+		input  string
+		offset int
+		msg    string
+	}{
+		{`["a", "\ud800"]`, 7, "invalid surrogate pair"},
+		{`{"\udc00": 1}`, 2, "invalid surrogate pair"},
+		{"[\"a\x01\"]", 3, "invalid control character"},
+		{`[1, }`, 4, "unexpected token"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			_, err := scanAll(tt.input)
+
+			serr, ok := err.(*SyntaxError)
+			if !ok {
+				t.Fatalf("expected *SyntaxError, got %v", err)
+			}
+
+			if serr.Offset != tt.offset || !strings.Contains(err.Error(), tt.msg) {
+				t.Errorf("error = %v (offset %d), want %q at %d", err, serr.Offset, tt.msg, tt.offset)
+			}
+		})
+	}
+}
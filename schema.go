@@ -0,0 +1,326 @@
+package json
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// ValidationError describes a single violation of a JSON Schema.
+type ValidationError struct {
+	Pointer string // Pointer is the JSON pointer (RFC 6901) of the invalid value. "" is the root.
+	Message string // Message describes the violation.
+}
+
+func (e ValidationError) Error() string {
+	return fmt.Sprintf("%s: %s", e.displayPointer(), e.Message)
+}
+
+func (e ValidationError) displayPointer() string {
+	if e.Pointer == "" {
+		return "/"
+	}
+
+	return e.Pointer
+}
+
+// Validate validates the node against the given JSON Schema and returns all violations.
+//
+// Only a core subset of JSON Schema is supported, other keywords are ignored:
+//
+//   - type: a type name or an array of them ("object", "array", "string",
+//     "number", "integer", "boolean", "null")
+//   - required, properties: object members
+//   - items: a schema applied to every array element
+//   - enum: an array of allowed values, compared structurally
+//   - minimum, maximum: inclusive number bounds
+//   - minLength, maxLength: string length in characters
+//   - pattern: a regular expression the string must match (unanchored)
+//
+// The returned error is not nil only if the schema itself is invalid,
+// or ErrNilNode if the node or the schema is nil.
+//
+// Usage:
+//
+//	schema := Must(Unmarshal([]byte(`{"type": "object", "required": ["name"]}`)))
+//	errs, err := Validate(Must(Unmarshal([]byte(`{}`))), schema)
+//	if err != nil {
+//		t.Errorf("invalid schema: %v", err)
+//	}
+//
+//	result: [{Pointer: "", Message: "missing required property \"name\""}]
+func Validate(node *Node, schema *Node) ([]ValidationError, error) {
+	v := &validator{patterns: make(map[string]*regexp.Regexp)}
+	if err := v.validate(node, schema, ""); err != nil {
+		return nil, err
+	}
+
+	return v.errs, nil
+}
+
+// validator collects the violations while walking the node and the schema together.
+type validator struct {
+	errs     []ValidationError
+	patterns map[string]*regexp.Regexp // patterns caches the compiled `pattern` keywords.
+}
+
+func (v *validator) fail(pointer, format string, args ...interface{}) {
+	v.errs = append(v.errs, ValidationError{Pointer: pointer, Message: fmt.Sprintf(format, args...)})
+}
+
+func (v *validator) validate(node, schema *Node, pointer string) error {
+	if node == nil || schema == nil {
+		return ErrNilNode
+	}
+
+	if !schema.IsObject() {
+		return fmt.Errorf("schema at %s must be an object", pointer)
+	}
+
+	if typ, ok := schema.next["type"]; ok {
+		matched, err := matchSchemaType(node, typ)
+		if err != nil {
+			return err
+		}
+
+		if !matched {
+			// the other keywords are meaningless for a value of the wrong type.
+			v.fail(pointer, "expected type %s, got %s", typ.String(), schemaTypeName(node))
+			return nil
+		}
+	}
+
+	if enum, ok := schema.next["enum"]; ok {
+		if err := v.validateEnum(node, enum, pointer); err != nil {
+			return err
+		}
+	}
+
+	switch node.Type() {
+	case Object:
+		return v.validateObject(node, schema, pointer)
+	case Array:
+		return v.validateArray(node, schema, pointer)
+	case String:
+		return v.validateString(node, schema, pointer)
+	case Number, Float:
+		return v.validateNumber(node, schema, pointer)
+	}
+
+	return nil
+}
+
+func (v *validator) validateEnum(node, enum *Node, pointer string) error {
+	values, err := enum.GetArray()
+	if err != nil {
+		return fmt.Errorf("enum must be an array: %w", err)
+	}
+
+	for _, value := range values {
+		if equals(node, value) {
+			return nil
+		}
+	}
+
+	v.fail(pointer, "value %s is not one of %s", node.String(), enum.String())
+	return nil
+}
+
+func (v *validator) validateObject(node, schema *Node, pointer string) error {
+	if required, ok := schema.next["required"]; ok {
+		keys, err := required.GetStringArray()
+		if err != nil {
+			return fmt.Errorf("required must be an array of strings: %w", err)
+		}
+
+		for _, key := range keys {
+			if !node.HasKey(key) {
+				v.fail(pointer, "missing required property %q", key)
+			}
+		}
+	}
+
+	properties, ok := schema.next["properties"]
+	if !ok {
+		return nil
+	}
+
+	if !properties.IsObject() {
+		return fmt.Errorf("properties must be an object")
+	}
+
+	for _, entry := range properties.Entries() {
+		member, ok := node.next[entry.Key]
+		if !ok {
+			continue
+		}
+
+		if err := v.validate(member, entry.Value, pointer+"/"+escapePointerToken(entry.Key)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (v *validator) validateArray(node, schema *Node, pointer string) error {
+	items, ok := schema.next["items"]
+	if !ok {
+		return nil
+	}
+
+	for i, elem := range node.MustArray() {
+		if err := v.validate(elem, items, pointer+"/"+strconv.Itoa(i)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (v *validator) validateString(node, schema *Node, pointer string) error {
+	str, err := node.GetString()
+	if err != nil {
+		return err
+	}
+
+	length := float64(utf8.RuneCountInString(str))
+
+	if limit, ok, err := schemaNumber(schema, "minLength"); err != nil {
+		return err
+	} else if ok && length < limit {
+		v.fail(pointer, "string length %d is less than minLength %g", int(length), limit)
+	}
+
+	if limit, ok, err := schemaNumber(schema, "maxLength"); err != nil {
+		return err
+	} else if ok && length > limit {
+		v.fail(pointer, "string length %d is greater than maxLength %g", int(length), limit)
+	}
+
+	pattern, ok := schema.next["pattern"]
+	if !ok {
+		return nil
+	}
+
+	expr, err := pattern.GetString()
+	if err != nil {
+		return fmt.Errorf("pattern must be a string: %w", err)
+	}
+
+	re, ok := v.patterns[expr]
+	if !ok {
+		if re, err = regexp.Compile(expr); err != nil {
+			return fmt.Errorf("invalid pattern %q: %w", expr, err)
+		}
+
+		v.patterns[expr] = re
+	}
+
+	if !re.MatchString(str) {
+		v.fail(pointer, "string %q does not match pattern %q", str, expr)
+	}
+
+	return nil
+}
+
+func (v *validator) validateNumber(node, schema *Node, pointer string) error {
+	num, err := node.GetNumeric()
+	if err != nil {
+		return err
+	}
+
+	if limit, ok, err := schemaNumber(schema, "minimum"); err != nil {
+		return err
+	} else if ok && num < limit {
+		v.fail(pointer, "value %g is less than minimum %g", num, limit)
+	}
+
+	if limit, ok, err := schemaNumber(schema, "maximum"); err != nil {
+		return err
+	} else if ok && num > limit {
+		v.fail(pointer, "value %g is greater than maximum %g", num, limit)
+	}
+
+	return nil
+}
+
+// schemaNumber returns the numeric keyword of the schema, if present.
+func schemaNumber(schema *Node, keyword string) (float64, bool, error) {
+	value, ok := schema.next[keyword]
+	if !ok {
+		return 0, false, nil
+	}
+
+	num, err := value.GetNumeric()
+	if err != nil {
+		return 0, false, fmt.Errorf("%s must be a number: %w", keyword, err)
+	}
+
+	return num, true, nil
+}
+
+// matchSchemaType reports whether the node matches the `type` keyword,
+// which is a type name or an array of type names.
+func matchSchemaType(node, typ *Node) (bool, error) {
+	if typ.IsString() {
+		return matchSchemaTypeName(node, typ.MustString())
+	}
+
+	names, err := typ.GetStringArray()
+	if err != nil {
+		return false, fmt.Errorf("type must be a string or an array of strings: %w", err)
+	}
+
+	for _, name := range names {
+		matched, err := matchSchemaTypeName(node, name)
+		if err != nil || matched {
+			return matched, err
+		}
+	}
+
+	return false, nil
+}
+
+func matchSchemaTypeName(node *Node, name string) (bool, error) {
+	switch name {
+	case "object":
+		return node.IsObject(), nil
+	case "array":
+		return node.IsArray(), nil
+	case "string":
+		return node.IsString(), nil
+	case "number":
+		return node.IsNumber(), nil
+	case "integer":
+		if !node.IsNumber() {
+			return false, nil
+		}
+
+		num, err := node.GetNumeric()
+		return err == nil && num == math.Trunc(num), nil
+	case "boolean":
+		return node.IsBool(), nil
+	case "null":
+		return node.IsNull(), nil
+	default:
+		return false, fmt.Errorf("unknown type %q", name)
+	}
+}
+
+// schemaTypeName returns the JSON Schema type name of the node.
+func schemaTypeName(node *Node) string {
+	if node.IsBool() {
+		return "boolean"
+	}
+
+	return node.Type().String()
+}
+
+// escapePointerToken escapes a reference token of a JSON pointer as defined in RFC 6901.
+func escapePointerToken(token string) string {
+	return strings.NewReplacer("~", "~0", "/", "~1").Replace(token)
+}
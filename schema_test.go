@@ -0,0 +1,104 @@
+package json
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	schema := `{
+		"type": "object",
+		"required": ["name", "age"],
+		"properties": {
+			"name": {"type": "string", "minLength": 2, "maxLength": 5, "pattern": "^[A-Z]"},
+			"age": {"type": "integer", "minimum": 0, "maximum": 150},
+			"role": {"enum": ["admin", "user", null]},
+			"tags": {"type": "array", "items": {"type": ["string", "null"]}},
+			"a/b~c": {"type": "boolean"}
+		}
+	}`
+
+	tests := []struct {
+		name     string
+		input    string
+		expected []string
+	}{
+		{name: "valid", input: `{"name": "Bob", "age": 30, "role": "user", "tags": ["x", null], "a/b~c": true}`},
+		{name: "valid null enum", input: `{"name": "Bob", "age": 30, "role": null}`},
+		{name: "wrong root type", input: `[]`, expected: []string{`/: expected type "object", got array`}},
+		{name: "missing required", input: `{"name": "Bob"}`, expected: []string{`/: missing required property "age"`}},
+		{
+			name:  "all violations",
+			input: `{"name": "bobby-x", "age": -1.5, "role": "root", "tags": ["x", 1, "y", true], "a/b~c": 0}`,
+			expected: []string{
+				`/a~1b~0c: expected type "boolean", got number`,
+				`/age: expected type "integer", got number`,
+				`/name: string length 7 is greater than maxLength 5`,
+				`/name: string "bobby-x" does not match pattern "^[A-Z]"`,
+				`/role: value "root" is not one of ["admin", "user", null]`,
+				`/tags/1: expected type ["string", "null"], got number`,
+				`/tags/3: expected type ["string", "null"], got boolean`,
+			},
+		},
+		{name: "bounds", input: `{"name": "B", "age": 151}`, expected: []string{
+			`/age: value 151 is greater than maximum 150`,
+			`/name: string length 1 is less than minLength 2`,
+		}},
+	}
+
+	s := Must(Unmarshal([]byte(schema)))
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			errs, err := Validate(Must(Unmarshal([]byte(tt.input))), s)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			got := make([]string, len(errs))
+			for i, e := range errs {
+				got[i] = e.Error()
+			}
+
+			if strings.Join(got, "\n") != strings.Join(tt.expected, "\n") {
+				t.Errorf("violations:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(tt.expected, "\n"))
+			}
+		})
+	}
+}
+
+func TestValidate_InvalidSchema(t *testing.T) {
+	tests := []string{
+		`[]`,
+		`{"type": "date"}`,
+		`{"type": 1}`,
+		`{"required": "name"}`,
+		`{"properties": []}`,
+		`{"enum": "a"}`,
+		`{"properties": {"a": {"minLength": "1"}}}`,
+		`{"properties": {"a": {"pattern": "("}}}`,
+		`{"properties": {"a": true}}`,
+	}
+
+	node := Must(Unmarshal([]byte(`{"a": "x"}`)))
+	for _, schema := range tests {
+		t.Run(schema, func(t *testing.T) {
+			if _, err := Validate(node, Must(Unmarshal([]byte(schema)))); err == nil {
+				t.Errorf("expected error for schema %s", schema)
+			}
+		})
+	}
+}
+
+func TestValidate_NilNode(t *testing.T) {
+	node := Must(Unmarshal([]byte(`{"a": "x"}`)))
+	schema := Must(Unmarshal([]byte(`{"type": "object"}`)))
+
+	if _, err := Validate(node, nil); err != ErrNilNode {
+		t.Errorf("expected ErrNilNode for nil schema, got %v", err)
+	}
+
+	if _, err := Validate(nil, schema); err != ErrNilNode {
+		t.Errorf("expected ErrNilNode for nil node, got %v", err)
+	}
+}
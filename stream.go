@@ -0,0 +1,572 @@
+package json
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// UnmarshalArrayStream parses the JSON-encoded array and calls fn with each top-level
+// element as soon as it is read, instead of building the whole array.
+//
+// Each element is decoded into its own tree, which the callback can process and
+// discard before the next element is parsed, so only one element is held in memory
+// at a time. It returns an error if the root is not an array or the data is malformed,
+// and stops at the first error returned by fn and returns it.
+//
+// Usage:
+//
+//	err := json.UnmarshalArrayStream(data, func(index int, elem *json.Node) error {
+//		println(index, elem.MustKey("id").MustString())
+//		return nil
+//	})
+func UnmarshalArrayStream(data []byte, fn func(index int, elem *Node) error) error {
+	s := NewScanner(data)
+
+	tok, err := s.Next()
+	if err != nil {
+		return err
+	}
+
+	if tok.Type != TokenArrayStart {
+		return fmt.Errorf("can't stream non-array root. got=%s", tok.Type)
+	}
+
+	for index := 0; ; index++ {
+		tok, start, end, err := s.nextValue()
+		if err != nil {
+			return err
+		}
+
+		if tok.Type == TokenArrayEnd {
+			return s.end()
+		}
+
+		elem, err := Unmarshal(data[start:end])
+		if err != nil {
+			return err
+		}
+
+		if err := fn(index, elem); err != nil {
+			return err
+		}
+	}
+}
+
+// UnmarshalObjectStream parses the JSON-encoded object and calls fn with the unquoted key
+// and the value of each top-level member as soon as it is read, instead of building the whole object.
+//
+// Like UnmarshalArrayStream, each value is decoded into its own tree, so only one member
+// is held in memory at a time. It returns an error if the root is not an object or the
+// data is malformed, and stops at the first error returned by fn and returns it.
+// Duplicate keys are passed to fn as they appear.
+//
+// Usage:
+//
+//	err := json.UnmarshalObjectStream(data, func(key string, value *json.Node) error {
+//		println(key, value.Size())
+//		return nil
+//	})
+func UnmarshalObjectStream(data []byte, fn func(key string, value *Node) error) error {
+	s := NewScanner(data)
+
+	tok, err := s.Next()
+	if err != nil {
+		return err
+	}
+
+	if tok.Type != TokenObjectStart {
+		return fmt.Errorf("can't stream non-object root. got=%s", tok.Type)
+	}
+
+	for {
+		tok, err := s.Next()
+		if err != nil {
+			return err
+		}
+
+		if tok.Type == TokenObjectEnd {
+			return s.end()
+		}
+
+		key := string(tok.Value)
+
+		_, start, end, err := s.nextValue()
+		if err != nil {
+			return err
+		}
+
+		value, err := Unmarshal(data[start:end])
+		if err != nil {
+			return err
+		}
+
+		value.key = &key
+		if err := fn(key, value); err != nil {
+			return err
+		}
+	}
+}
+
+// nextValue reads all tokens of the next value and returns its first token and
+// its byte range in the data. For a closing bracket, only the token is read.
+func (s *Scanner) nextValue() (Token, int, int, error) {
+	first, err := s.Next()
+	if err != nil {
+		return first, 0, 0, err
+	}
+
+	start := s.offset
+	for depth := openDepth(first.Type); depth > 0; {
+		tok, err := s.Next()
+		if err != nil {
+			return first, 0, 0, err
+		}
+
+		switch tok.Type {
+		case TokenObjectStart, TokenArrayStart:
+			depth++
+		case TokenObjectEnd, TokenArrayEnd:
+			depth--
+		}
+	}
+
+	return first, start, s.buf.index + 1, nil
+}
+
+// end checks that there's no data after the root value.
+func (s *Scanner) end() error {
+	_, err := s.Next()
+	switch err {
+	case io.EOF:
+		return nil
+	case nil:
+		return newSyntaxError(s.offset, "unexpected data after the root value")
+	default:
+		return err
+	}
+}
+
+func openDepth(t TokenType) int {
+	if t == TokenObjectStart || t == TokenArrayStart {
+		return 1
+	}
+
+	return 0
+}
+
+// decodeState is the position of a Decoder in the grammar, deciding which token may come next.
+type decodeState int
+
+const (
+	decodeTopValue    decodeState = iota // a top-level value, or the end of the stream
+	decodeArrayStart                     // a value or `]`, after `[`
+	decodeArrayValue                     // `,` or `]`, after an element
+	decodeArrayComma                     // a value, after `,`
+	decodeObjectStart                    // a key or `}`, after `{`
+	decodeObjectKey                      // `:`, after a key
+	decodeObjectColon                    // a value, after `:`
+	decodeObjectValue                    // `,` or `}`, after a member value
+	decodeObjectComma                    // a key, after `,`
+)
+
+// Decoder reads JSON values from an input stream, like the Decoder of the standard library.
+//
+// The stream may hold several top-level values separated by whitespace. Decode reads
+// a whole value, while Token reads the next token, so that the two can be mixed to
+// walk into a large array and decode its elements one at a time. Only the value being
+// decoded is held in memory.
+//
+// Usage:
+//
+//	dec := json.NewDecoder(r) // [{"id": 1}, {"id": 2}]
+//	if _, err := dec.Token(); err != nil { // [
+//		return err
+//	}
+//
+//	for dec.More() {
+//		var node *json.Node
+//		if err := dec.Decode(&node); err != nil {
+//			return err
+//		}
+//
+//		println(node.MustKey("id").MustNumeric())
+//	}
+//
+//	if _, err := dec.Token(); err != nil { // ]
+//		return err
+//	}
+type Decoder struct {
+	r      *bufio.Reader
+	stack  []byte // stack holds the open brackets, `[` or `{`.
+	state  decodeState
+	offset int // offset is the number of bytes read from the stream.
+	err    error
+}
+
+// NewDecoder returns a new decoder that reads from r.
+func NewDecoder(r io.Reader) *Decoder {
+	return &Decoder{r: bufio.NewReader(r)}
+}
+
+// Decode reads the next JSON value from the stream and stores its root node in v.
+//
+// The value may be a top-level value, an element of an array or the value of an object
+// member opened by Token. At the end of the stream, it returns io.EOF.
+func (d *Decoder) Decode(v **Node) error {
+	if d.err != nil {
+		return d.err
+	}
+
+	if err := d.decode(v); err != nil {
+		d.err = err
+		return err
+	}
+
+	return nil
+}
+
+func (d *Decoder) decode(v **Node) error {
+	c, err := d.peek()
+	if err != nil {
+		return d.eof(err)
+	}
+
+	// consume the separator in front of the value, like Token does.
+	if d.state == decodeArrayValue && c == comma || d.state == decodeObjectKey && c == colon {
+		d.skip()
+		if d.state == decodeArrayValue {
+			d.state = decodeArrayComma
+		} else {
+			d.state = decodeObjectColon
+		}
+
+		if c, err = d.peek(); err != nil {
+			return d.eof(err)
+		}
+	}
+
+	if !d.valueExpected() || c == bracketClose || c == curlyClose {
+		return newSyntaxError(d.offset, "expected a value")
+	}
+
+	start := d.offset
+	raw, err := d.readValue(c)
+	if err != nil {
+		return err
+	}
+
+	node, err := Unmarshal(raw)
+	if err != nil {
+		var serr *SyntaxError
+		if errors.As(err, &serr) {
+			serr.Offset += start
+		}
+
+		return err
+	}
+
+	d.valueDone()
+	*v = node
+	return nil
+}
+
+// More reports whether there is another element in the current array or object,
+// or another value in the stream at the top level.
+func (d *Decoder) More() bool {
+	if d.err != nil {
+		return false
+	}
+
+	c, err := d.peek()
+	return err == nil && c != bracketClose && c != curlyClose
+}
+
+// Token returns the next token of the stream: the brackets of arrays and objects,
+// the keys, and the scalar values. Commas and colons are validated but not returned.
+//
+// At the end of the stream, it returns io.EOF. Once an error is returned,
+// all subsequent calls of Token and Decode return the same error.
+func (d *Decoder) Token() (Token, error) {
+	if d.err != nil {
+		return Token{}, d.err
+	}
+
+	tok, err := d.token()
+	if err != nil {
+		d.err = err
+	}
+
+	return tok, err
+}
+
+func (d *Decoder) token() (Token, error) {
+	for {
+		c, err := d.peek()
+		if err != nil {
+			return Token{}, d.eof(err)
+		}
+
+		switch c {
+		case bracketOpen, curlyOpen:
+			if !d.valueExpected() {
+				return Token{}, newSyntaxError(d.offset, "unexpected token")
+			}
+
+			if len(d.stack) >= maxNestingDepth {
+				return Token{}, ErrMaxNesting
+			}
+
+			d.skip()
+			d.stack = append(d.stack, c)
+			if c == bracketOpen {
+				d.state = decodeArrayStart
+				return Token{Type: TokenArrayStart, Value: []byte{c}}, nil
+			}
+
+			d.state = decodeObjectStart
+			return Token{Type: TokenObjectStart, Value: []byte{c}}, nil
+
+		case bracketClose, curlyClose:
+			ok := c == bracketClose && (d.state == decodeArrayStart || d.state == decodeArrayValue) ||
+				c == curlyClose && (d.state == decodeObjectStart || d.state == decodeObjectValue)
+			if !ok {
+				return Token{}, newSyntaxError(d.offset, "unexpected token")
+			}
+
+			d.skip()
+			d.stack = d.stack[:len(d.stack)-1]
+			d.valueDone()
+
+			if c == bracketClose {
+				return Token{Type: TokenArrayEnd, Value: []byte{c}}, nil
+			}
+
+			return Token{Type: TokenObjectEnd, Value: []byte{c}}, nil
+
+		case comma:
+			switch d.state {
+			case decodeArrayValue:
+				d.state = decodeArrayComma
+			case decodeObjectValue:
+				d.state = decodeObjectComma
+			default:
+				return Token{}, newSyntaxError(d.offset, "unexpected token")
+			}
+
+			d.skip()
+
+		case colon:
+			if d.state != decodeObjectKey {
+				return Token{}, newSyntaxError(d.offset, "unexpected token")
+			}
+
+			d.skip()
+			d.state = decodeObjectColon
+
+		default:
+			key := d.state == decodeObjectStart || d.state == decodeObjectComma
+			if !key && !d.valueExpected() || key && c != doubleQuote {
+				return Token{}, newSyntaxError(d.offset, "unexpected token")
+			}
+
+			start := d.offset
+			raw, err := d.readValue(c)
+			if err != nil {
+				return Token{}, err
+			}
+
+			tok, err := scanScalar(raw, start)
+			if err != nil {
+				return Token{}, err
+			}
+
+			if key {
+				tok.Type = TokenKey
+				d.state = decodeObjectKey
+			} else {
+				d.valueDone()
+			}
+
+			return tok, nil
+		}
+	}
+}
+
+// scanScalar returns the token of the raw scalar value read at the given offset of the stream.
+func scanScalar(raw []byte, offset int) (Token, error) {
+	s := NewScanner(raw)
+
+	tok, err := s.Next()
+	if err == nil {
+		err = s.end()
+	}
+
+	if err != nil {
+		var serr *SyntaxError
+		if errors.As(err, &serr) {
+			serr.Offset += offset
+		}
+
+		return Token{}, err
+	}
+
+	return tok, nil
+}
+
+func (d *Decoder) valueExpected() bool {
+	switch d.state {
+	case decodeTopValue, decodeArrayStart, decodeArrayComma, decodeObjectColon:
+		return true
+	default:
+		return false
+	}
+}
+
+// valueDone moves the state past a complete value.
+func (d *Decoder) valueDone() {
+	switch {
+	case len(d.stack) == 0:
+		d.state = decodeTopValue
+	case d.stack[len(d.stack)-1] == bracketOpen:
+		d.state = decodeArrayValue
+	default:
+		d.state = decodeObjectValue
+	}
+}
+
+// eof returns io.EOF at the end of a complete stream, or io.ErrUnexpectedEOF
+// in the middle of a value.
+func (d *Decoder) eof(err error) error {
+	if err != io.EOF {
+		return err
+	}
+
+	if len(d.stack) == 0 && d.state == decodeTopValue {
+		return io.EOF
+	}
+
+	return io.ErrUnexpectedEOF
+}
+
+// peek skips the whitespace and returns the next byte without consuming it.
+func (d *Decoder) peek() (byte, error) {
+	for {
+		c, err := d.r.ReadByte()
+		if err != nil {
+			return 0, err
+		}
+
+		switch c {
+		case whiteSpace, tab, newLine, carriageReturn:
+			d.offset++
+		default:
+			return c, d.r.UnreadByte()
+		}
+	}
+}
+
+// skip consumes the byte returned by peek.
+func (d *Decoder) skip() {
+	_, _ = d.r.ReadByte()
+	d.offset++
+}
+
+// readValue reads the bytes of the value starting with c, the byte returned by peek.
+// It only finds where the value ends, the bytes are validated by the caller.
+func (d *Decoder) readValue(c byte) ([]byte, error) {
+	var (
+		raw   []byte
+		depth int
+	)
+
+	for {
+		if c == doubleQuote {
+			var err error
+			if raw, err = d.readString(raw); err != nil {
+				return nil, err
+			}
+		} else if isScalarByte(c) {
+			for isScalarByte(c) {
+				d.skip()
+				raw = append(raw, c)
+
+				var err error
+				if c, err = d.r.ReadByte(); err != nil {
+					if err != io.EOF {
+						return nil, err
+					}
+
+					break
+				}
+
+				if err = d.r.UnreadByte(); err != nil {
+					return nil, err
+				}
+			}
+		} else {
+			switch c {
+			case bracketOpen, curlyOpen:
+				depth++
+			case bracketClose, curlyClose:
+				depth--
+			}
+
+			d.skip()
+			raw = append(raw, c)
+		}
+
+		if depth <= 0 {
+			return raw, nil
+		}
+
+		var err error
+		if c, err = d.r.ReadByte(); err != nil {
+			if err == io.EOF {
+				return nil, io.ErrUnexpectedEOF
+			}
+
+			return nil, err
+		}
+
+		if err = d.r.UnreadByte(); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// readString appends the string literal starting at the next byte to raw.
+func (d *Decoder) readString(raw []byte) ([]byte, error) {
+	d.skip()
+	raw = append(raw, doubleQuote)
+
+	for escaped := false; ; {
+		c, err := d.r.ReadByte()
+		if err != nil {
+			if err == io.EOF {
+				return nil, io.ErrUnexpectedEOF
+			}
+
+			return nil, err
+		}
+
+		d.offset++
+		raw = append(raw, c)
+
+		switch {
+		case escaped:
+			escaped = false
+		case c == backSlash:
+			escaped = true
+		case c == doubleQuote:
+			return raw, nil
+		}
+	}
+}
+
+// isScalarByte reports whether c may be part of a number or a literal.
+// The literal is validated after it is read.
+func isScalarByte(c byte) bool {
+	return 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9' || c == '+' || c == '-' || c == '.'
+}
@@ -0,0 +1,364 @@
+package json
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func TestUnmarshalArrayStream(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected []string
+	}{
+		{name: "empty", input: ` [ ] `},
+		{name: "scalars", input: `[1, "a\"b", true, null, -2.5e3]`, expected: []string{`1`, `"a\"b"`, `true`, `null`, `-2.5e3`}},
+		{name: "containers", input: `[{"a": [1, {"b": 2}]}, [], [[3]]]`, expected: []string{`{"a": [1, {"b": 2}]}`, `[]`, `[[3]]`}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			err := UnmarshalArrayStream([]byte(tt.input), func(index int, elem *Node) error {
+				if index != len(got) {
+					t.Errorf("index = %d, want %d", index, len(got))
+				}
+
+				if elem.prev != nil {
+					t.Errorf("element must be a root node")
+				}
+
+				got = append(got, elem.String())
+				return nil
+			})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if strings.Join(got, " ") != strings.Join(tt.expected, " ") {
+				t.Errorf("elements = %v, want %v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestUnmarshalArrayStream_Values(t *testing.T) {
+	data := []byte(`[{"id": "a", "price": 10.5}, {"id": "b", "price": 2}]`)
+
+	total := 0.0
+	err := UnmarshalArrayStream(data, func(index int, elem *Node) error {
+		total += elem.MustKey("price").MustNumeric()
+		return nil
+	})
+	if err != nil || total != 12.5 {
+		t.Errorf("total = %v, err = %v", total, err)
+	}
+}
+
+func TestUnmarshalArrayStream_Fail(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		count int // count is the number of elements passed to the callback before the error.
+		err   error
+	}{
+		{name: "object root", input: `{"a": 1}`},
+		{name: "scalar root", input: `1`},
+		{name: "empty", input: ``, err: ErrEmptyDocument},
+		{name: "truncated", input: `[1, {"a": 2`, count: 1, err: io.ErrUnexpectedEOF},
+		{name: "malformed element", input: `[1, tru, 3]`, count: 1, err: ErrSyntax},
+		{name: "trailing comma", input: `[1, 2,]`, count: 2, err: ErrSyntax},
+		{name: "trailing data", input: `[1] 2`, count: 1, err: ErrSyntax},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			count := 0
+			err := UnmarshalArrayStream([]byte(tt.input), func(int, *Node) error {
+				count++
+				return nil
+			})
+
+			if err == nil {
+				t.Fatalf("expected error")
+			}
+
+			if tt.err != nil && !errors.Is(err, tt.err) {
+				t.Errorf("expected %v, got %v", tt.err, err)
+			}
+
+			if count != tt.count {
+				t.Errorf("count = %d, want %d", count, tt.count)
+			}
+		})
+	}
+}
+
+func TestUnmarshalArrayStream_CallbackError(t *testing.T) {
+	count := 0
+	err := UnmarshalArrayStream([]byte(`[1, 2, 3, invalid`), func(index int, elem *Node) error {
+		count++
+		if index == 1 {
+			return errStop
+		}
+
+		return nil
+	})
+
+	if err != errStop || count != 2 {
+		t.Errorf("expected to stop at the second element, got err=%v, count=%d", err, count)
+	}
+}
+
+func TestUnmarshalObjectStream(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected []string
+	}{
+		{name: "empty", input: ` { } `},
+		{name: "scalars", input: `{"a": 1, "b\u00e9": "x", "c": null}`, expected: []string{`a=1`, `bé="x"`, `c=null`}},
+		{name: "containers", input: `{"a": {"b": [1, {"c": 2}]}, "d": []}`, expected: []string{`a={"b": [1, {"c": 2}]}`, `d=[]`}},
+		{name: "duplicate keys", input: `{"a": 1, "a": 2}`, expected: []string{`a=1`, `a=2`}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			err := UnmarshalObjectStream([]byte(tt.input), func(key string, value *Node) error {
+				if value.Key() != key || value.prev != nil {
+					t.Errorf("value of %q has wrong references", key)
+				}
+
+				got = append(got, key+"="+value.String())
+				return nil
+			})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if strings.Join(got, " ") != strings.Join(tt.expected, " ") {
+				t.Errorf("members = %v, want %v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestUnmarshalObjectStream_Fail(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		count int
+		err   error
+	}{
+		{name: "array root", input: `[1]`},
+		{name: "empty", input: ``, err: ErrEmptyDocument},
+		{name: "truncated", input: `{"a": 1, "b": [2`, count: 1, err: io.ErrUnexpectedEOF},
+		{name: "missing value", input: `{"a": 1, "b"}`, count: 1, err: ErrSyntax},
+		{name: "trailing data", input: `{"a": 1}}`, count: 1, err: ErrSyntax},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			count := 0
+			err := UnmarshalObjectStream([]byte(tt.input), func(string, *Node) error {
+				count++
+				return nil
+			})
+
+			if err == nil {
+				t.Fatalf("expected error")
+			}
+
+			if tt.err != nil && !errors.Is(err, tt.err) {
+				t.Errorf("expected %v, got %v", tt.err, err)
+			}
+
+			if count != tt.count {
+				t.Errorf("count = %d, want %d", count, tt.count)
+			}
+		})
+	}
+
+	err := UnmarshalObjectStream([]byte(`{"a": 1, "b": 2, "c": invalid`), func(key string, _ *Node) error {
+		if key == "b" {
+			return errStop
+		}
+
+		return nil
+	})
+	if err != errStop {
+		t.Errorf("expected callback error, got %v", err)
+	}
+}
+
+func TestDecoder_More(t *testing.T) {
+	dec := NewDecoder(strings.NewReader(` [{"id": 1}, {"id": [2, "]"]} ,{"id": 3}] `))
+
+	if tok, err := dec.Token(); err != nil || tok.Type != TokenArrayStart {
+		t.Fatalf("Token() = %v, %v, want array-start", tok, err)
+	}
+
+	var got []string
+	for dec.More() {
+		var node *Node
+		if err := dec.Decode(&node); err != nil {
+			t.Fatalf("Decode returns error: %v", err)
+		}
+
+		got = append(got, node.MustKey("id").String())
+	}
+
+	if expected := `1|[2, "]"]|3`; strings.Join(got, "|") != expected {
+		t.Errorf("decoded ids = %v, want %s", got, expected)
+	}
+
+	if tok, err := dec.Token(); err != nil || tok.Type != TokenArrayEnd {
+		t.Fatalf("Token() = %v, %v, want array-end", tok, err)
+	}
+
+	if dec.More() {
+		t.Errorf("More must be false at the end of the stream")
+	}
+
+	if _, err := dec.Token(); err != io.EOF {
+		t.Errorf("Token() at the end = %v, want io.EOF", err)
+	}
+}
+
+func TestDecoder_Stream(t *testing.T) {
+	dec := NewDecoder(iotest.OneByteReader(strings.NewReader("{\"a\": 1}\n[true]\n\"x\" 12.5 null")))
+
+	var got []string
+	for {
+		var node *Node
+		err := dec.Decode(&node)
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			t.Fatalf("Decode returns error: %v", err)
+		}
+
+		got = append(got, node.String())
+	}
+
+	if expected := `{"a": 1}|[true]|"x"|12.5|null`; strings.Join(got, "|") != expected {
+		t.Errorf("decoded values = %v, want %s", got, expected)
+	}
+}
+
+func TestDecoder_Token(t *testing.T) {
+	dec := NewDecoder(strings.NewReader(`{"a": [1, "x\"y", true, null], "b": {}} 7`))
+
+	var got []string
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			t.Fatalf("Token returns error: %v", err)
+		}
+
+		got = append(got, tok.Type.String()+" "+string(tok.Value))
+	}
+
+	expected := []string{
+		"object-start {", "key a", "array-start [", "number 1", `string x"y`, "bool true", "null null",
+		"array-end ]", "key b", "object-start {", "object-end }", "object-end }", "number 7",
+	}
+
+	if strings.Join(got, ", ") != strings.Join(expected, ", ") {
+		t.Errorf("tokens = %v, want %v", got, expected)
+	}
+}
+
+func TestDecoder_TokenAndDecode(t *testing.T) {
+	dec := NewDecoder(strings.NewReader(`{"items": [1, {"b": 2}], "next": "x"}`))
+
+	for _, expected := range []TokenType{TokenObjectStart, TokenKey} {
+		if tok, err := dec.Token(); err != nil || tok.Type != expected {
+			t.Fatalf("Token() = %v, %v, want %s", tok, err, expected)
+		}
+	}
+
+	// decode the value of the member after its key.
+	var items *Node
+	if err := dec.Decode(&items); err != nil {
+		t.Fatalf("Decode returns error: %v", err)
+	}
+
+	if items.String() != `[1, {"b": 2}]` {
+		t.Errorf("items = %s", items)
+	}
+
+	if tok, err := dec.Token(); err != nil || tok.Type != TokenKey || string(tok.Value) != "next" {
+		t.Fatalf("Token() = %v, %v, want key next", tok, err)
+	}
+
+	var next *Node
+	if err := dec.Decode(&next); err != nil || next.MustString() != "x" {
+		t.Fatalf("Decode() = %v, %v, want x", next, err)
+	}
+
+	if dec.More() {
+		t.Errorf("More must be false before the closing bracket")
+	}
+}
+
+func TestDecoder_Fail(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		err   error
+	}{
+		{"unterminated array", `[1, 2`, io.ErrUnexpectedEOF},
+		{"unterminated string", `["abc`, io.ErrUnexpectedEOF},
+		{"missing comma", `[1 2]`, nil},
+		{"trailing comma", `[1,]`, nil},
+		{"invalid literal", `[tru]`, nil},
+		{"mismatched bracket", `[1}`, nil},
+		{"missing colon", `{"a" 1}`, nil},
+		{"non-string key", `{1: 2}`, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dec := NewDecoder(strings.NewReader(tt.input))
+
+			var err error
+			for err == nil {
+				_, err = dec.Token()
+			}
+
+			if err == io.EOF {
+				t.Fatalf("Token must fail before the end of %q", tt.input)
+			}
+
+			if tt.err != nil && !errors.Is(err, tt.err) {
+				t.Errorf("Token() error = %v, want %v", err, tt.err)
+			}
+
+			if _, again := dec.Token(); again != err {
+				t.Errorf("Token must keep returning %v, got %v", err, again)
+			}
+		})
+	}
+
+	var node *Node
+	dec := NewDecoder(strings.NewReader(`[1, {"a": }]`))
+	if _, err := dec.Token(); err != nil {
+		t.Fatalf("Token returns error: %v", err)
+	}
+
+	if err := dec.Decode(&node); err != nil {
+		t.Fatalf("Decode returns error: %v", err)
+	}
+
+	var serr *SyntaxError
+	if err := dec.Decode(&node); !errors.As(err, &serr) || serr.Offset < 4 {
+		t.Errorf("Decode() error = %v, want a *SyntaxError in the second element", err)
+	}
+}
@@ -38,6 +38,14 @@ var (
 	trueLiteral  = []byte("true")
 	falseLiteral = []byte("false")
 	nullLiteral  = []byte("null")
+
+	// byteOrderMark is the UTF-8 encoded byte order mark (U+FEFF)
+	byteOrderMark = []byte("\xef\xbb\xbf")
+
+	// non-standard literals, see Options.AllowInfNaN
+	nanLiteral         = []byte("NaN")
+	infinityLiteral    = []byte("Infinity")
+	negInfinityLiteral = []byte("-Infinity")
 )
 
 type ValueType int
@@ -62,6 +70,8 @@ func (v ValueType) String() string {
 		return "string"
 	case Number:
 		return "number"
+	case Float:
+		return "float"
 	case Object:
 		return "object"
 	case Array: